@@ -89,6 +89,8 @@ const (
 	CodeSQLUniqueConstraint
 	CodeSQLConflict
 	CodeSQLNoRowsAffected
+	CodeSQLMigration
+	CodeSQLCircuitOpen
 )
 
 const (
@@ -206,6 +208,18 @@ const (
 	CodeCacheLockNotAcquired
 	CodeCacheInvalidCastType
 	CodeCacheNotFound
+	CodeCacheIncrement
+	CodeCacheAddSetMember
+	CodeCacheGetSetMembers
+	CodeCacheAddSortedSetMember
+	CodeCacheGetSortedSetRange
+	CodeCachePushList
+	CodeCachePopList
+	CodeCacheGetExpiration
+	CodeCachePipeline
+	CodeCachePublish
+	CodeCacheSubscribe
+	CodeFailedRefreshLock
 )
 
 const (
@@ -289,6 +303,8 @@ var ErrorMessages = AppMessage{
 	CodeSQLUniqueConstraint:   ErrMsgConflict,
 	CodeSQLConflict:           ErrMsgConflict,
 	CodeSQLNoRowsAffected:     ErrMsgInternalServerError,
+	CodeSQLMigration:          ErrMsgInternalServerError,
+	CodeSQLCircuitOpen:        ErrMsgServiceUnavailable,
 
 	CodeClient:                ErrMsgInternalServerError,
 	CodeClientMarshal:         ErrMsgInternalServerError,
@@ -338,6 +354,19 @@ var ErrorMessages = AppMessage{
 	CodeCacheInvalidCastType: ErrMsgInternalServerError,
 	CodeCacheNotFound:        ErrMsgInternalServerError,
 
+	CodeCacheIncrement:          ErrMsgInternalServerError,
+	CodeCacheAddSetMember:       ErrMsgInternalServerError,
+	CodeCacheGetSetMembers:      ErrMsgInternalServerError,
+	CodeCacheAddSortedSetMember: ErrMsgInternalServerError,
+	CodeCacheGetSortedSetRange:  ErrMsgInternalServerError,
+	CodeCachePushList:           ErrMsgInternalServerError,
+	CodeCachePopList:            ErrMsgInternalServerError,
+	CodeCacheGetExpiration:      ErrMsgInternalServerError,
+	CodeCachePipeline:           ErrMsgInternalServerError,
+	CodeCachePublish:            ErrMsgInternalServerError,
+	CodeCacheSubscribe:          ErrMsgInternalServerError,
+	CodeFailedRefreshLock:       ErrMsgInternalServerError,
+
 	CodeErrorHttpNewRequest: ErrMsgInternalServerError,
 	CodeErrorHttpDo:         ErrMsgInternalServerError,
 	CodeErrorIoutilReadAll:  ErrMsgInternalServerError,
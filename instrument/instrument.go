@@ -35,6 +35,42 @@ type Interface interface {
 	SchedulerRunningTimer(schedulername string) *prometheus.Timer
 }
 
+// InterfaceV2 extends Interface with metrics added after v1.0. Interface is
+// frozen, so the value returned by Init implements InterfaceV2 and callers
+// that need the newer metrics type-assert to it.
+type InterfaceV2 interface {
+	Interface
+	// DatabaseTxCounter counts transaction outcomes (commit, rollback, retry).
+	DatabaseTxCounter(dbname, conntype, txname, outcome string)
+	// DatabaseCircuitBreakerState records a circuit breaker moving to state
+	// (closed, half_open or open).
+	DatabaseCircuitBreakerState(dbname, conntype, state string)
+	// CacheCounter counts an event (hit, miss or eviction) of an in-process
+	// cache.
+	CacheCounter(cachename, event string)
+}
+
+// Circuit breaker states reported to DatabaseCircuitBreakerState, with the
+// value of the db_circuit_breaker_state gauge.
+const (
+	CircuitBreakerClosed   = "closed"
+	CircuitBreakerHalfOpen = "half_open"
+	CircuitBreakerOpen     = "open"
+)
+
+// In-process cache events reported to CacheCounter.
+const (
+	CacheHit      = "hit"
+	CacheMiss     = "miss"
+	CacheEviction = "eviction"
+)
+
+var circuitBreakerStateValues = map[string]float64{
+	CircuitBreakerClosed:   0,
+	CircuitBreakerHalfOpen: 1,
+	CircuitBreakerOpen:     2,
+}
+
 type instrument struct {
 	cfg               Config
 	prome             promeRegistry
@@ -42,6 +78,10 @@ type instrument struct {
 	responseStatus    *prometheus.CounterVec
 	requestDuration   *prometheus.HistogramVec
 	dbQueryDuration   *prometheus.HistogramVec
+	dbTxTotal         *prometheus.CounterVec
+	dbBreakerState    *prometheus.GaugeVec
+	dbBreakerTotal    *prometheus.CounterVec
+	cacheTotal        *prometheus.CounterVec
 	schedulerTotal    *prometheus.CounterVec
 	schedulerDuration *prometheus.HistogramVec
 }
@@ -93,6 +133,34 @@ func Init(cfg Config) Interface {
 		},
 		[]string{"database", "connection_type", "query_name"},
 	)
+	instr.dbTxTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_transaction_total",
+			Help: "Number of Database transaction outcomes",
+		},
+		[]string{"database", "connection_type", "tx_name", "outcome"},
+	)
+	instr.dbBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "db_circuit_breaker_state",
+			Help: "State of Database circuit breaker (0 closed, 1 half open, 2 open)",
+		},
+		[]string{"database", "connection_type"},
+	)
+	instr.dbBreakerTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_circuit_breaker_transitions_total",
+			Help: "Number of Database circuit breaker state changes",
+		},
+		[]string{"database", "connection_type", "state"},
+	)
+	instr.cacheTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_events_total",
+			Help: "Number of in-process cache hits, misses and evictions",
+		},
+		[]string{"cache", "event"},
+	)
 	instr.schedulerTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "scheduler_running_total",
@@ -113,6 +181,10 @@ func Init(cfg Config) Interface {
 		instr.responseStatus,
 		instr.requestDuration,
 		instr.dbQueryDuration,
+		instr.dbTxTotal,
+		instr.dbBreakerState,
+		instr.dbBreakerTotal,
+		instr.cacheTotal,
 		instr.schedulerTotal,
 		instr.schedulerDuration,
 	)
@@ -173,6 +245,32 @@ func (i *instrument) RegisterDBStats(db *sql.DB, dbname string) {
 	i.prome.registerer.MustRegister(collectors.NewDBStatsCollector(db, dbname))
 }
 
+// DatabaseTxCounter increments the transaction counter for the given outcome.
+func (i *instrument) DatabaseTxCounter(dbname, conntype, txname, outcome string) {
+	if !i.cfg.Metrics.Enabled {
+		return
+	}
+	i.dbTxTotal.WithLabelValues(dbname, conntype, txname, outcome).Inc()
+}
+
+// DatabaseCircuitBreakerState sets the circuit breaker state gauge and counts
+// the transition.
+func (i *instrument) DatabaseCircuitBreakerState(dbname, conntype, state string) {
+	if !i.cfg.Metrics.Enabled {
+		return
+	}
+	i.dbBreakerState.WithLabelValues(dbname, conntype).Set(circuitBreakerStateValues[state])
+	i.dbBreakerTotal.WithLabelValues(dbname, conntype, state).Inc()
+}
+
+// CacheCounter increments the counter of the cache event.
+func (i *instrument) CacheCounter(cachename, event string) {
+	if !i.cfg.Metrics.Enabled {
+		return
+	}
+	i.cacheTotal.WithLabelValues(cachename, event).Inc()
+}
+
 // SchedulerRunningCounter increments the running-scheduler counter.
 func (i *instrument) SchedulerRunningCounter(schedulername string) {
 	if !i.cfg.Metrics.Enabled {
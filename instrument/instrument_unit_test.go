@@ -29,6 +29,7 @@ func Test_instrument_Init_Enabled(t *testing.T) {
 	assert.NotNil(t, i.responseStatus)
 	assert.NotNil(t, i.requestDuration)
 	assert.NotNil(t, i.dbQueryDuration)
+	assert.NotNil(t, i.dbTxTotal)
 	assert.NotNil(t, i.schedulerTotal)
 	assert.NotNil(t, i.schedulerDuration)
 }
@@ -106,6 +107,47 @@ func Test_instrument_DatabaseQueryTimer_Unit(t *testing.T) {
 	})
 }
 
+func Test_instrument_DatabaseTxCounter_Unit(t *testing.T) {
+	t.Run("disabled is a no-op", func(t *testing.T) {
+		Init(Config{}).(InterfaceV2).DatabaseTxCounter("db", "leader", "tx", "commit")
+	})
+	t.Run("enabled increments", func(t *testing.T) {
+		i := Init(Config{Metrics: MetricsConfig{Enabled: true}}).(*instrument)
+		i.DatabaseTxCounter("testdb", "leader", "txTransfer", "retry")
+		i.DatabaseTxCounter("testdb", "leader", "txTransfer", "retry")
+		assert.Equal(t, float64(2), testutil.ToFloat64(i.dbTxTotal.WithLabelValues("testdb", "leader", "txTransfer", "retry")))
+	})
+}
+
+func Test_instrument_DatabaseCircuitBreakerState_Unit(t *testing.T) {
+	t.Run("disabled is a no-op", func(t *testing.T) {
+		Init(Config{}).(InterfaceV2).DatabaseCircuitBreakerState("db", "leader", CircuitBreakerOpen)
+	})
+	t.Run("enabled sets state and counts transitions", func(t *testing.T) {
+		i := Init(Config{Metrics: MetricsConfig{Enabled: true}}).(*instrument)
+		i.DatabaseCircuitBreakerState("testdb", "leader", CircuitBreakerOpen)
+		assert.Equal(t, float64(2), testutil.ToFloat64(i.dbBreakerState.WithLabelValues("testdb", "leader")))
+		i.DatabaseCircuitBreakerState("testdb", "leader", CircuitBreakerHalfOpen)
+		assert.Equal(t, float64(1), testutil.ToFloat64(i.dbBreakerState.WithLabelValues("testdb", "leader")))
+		i.DatabaseCircuitBreakerState("testdb", "leader", CircuitBreakerOpen)
+		assert.Equal(t, float64(2), testutil.ToFloat64(i.dbBreakerTotal.WithLabelValues("testdb", "leader", CircuitBreakerOpen)))
+	})
+}
+
+func Test_instrument_CacheCounter_Unit(t *testing.T) {
+	t.Run("disabled is a no-op", func(t *testing.T) {
+		Init(Config{}).(InterfaceV2).CacheCounter("config", CacheHit)
+	})
+	t.Run("enabled increments", func(t *testing.T) {
+		i := Init(Config{Metrics: MetricsConfig{Enabled: true}}).(*instrument)
+		i.CacheCounter("config", CacheHit)
+		i.CacheCounter("config", CacheHit)
+		i.CacheCounter("config", CacheEviction)
+		assert.Equal(t, float64(2), testutil.ToFloat64(i.cacheTotal.WithLabelValues("config", CacheHit)))
+		assert.Equal(t, float64(1), testutil.ToFloat64(i.cacheTotal.WithLabelValues("config", CacheEviction)))
+	})
+}
+
 func Test_instrument_RegisterDBStats_Unit(t *testing.T) {
 	t.Run("disabled is a no-op", func(t *testing.T) {
 		// With metrics disabled, no DB stats are registered and the empty *sql.DB
@@ -0,0 +1,120 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unitTestOrderFilter struct {
+	ID       []int64 `param:"order_id" db:"id"`
+	Status   string  `param:"order_status" db:"status"`
+	TotalGTE int64   `param:"total__gte" db:"total"`
+	Search   struct {
+		Note string `param:"note" db:"note"`
+		Ref  string `param:"ref" db:"ref"`
+	} `param:"search__or"`
+}
+
+type unitTestCustomerFilter struct {
+	Name   string `param:"customer_name" db:"name"`
+	Status string `param:"customer_status" db:"status"`
+}
+
+type unitTestReportParam struct {
+	Order    unitTestOrderFilter
+	Customer *unitTestCustomerFilter
+	Region   string   `param:"region" db:"region"`
+	SortBy   []string `param:"sort_by" db:"sort_by"`
+	Limit    int64    `param:"limit" db:"limit"`
+}
+
+func Test_sqlClausebuilder_AddAliasPrefixes(t *testing.T) {
+	param := &unitTestReportParam{
+		Order:    unitTestOrderFilter{ID: []int64{1, 2}, Status: "paid", TotalGTE: 100},
+		Customer: &unitTestCustomerFilter{Name: "%jo%", Status: "active"},
+		Region:   "id",
+		SortBy:   []string{"-customer_name,total__gte"},
+	}
+	param.Order.Search.Note = "%gift%"
+
+	db, _ := newMockSQLInterface(t)
+	qb := NewSQLQueryBuilder(db, "param", "db", nil).AddAliasPrefixes(map[string]interface{}{
+		"o": &param.Order,
+		"c": param.Customer,
+	})
+	got, gotArgs, _, _, err := qb.Build(param)
+	require.NoError(t, err)
+	// the order struct shares its address with param, yet region keeps no alias
+	assert.Equal(t, " WHERE 1=1 AND o.id IN (?, ?) AND o.status=? AND o.total>=? AND (o.note LIKE ?) AND c.name LIKE ? AND c.status=? AND region=? ORDER BY c.name DESC, o.total ASC LIMIT 0, 10;", got)
+	assert.Equal(t, []interface{}{int64(1), int64(2), "paid", int64(100), "%gift%", "%jo%", "active", "id"}, gotArgs)
+}
+
+func Test_sqlClausebuilder_Build_AmbiguousColumns(t *testing.T) {
+	t.Run("param of two columns", func(t *testing.T) {
+		type customer struct {
+			Status string `param:"status" db:"status"`
+		}
+		type order struct {
+			Status string `param:"status" db:"status"`
+		}
+		param := &struct {
+			Order    order
+			Customer customer
+		}{}
+
+		db, _ := newMockSQLInterface(t)
+		_, _, _, _, err := NewSQLQueryBuilder(db, "param", "db", nil).
+			AddAliasPrefixes(map[string]interface{}{"o": &param.Order, "c": &param.Customer}).
+			Build(param)
+		assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(err))
+	})
+
+	t.Run("column with and without alias", func(t *testing.T) {
+		param := &struct {
+			Order  unitTestOrderFilter
+			Status string `param:"status" db:"status"`
+		}{}
+
+		db, _ := newMockSQLInterface(t)
+		_, _, _, _, err := NewSQLQueryBuilder(db, "param", "db", nil).AddAliasPrefix("o", &param.Order).Build(param)
+		assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(err))
+	})
+
+	t.Run("same column of two aliases", func(t *testing.T) {
+		param := &unitTestReportParam{Customer: &unitTestCustomerFilter{}}
+
+		db, _ := newMockSQLInterface(t)
+		got, _, _, _, err := NewSQLQueryBuilder(db, "param", "db", nil).
+			AddAliasPrefixes(map[string]interface{}{"o": &param.Order, "c": param.Customer}).
+			Build(param)
+		require.NoError(t, err)
+		assert.Equal(t, " WHERE 1=1 LIMIT 0, 10;", got)
+	})
+}
+
+func Test_sqlClausebuilder_BuildUpdate_Aliased(t *testing.T) {
+	type update struct {
+		Status string `param:"status" db:"status"`
+	}
+	type where struct {
+		ID int64 `param:"id" db:"id"`
+	}
+
+	t.Run("aliased update", func(t *testing.T) {
+		u, w := &update{Status: "paid"}, &where{ID: 1}
+		db, _ := newMockSQLInterface(t)
+		_, _, err := NewSQLQueryBuilder(db, "param", "db", nil).AddAliasPrefix("o", u).BuildUpdate(u, w)
+		assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(err))
+	})
+
+	t.Run("aliased where", func(t *testing.T) {
+		u, w := &update{Status: "paid"}, &where{ID: 1}
+		db, _ := newMockSQLInterface(t)
+		_, _, err := NewSQLQueryBuilder(db, "param", "db", nil).AddAliasPrefix("o", w).BuildUpdate(u, w)
+		assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(err))
+	})
+}
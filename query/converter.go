@@ -3,6 +3,7 @@ package query
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/downsized-devs/sdk-go/null"
@@ -42,6 +43,18 @@ const (
 	TimeArr
 )
 
+// groupStart and groupEnd are passed to the builder function around the
+// fields of a grouped param struct, see isGroupParam.
+const (
+	groupStart int8 = -1 - iota
+	groupEnd
+)
+
+const (
+	groupOr  = "__or"
+	groupAnd = "__and"
+)
+
 const (
 	nullStringType = "null.String"
 	nullBoolType   = "null.Bool"
@@ -88,10 +101,7 @@ func traverseOnParam(paramTagName, dbTagName, fieldTagName, fieldName, paramTagV
 				if dbTagValue == "-" {
 					continue
 				}
-				var address string
-				if p.CanAddr() {
-					address = fmt.Sprint(p.Addr().Pointer())
-				}
+				address := aliasKey(p)
 				alias := aliasMap[address]
 				if alias != "" && dbTagValue != "" && address != "" {
 					dbTagValue = alias + "." + dbTagValue
@@ -102,6 +112,17 @@ func traverseOnParam(paramTagName, dbTagName, fieldTagName, fieldName, paramTagV
 					continue
 				}
 
+				if isGroupParam(paramTagValue, p.Field(i)) {
+					// a group belongs to the table of the struct holding it
+					if group := aliasKey(reflect.Indirect(p.Field(i))); alias != "" && group != "" && aliasMap[group] == "" {
+						aliasMap[group] = alias
+					}
+					builderFunc(groupStart, false, false, false, fieldName, paramTagValue, "", nil)
+					traverseOnParam(paramTagName, dbTagName, fieldTagName, fieldName+"."+getNameFromStructTagOrOriginalName(fieldTagName, p, i), paramTagValue, dbTagValue, aliasMap, p.Field(i), builderFunc)
+					builderFunc(groupEnd, false, false, false, fieldName, paramTagValue, "", nil)
+					continue
+				}
+
 				traverseOnParam(paramTagName, dbTagName, fieldTagName, fieldName+"."+getNameFromStructTagOrOriginalName(fieldTagName, p, i), paramTagValue, dbTagValue, aliasMap, p.Field(i), builderFunc)
 			}
 		}
@@ -220,6 +241,29 @@ func isNullType(e reflect.Value) bool {
 			e.Type().String() == nullDateType)
 }
 
+// aliasKey identifies an addressable struct in the alias map. The type tells
+// a struct apart from its first field, which shares its address.
+func aliasKey(v reflect.Value) string {
+	if !v.IsValid() || !v.CanAddr() {
+		return ""
+	}
+	return fmt.Sprintf("%d:%s", v.Addr().Pointer(), v.Type())
+}
+
+// isGroupParam reports whether the field holds a struct of conditions to be
+// joined together, with OR for a param tag ending in __or and with AND for
+// one ending in __and, e.g. `param:"search__or"`.
+func isGroupParam(paramTagValue string, e reflect.Value) bool {
+	if !strings.HasSuffix(paramTagValue, groupOr) && !strings.HasSuffix(paramTagValue, groupAnd) {
+		return false
+	}
+	t := e.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t.String() != timeType && !isNullType(reflect.Zero(t))
+}
+
 func getNameFromStructTagOrOriginalName(fieldName string, v reflect.Value, i int) string {
 	name := v.Type().Field(i).Tag.Get(fieldName)
 	if len(name) > 0 {
@@ -0,0 +1,204 @@
+package query
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+const (
+	CursorNext = "next"
+	CursorPrev = "prev"
+
+	defaultCursorColumn = "id"
+)
+
+var rowMapper = reflectx.NewMapperFunc("db", sqlx.NameMapper)
+
+// KeysetCursor is the Cursor of keyset pagination. It holds the keyset
+// columns of the page it was built for, so that a cursor is rejected once
+// the sort order changes, and the values of the row to seek from.
+type KeysetCursor struct {
+	Direction string
+	Columns   []string
+	Values    []interface{}
+}
+
+type encodedCursor struct {
+	Direction string        `json:"d"`
+	Columns   []string      `json:"c"`
+	Values    []cursorValue `json:"v"`
+}
+
+// cursorValue keeps the Go type of a value across the JSON round trip, so
+// that an int64 id is not sent back as a float64.
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+func (c *KeysetCursor) EncodeCursor() (string, error) {
+	enc := encodedCursor{Direction: c.Direction, Columns: c.Columns}
+	for i, v := range c.Values {
+		cv, err := encodeCursorValue(v)
+		if err != nil {
+			return "", errors.NewWithCode(codes.CodeInvalidValue, "cannot encode cursor column %s: %s", c.Columns[i], err.Error())
+		}
+		enc.Values = append(enc.Values, cv)
+	}
+
+	raw, err := json.Marshal(enc)
+	if err != nil {
+		return "", errors.NewWithCode(codes.CodeInvalidValue, "%s", err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func (c *KeysetCursor) DecodeCursor(v string) error {
+	raw, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return errors.NewWithCode(codes.CodeBadRequest, "invalid cursor: %s", err.Error())
+	}
+	var enc encodedCursor
+	if err := json.Unmarshal(raw, &enc); err != nil {
+		return errors.NewWithCode(codes.CodeBadRequest, "invalid cursor: %s", err.Error())
+	}
+	if (enc.Direction != CursorNext && enc.Direction != CursorPrev) || len(enc.Columns) == 0 || len(enc.Columns) != len(enc.Values) {
+		return errors.NewWithCode(codes.CodeBadRequest, "invalid cursor")
+	}
+
+	c.Direction, c.Columns, c.Values = enc.Direction, enc.Columns, nil
+	for _, cv := range enc.Values {
+		val, err := decodeCursorValue(cv)
+		if err != nil {
+			return errors.NewWithCode(codes.CodeBadRequest, "invalid cursor: %s", err.Error())
+		}
+		c.Values = append(c.Values, val)
+	}
+	return nil
+}
+
+func encodeCursorValue(v interface{}) (cursorValue, error) {
+	val, err := driver.DefaultParameterConverter.ConvertValue(v)
+	if err != nil {
+		return cursorValue{}, err
+	}
+	switch f := val.(type) {
+	case int64:
+		return cursorValue{Type: "i", Value: strconv.FormatInt(f, 10)}, nil
+	case float64:
+		return cursorValue{Type: "f", Value: strconv.FormatFloat(f, 'g', -1, 64)}, nil
+	case bool:
+		return cursorValue{Type: "b", Value: strconv.FormatBool(f)}, nil
+	case string:
+		return cursorValue{Type: "s", Value: f}, nil
+	case []byte:
+		return cursorValue{Type: "s", Value: string(f)}, nil
+	case time.Time:
+		return cursorValue{Type: "t", Value: f.Format(time.RFC3339Nano)}, nil
+	}
+	return cursorValue{}, fmt.Errorf("NULL cannot be used as a keyset value")
+}
+
+func decodeCursorValue(cv cursorValue) (interface{}, error) {
+	switch cv.Type {
+	case "i":
+		return strconv.ParseInt(cv.Value, 10, 64)
+	case "f":
+		return strconv.ParseFloat(cv.Value, 64)
+	case "b":
+		return strconv.ParseBool(cv.Value)
+	case "s":
+		return cv.Value, nil
+	case "t":
+		return time.Parse(time.RFC3339Nano, cv.Value)
+	}
+	return nil, fmt.Errorf("unknown value type %q", cv.Type)
+}
+
+type keysetColumn struct {
+	column string
+	desc   bool
+}
+
+func (k keysetColumn) String() string {
+	if k.desc {
+		return k.column + " DESC"
+	}
+	return k.column + " ASC"
+}
+
+// keysetPredicate returns the condition selecting the rows after values in
+// the order of cols, or before them when backward is set. Columns sorted in
+// the same direction compare as a row value, e.g. (a, b) > (?, ?); mixed
+// directions expand to (a > ? OR (a = ? AND b < ?)).
+func keysetPredicate(cols []keysetColumn, values []interface{}, backward bool) (string, []interface{}) {
+	op := func(c keysetColumn) string {
+		if c.desc != backward {
+			return "<"
+		}
+		return ">"
+	}
+
+	if len(cols) == 1 {
+		return cols[0].column + " " + op(cols[0]) + " ?", values
+	}
+
+	uniform := true
+	names := make([]string, 0, len(cols))
+	for _, c := range cols {
+		uniform = uniform && c.desc == cols[0].desc
+		names = append(names, c.column)
+	}
+	if uniform {
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(names, ", "), op(cols[0]), strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")), values
+	}
+
+	var (
+		ors  []string
+		args []interface{}
+	)
+	for i, c := range cols {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, cols[j].column+" = ?")
+			args = append(args, values[j])
+		}
+		ands = append(ands, c.column+" "+op(c)+" ?")
+		args = append(args, values[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+// keysetValues reads the value of every keyset column from row, a struct or
+// a pointer to one, matching the column name without its table alias to the
+// `db` tag.
+func keysetValues(row reflect.Value, cols []keysetColumn) ([]interface{}, error) {
+	row = reflect.Indirect(row)
+	if row.Kind() != reflect.Struct {
+		return nil, errors.NewWithCode(codes.CodeInvalidValue, "cursor rows must be structs, got %s", row.Type())
+	}
+
+	fields := rowMapper.TypeMap(row.Type())
+	values := make([]interface{}, 0, len(cols))
+	for _, c := range cols {
+		name := c.column[strings.LastIndex(c.column, ".")+1:]
+		fi := fields.GetByPath(name)
+		if fi == nil {
+			return nil, errors.NewWithCode(codes.CodeInvalidValue, "cursor rows have no `db:\"%s\"` field", name)
+		}
+		values = append(values, reflectx.FieldByIndexesReadOnly(row, fi.Index).Interface())
+	}
+	return values, nil
+}
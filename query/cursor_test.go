@@ -0,0 +1,264 @@
+package query
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/sql"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	mock_sql "github.com/downsized-devs/sdk-go/tests/mock/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type unitTestKeysetParam struct {
+	ID     int64    `param:"id" db:"id" cursorField:"id"`
+	Score  int64    `param:"score" db:"score" cursorField:"score"`
+	Name   string   `param:"name" db:"name" cursorField:"name"`
+	Status string   `param:"status" db:"status"`
+	SortBy []string `param:"sort_by" db:"sort_by"`
+	Limit  int64    `param:"limit" db:"limit"`
+	Cursor string   `param:"cursor" db:"cursor"`
+}
+
+type unitTestKeysetRow struct {
+	ID    int64  `db:"id"`
+	Score int64  `db:"score"`
+	Name  string `db:"name"`
+}
+
+func newTestCursor(t *testing.T, direction string, columns []string, values ...interface{}) string {
+	t.Helper()
+	c, err := (&KeysetCursor{Direction: direction, Columns: columns, Values: values}).EncodeCursor()
+	require.NoError(t, err)
+	return c
+}
+
+func TestKeysetCursor(t *testing.T) {
+	at := time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC)
+	in := &KeysetCursor{Direction: CursorPrev, Columns: []string{"a ASC", "b ASC", "c ASC", "d ASC", "e ASC", "f ASC"}, Values: []interface{}{int64(1) << 60, 1.5, "x,y", true, at, int32(3)}}
+	enc, err := in.EncodeCursor()
+	require.NoError(t, err)
+
+	var out KeysetCursor
+	require.NoError(t, out.DecodeCursor(enc))
+	assert.Equal(t, CursorPrev, out.Direction)
+	assert.Equal(t, in.Columns, out.Columns)
+	assert.Equal(t, []interface{}{int64(1) << 60, 1.5, "x,y", true, at, int64(3)}, out.Values)
+
+	_, err = (&KeysetCursor{Direction: CursorNext, Columns: []string{"a ASC"}, Values: []interface{}{nil}}).EncodeCursor()
+	assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(err))
+
+	for _, invalid := range []string{"%%%", "bm90IGpzb24", newTestCursor(t, "sideways", []string{"a ASC"}, 1)} {
+		assert.Equal(t, codes.CodeBadRequest, errors.GetCode(out.DecodeCursor(invalid)))
+	}
+}
+
+func Test_sqlClausebuilder_Build_Keyset(t *testing.T) {
+	scoreDesc := []string{"score DESC", "id DESC"}
+	tests := []struct {
+		name         string
+		param        unitTestKeysetParam
+		opt          *Option
+		rebind       func(string) string
+		want         string
+		wantArgs     []interface{}
+		wantCount    string
+		wantCountArg []interface{}
+		wantCode     codes.Code
+	}{
+		{
+			name:      "first page",
+			param:     unitTestKeysetParam{SortBy: []string{"-score"}, Limit: 2},
+			want:      " WHERE 1=1 ORDER BY score DESC, id DESC LIMIT 3;",
+			wantCount: " WHERE 1=1;",
+		},
+		{
+			name:      "unsortable and unknown params are skipped",
+			param:     unitTestKeysetParam{SortBy: []string{"status,-score,foo"}},
+			want:      " WHERE 1=1 ORDER BY score DESC, id DESC LIMIT 11;",
+			wantCount: " WHERE 1=1;",
+		},
+		{
+			name:         "next page",
+			param:        unitTestKeysetParam{SortBy: []string{"-score"}, Limit: 2, Cursor: newTestCursor(t, CursorNext, scoreDesc, int64(50), int64(7))},
+			want:         " WHERE 1=1 AND (score, id) < (?, ?) ORDER BY score DESC, id DESC LIMIT 3;",
+			wantArgs:     []interface{}{int64(50), int64(7)},
+			wantCount:    " WHERE 1=1;",
+			wantCountArg: []interface{}{},
+		},
+		{
+			name:         "prev page",
+			param:        unitTestKeysetParam{SortBy: []string{"-score"}, Limit: 2, Cursor: newTestCursor(t, CursorPrev, scoreDesc, int64(50), int64(7))},
+			want:         " WHERE 1=1 AND (score, id) > (?, ?) ORDER BY score ASC, id ASC LIMIT 3;",
+			wantArgs:     []interface{}{int64(50), int64(7)},
+			wantCount:    " WHERE 1=1;",
+			wantCountArg: []interface{}{},
+		},
+		{
+			name:  "mixed directions",
+			param: unitTestKeysetParam{SortBy: []string{"name,-score"}, Cursor: newTestCursor(t, CursorNext, []string{"name ASC", "score DESC", "id DESC"}, "b", int64(50), int64(7))},
+			want:  " WHERE 1=1 AND ((name > ?) OR (name = ? AND score < ?) OR (name = ? AND score = ? AND id < ?)) ORDER BY name ASC, score DESC, id DESC LIMIT 11;",
+			wantArgs: []interface{}{
+				"b", "b", int64(50), "b", int64(50), int64(7),
+			},
+			wantCount:    " WHERE 1=1;",
+			wantCountArg: []interface{}{},
+		},
+		{
+			name:         "tie-breaker only",
+			param:        unitTestKeysetParam{Limit: 3, Cursor: newTestCursor(t, CursorNext, []string{"id ASC"}, int64(7))},
+			want:         " WHERE 1=1 AND id > ? ORDER BY id ASC LIMIT 4;",
+			wantArgs:     []interface{}{int64(7)},
+			wantCount:    " WHERE 1=1;",
+			wantCountArg: []interface{}{},
+		},
+		{
+			name:         "custom tie-breaker sorted explicitly",
+			param:        unitTestKeysetParam{SortBy: []string{"-id"}, Cursor: newTestCursor(t, CursorNext, []string{"id DESC"}, int64(7))},
+			opt:          &Option{CursorColumn: "id", DisableLimit: true},
+			want:         " WHERE 1=1 AND id < ? ORDER BY id DESC;",
+			wantArgs:     []interface{}{int64(7)},
+			wantCount:    " WHERE 1=1;",
+			wantCountArg: []interface{}{},
+		},
+		{
+			name:         "postgres bindvars",
+			param:        unitTestKeysetParam{Status: "active", SortBy: []string{"-score"}, Limit: 2, Cursor: newTestCursor(t, CursorNext, scoreDesc, int64(50), int64(7))},
+			rebind:       func(q string) string { return sqlx.Rebind(sqlx.DOLLAR, q) },
+			want:         " WHERE 1=1 AND status=$1 AND (score, id) < ($2, $3) ORDER BY score DESC, id DESC LIMIT 3;",
+			wantArgs:     []interface{}{"active", int64(50), int64(7)},
+			wantCount:    " WHERE 1=1 AND status=$1;",
+			wantCountArg: []interface{}{"active"},
+		},
+		{
+			name:     "cursor from another sort order",
+			param:    unitTestKeysetParam{SortBy: []string{"name"}, Cursor: newTestCursor(t, CursorNext, scoreDesc, int64(50), int64(7))},
+			wantCode: codes.CodeBadRequest,
+		},
+		{
+			name:     "malformed cursor",
+			param:    unitTestKeysetParam{Cursor: "not-a-cursor"},
+			wantCode: codes.CodeBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			db := mock_sql.NewMockInterface(ctrl)
+			cmd := mock_sql.NewMockCommand(ctrl)
+			db.EXPECT().Leader().Return(cmd).AnyTimes()
+			rebind := tt.rebind
+			if rebind == nil {
+				rebind = func(q string) string { return q }
+			}
+			cmd.EXPECT().Rebind(gomock.Any()).DoAndReturn(rebind).AnyTimes()
+
+			got, gotArgs, gotCount, gotCountArgs, err := NewSQLQueryBuilder(db, "param", "db", tt.opt).Build(&tt.param)
+			if tt.wantCode != 0 {
+				assert.Equal(t, tt.wantCode, errors.GetCode(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantArgs, gotArgs)
+			assert.Equal(t, tt.wantCount, gotCount)
+			assert.Equal(t, tt.wantCountArg, gotCountArgs)
+		})
+	}
+}
+
+func Test_sqlClausebuilder_BuildCursors(t *testing.T) {
+	db, _ := newMockSQLInterface(t)
+	qb := NewSQLQueryBuilder(db, "param", "db", nil)
+	_, _, err := qb.BuildCursors([]unitTestKeysetRow{})
+	assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(err))
+
+	qb = NewSQLQueryBuilder(db, "param", "db", nil)
+	_, _, _, _, err = qb.Build(&unitTestKeysetParam{SortBy: []string{"-score"}, Limit: 2})
+	require.NoError(t, err)
+
+	next, prev, err := qb.BuildCursors(&[]unitTestKeysetRow{})
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	assert.Empty(t, prev)
+
+	_, _, err = qb.BuildCursors(&[]struct{ Other int64 }{{Other: 1}, {Other: 2}, {Other: 3}})
+	assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(err))
+	_, _, err = qb.BuildCursors([]unitTestKeysetRow{})
+	assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(err))
+
+	// the row past the limit only tells that a next page exists
+	rows := []*unitTestKeysetRow{{ID: 3, Score: 90}, {ID: 1, Score: 80}, {ID: 2, Score: 80}}
+	next, prev, err = qb.BuildCursors(&rows)
+	require.NoError(t, err)
+	assert.Empty(t, prev)
+	assert.Len(t, rows, 2)
+	var cursor KeysetCursor
+	require.NoError(t, cursor.DecodeCursor(next))
+	assert.Equal(t, KeysetCursor{Direction: CursorNext, Columns: []string{"score DESC", "id DESC"}, Values: []interface{}{int64(80), int64(1)}}, cursor)
+}
+
+// newTestItemDB returns a sqlite database with an item table of 7 rows.
+func newTestItemDB(t *testing.T) sql.Interface {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	log := mock_log.NewMockInterface(ctrl)
+	log.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	db := sql.Init(sql.Config{
+		Driver:                      "sqlite3",
+		Leader:                      sql.ConnConfig{DB: filepath.Join(t.TempDir(), "item.db")},
+		FollowerHealthCheckInterval: -1,
+	}, log, nil)
+	t.Cleanup(db.Stop)
+
+	ctx := context.Background()
+	_, err := db.Leader().Exec(ctx, "createItem", `CREATE TABLE item (id INTEGER PRIMARY KEY, score INTEGER NOT NULL, name TEXT NOT NULL)`)
+	require.NoError(t, err)
+	_, err = db.Leader().Exec(ctx, "insertItem", `INSERT INTO item (id, score, name) VALUES (1, 50, 'a'), (2, 70, 'b'), (3, 50, 'c'), (4, 90, 'd'), (5, 70, 'e'), (6, 10, 'f'), (7, 50, 'g')`)
+	require.NoError(t, err)
+	return db
+}
+
+// TestKeyset_Sqlite pages through a real table forward and back again.
+func TestKeyset_Sqlite(t *testing.T) {
+	db := newTestItemDB(t)
+	ctx := context.Background()
+
+	page := func(cursor string) ([]int64, string, string) {
+		t.Helper()
+		qb := NewSQLQueryBuilder(db, "param", "db", nil)
+		where, args, _, _, err := qb.Build(&unitTestKeysetParam{SortBy: []string{"-score"}, Limit: 3, Cursor: cursor})
+		require.NoError(t, err)
+		rows, err := sql.Select[unitTestKeysetRow](ctx, db.Leader(), "listItem", "SELECT id, score, name FROM item"+where, args...)
+		require.NoError(t, err)
+		next, prev, err := qb.BuildCursors(&rows)
+		require.NoError(t, err)
+
+		ids := []int64{}
+		for _, r := range rows {
+			ids = append(ids, r.ID)
+		}
+		return ids, next, prev
+	}
+
+	ids, next, prev := page("")
+	assert.Equal(t, []int64{4, 5, 2}, ids)
+	assert.Empty(t, prev)
+	ids, next, prev = page(next)
+	assert.Equal(t, []int64{7, 3, 1}, ids)
+	assert.NotEmpty(t, prev)
+	ids, last, _ := page(next)
+	assert.Equal(t, []int64{6}, ids)
+	assert.Empty(t, last)
+
+	ids, _, prev = page(prev)
+	assert.Equal(t, []int64{4, 5, 2}, ids)
+	assert.Empty(t, prev)
+}
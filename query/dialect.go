@@ -0,0 +1,112 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/downsized-devs/sdk-go/sql"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	driverMySQL    = "mysql"
+	driverPostgres = "postgres"
+	driverSQLite   = "sqlite3"
+)
+
+// identifier matches a column name with an optional table alias; anything
+// else in a db tag, like an expression, is written as it is.
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// plainIdentifier matches the names that every driver reads the same way
+// unquoted.
+var plainIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// dialect is what differs in the SQL written for each driver. Its zero
+// value, used when the driver is unknown, keeps the generic output:
+// unquoted identifiers, LIKE, `LIMIT offset, count` and bindvars rebound by
+// the leader connection.
+type dialect struct {
+	driver string
+}
+
+// newDialect returns the dialect of the driver behind db.
+func newDialect(db sql.Interface) dialect {
+	if db == nil {
+		return dialect{}
+	}
+	switch driver := sql.DriverName(db.Leader()); driver {
+	case driverMySQL, driverPostgres, driverSQLite:
+		return dialect{driver: driver}
+	}
+	return dialect{}
+}
+
+// quote quotes the parts of a column name that are reserved words or not
+// plain lower case names, e.g. o.order as o."order" and IsActive as
+// "IsActive", which postgres would otherwise fold to isactive.
+func (d dialect) quote(column string) string {
+	if d.driver == "" || !identifier.MatchString(column) {
+		return column
+	}
+	q := `"`
+	if d.driver == driverMySQL {
+		q = "`"
+	}
+	parts := strings.Split(column, ".")
+	for i, p := range parts {
+		if !plainIdentifier.MatchString(p) || reservedWords[p] {
+			parts[i] = q + p + q
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// reservedWords are the keywords, reserved in MySQL, Postgres or SQLite, that
+// are likely to name a column or table.
+var reservedWords = map[string]bool{
+	"all": true, "and": true, "any": true, "as": true, "asc": true,
+	"between": true, "both": true, "by": true, "case": true, "check": true,
+	"collate": true, "column": true, "constraint": true, "create": true,
+	"cross": true, "current_date": true, "current_time": true,
+	"current_timestamp": true, "current_user": true, "default": true,
+	"delete": true, "desc": true, "distinct": true, "drop": true, "else": true,
+	"end": true, "exists": true, "fetch": true, "for": true, "foreign": true,
+	"from": true, "full": true, "grant": true, "group": true, "having": true,
+	"in": true, "index": true, "inner": true, "insert": true, "interval": true,
+	"into": true, "is": true, "join": true, "key": true, "left": true,
+	"like": true, "limit": true, "lock": true, "natural": true, "not": true,
+	"null": true, "offset": true, "on": true, "only": true, "or": true,
+	"order": true, "outer": true, "primary": true, "range": true, "rank": true,
+	"references": true, "right": true, "row": true, "rows": true,
+	"select": true, "set": true, "table": true, "then": true, "to": true,
+	"union": true, "unique": true, "update": true, "user": true, "using": true,
+	"values": true, "when": true, "where": true, "window": true, "with": true,
+}
+
+// like is the case-insensitive LIKE operator. LIKE already ignores case in
+// MySQL's default collations and for ASCII in SQLite.
+func (d dialect) like() string {
+	if d.driver == driverPostgres {
+		return " ILIKE "
+	}
+	return " LIKE "
+}
+
+func (d dialect) limitOffset(limit, offset int64) string {
+	switch d.driver {
+	case driverPostgres, driverSQLite:
+		return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+	}
+	return fmt.Sprintf(" LIMIT %d, %d", offset, limit)
+}
+
+// rebind turns the `?` bindvars the builder writes into those of the driver,
+// e.g. $1, $2 for postgres.
+func (d dialect) rebind(db sql.Interface, query string) string {
+	if d.driver == "" {
+		return db.Leader().Rebind(query)
+	}
+	return sqlx.Rebind(sqlx.BindType(d.driver), query)
+}
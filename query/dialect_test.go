@@ -0,0 +1,206 @@
+package query
+
+import (
+	"context"
+	stdsql "database/sql"
+	"database/sql/driver"
+	goerr "errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/null"
+	"github.com/downsized-devs/sdk-go/sql"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// stubConnector opens connections that only answer pings, enough to create
+// a sql.Interface whose driver the builder reads.
+type stubConnector struct{}
+
+func (stubConnector) Connect(context.Context) (driver.Conn, error) { return stubConn{}, nil }
+func (stubConnector) Driver() driver.Driver                        { return stubDriver{} }
+
+type stubDriver struct{}
+
+func (stubDriver) Open(string) (driver.Conn, error) { return stubConn{}, nil }
+
+type stubConn struct{}
+
+func (stubConn) Prepare(string) (driver.Stmt, error) { return nil, goerr.New("stub connection") }
+func (stubConn) Close() error                        { return nil }
+func (stubConn) Begin() (driver.Tx, error)           { return nil, goerr.New("stub connection") }
+
+func newDialectTestDB(t *testing.T, driverName string) sql.Interface {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	log := mock_log.NewMockInterface(ctrl)
+	log.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+
+	db, err := sql.InitWithError(sql.Config{
+		Driver:                      driverName,
+		Leader:                      sql.ConnConfig{MockDB: stdsql.OpenDB(stubConnector{})},
+		FollowerHealthCheckInterval: -1,
+	}, log, nil)
+	require.NoError(t, err)
+	t.Cleanup(db.Stop)
+	return db
+}
+
+type dialectTestSearch struct {
+	Name  string `db:"name" param:"name"`
+	Email string `db:"email" param:"email"`
+}
+
+type dialectTestParam struct {
+	ID        []int64           `db:"id" param:"id"`
+	Name      string            `db:"name" param:"name" cursorField:"name"`
+	Score     int64             `db:"score" param:"score__gte" cursorField:"score"`
+	CreatedAt []time.Time       `db:"created_at" param:"created_at__between"`
+	DeletedAt null.Bool         `db:"deleted_at" param:"deleted_at__isnull"`
+	Search    dialectTestSearch `param:"search__or"`
+	SortBy    []string          `db:"sort_by" param:"sort_by"`
+	Page      int64             `db:"page" param:"page"`
+	Limit     int64             `db:"limit" param:"limit"`
+}
+
+type dialectTestCursorParam struct {
+	Score    int64    `db:"score" param:"score" cursorField:"score"`
+	MinScore int64    `db:"score" param:"score__gte"`
+	SortBy   []string `db:"sort_by" param:"sort_by"`
+	Limit    int64    `db:"limit" param:"limit"`
+	Cursor   string   `db:"cursor" param:"cursor"`
+}
+
+type dialectTestUpdate struct {
+	Name      null.String `db:"name" param:"name"`
+	Group     null.String `db:"group" param:"group"`
+	DeletedAt null.Time   `db:"deleted_at" param:"deleted_at"`
+}
+
+type dialectTestWhere struct {
+	ID    int64  `db:"id" param:"id"`
+	Email string `db:"email" param:"email__nin"`
+}
+
+func TestDialect_Golden(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	cursor, err := (&KeysetCursor{Direction: CursorNext, Columns: []string{"score DESC", "id DESC"}, Values: []interface{}{int64(90), int64(7)}}).EncodeCursor()
+	require.NoError(t, err)
+
+	cases := []struct {
+		name  string
+		build func(db sql.Interface) (string, error)
+	}{
+		{
+			name: "filters",
+			build: func(db sql.Interface) (string, error) {
+				return formatBuild(NewSQLQueryBuilder(db, "param", "db", &Option{IsActive: true}).Build(&dialectTestParam{
+					ID:        []int64{1, 2},
+					Name:      "%jo%",
+					Score:     10,
+					CreatedAt: []time.Time{from, to},
+					DeletedAt: null.BoolFrom(true),
+					Search:    dialectTestSearch{Name: "%jo%", Email: "jo@example.com"},
+					SortBy:    []string{"-name,id"},
+					Page:      3,
+					Limit:     20,
+				}))
+			},
+		},
+		{
+			name: "alias",
+			build: func(db sql.Interface) (string, error) {
+				param := &dialectTestParam{ID: []int64{1}, Score: 10}
+				return formatBuild(NewSQLQueryBuilder(db, "param", "db", nil).AddAliasPrefix("u", param).Build(param))
+			},
+		},
+		{
+			name: "cursor",
+			build: func(db sql.Interface) (string, error) {
+				return formatBuild(NewSQLQueryBuilder(db, "param", "db", nil).Build(&dialectTestCursorParam{
+					MinScore: 10,
+					SortBy:   []string{"-score"},
+					Limit:    20,
+					Cursor:   cursor,
+				}))
+			},
+		},
+		{
+			name: "update",
+			build: func(db sql.Interface) (string, error) {
+				query, args, err := NewSQLQueryBuilder(db, "param", "db", nil).BuildUpdate(
+					&dialectTestUpdate{Name: null.StringFrom("jo"), Group: null.StringFrom("admin"), DeletedAt: null.Time{SqlNull: true}},
+					&dialectTestWhere{ID: 7, Email: "jo@example.com"},
+				)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%s\n%v\n", query, args), nil
+			},
+		},
+	}
+
+	for _, driverName := range []string{"mysql", "postgres", "sqlite3"} {
+		t.Run(driverName, func(t *testing.T) {
+			db := newDialectTestDB(t, driverName)
+			var got strings.Builder
+			for _, tc := range cases {
+				out, err := tc.build(db)
+				require.NoError(t, err, tc.name)
+				fmt.Fprintf(&got, "-- %s\n%s", tc.name, out)
+			}
+
+			golden := filepath.Join("testdata", "dialect", driverName+".golden")
+			if *update {
+				require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0o755))
+				require.NoError(t, os.WriteFile(golden, []byte(got.String()), 0o600))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), got.String())
+		})
+	}
+}
+
+func formatBuild(query string, args []interface{}, countQuery string, countArgs []interface{}, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s\n%v\n%s\n%v\n", query, args, countQuery, countArgs), nil
+}
+
+func Test_dialect_quote(t *testing.T) {
+	tests := []struct {
+		driver string
+		column string
+		want   string
+	}{
+		{driver: "", column: "o.order", want: "o.order"},
+		{driver: driverMySQL, column: "o.order", want: "o.`order`"},
+		{driver: driverPostgres, column: "order", want: `"order"`},
+		{driver: driverSQLite, column: "user.Group", want: `"user"."Group"`},
+		{driver: driverPostgres, column: "u.id", want: "u.id"},
+		{driver: driverPostgres, column: "IsActive", want: `"IsActive"`},
+		{driver: driverMySQL, column: "u.createdAt", want: "u.`createdAt`"},
+		{driver: driverMySQL, column: "status", want: "status"},
+		{driver: driverPostgres, column: "LOWER(name)", want: "LOWER(name)"},
+		{driver: driverMySQL, column: "a.b.c", want: "a.b.c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.driver+" "+tt.column, func(t *testing.T) {
+			assert.Equal(t, tt.want, dialect{driver: tt.driver}.quote(tt.column))
+		})
+	}
+}
@@ -0,0 +1,342 @@
+package query
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/null"
+	"github.com/gin-gonic/gin"
+)
+
+// filterKey matches filter[column] and filter[column][operator].
+var filterKey = regexp.MustCompile(`^filter\[([A-Za-z0-9_]+)\](?:\[([a-z]+)\])?$`)
+
+// BindQuery fills param from the query string of the request, see
+// BindValues.
+func (s *sqlClausebuilder) BindQuery(c *gin.Context, param interface{}) error {
+	return s.BindValues(c.Request.URL.Query(), param)
+}
+
+// BindValues fills param, a pointer to a param struct, from query string
+// values such as
+//
+//	?filter[status]=active&filter[price][gte]=10&sort_by=-created_at&fields=id,name
+//
+// The param tags of the struct are the allow-list: filter[col] sets the
+// field tagged col, filter[col][op] the one tagged col__op, and sort_by and
+// fields only accept the names of those params. Anything else fails with
+// codes.CodeBadRequest. page, limit and cursor set the fields of the same
+// name. Values of slice fields may be repeated or comma separated. Other
+// query keys are ignored.
+func (s *sqlClausebuilder) BindValues(values url.Values, param interface{}) error {
+	p := reflect.ValueOf(param)
+	if p.Kind() != reflect.Pointer || p.IsNil() || p.Elem().Kind() != reflect.Struct {
+		return errors.NewWithCode(codes.CodeInvalidValue, "passed param should be a pointer to a struct and cannot be nil")
+	}
+
+	fields := map[string][]int{}
+	collectParamFields(s.paramTag, s.dbTag, p.Elem().Type(), nil, fields)
+
+	// sorted keys keep the first error stable
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// filter[id] and filter[id][in] add up to the same param
+	var filterNames []string
+	filters := map[string][]string{}
+	for _, key := range keys {
+		raw := values[key]
+		switch {
+		case filterKey.MatchString(key):
+			match := filterKey.FindStringSubmatch(key)
+			name := filterParamName(match[1], match[2])
+			if isSpecialParam(name) || fields[name] == nil {
+				return errors.NewWithCode(codes.CodeBadRequest, "unknown filter %s", key)
+			}
+			if _, ok := filters[name]; !ok {
+				filterNames = append(filterNames, name)
+			}
+			filters[name] = append(filters[name], raw...)
+		case isSortBy(key):
+			sortBy := normalizeSortBy(splitValues(raw))
+			for _, col := range sortBy {
+				name := strings.TrimPrefix(col, "-")
+				if isSpecialParam(name) || fields[name] == nil {
+					return errors.NewWithCode(codes.CodeBadRequest, "unknown sort column %s", name)
+				}
+			}
+			if err := setSpecialField(p.Elem(), fields, key, sortBy, isSortBy); err != nil {
+				return err
+			}
+		case isFields(key):
+			names := splitValues(raw)
+			for _, name := range names {
+				if isSpecialParam(name) || fields[name] == nil {
+					return errors.NewWithCode(codes.CodeBadRequest, "unknown field %s", name)
+				}
+			}
+			if err := setSpecialField(p.Elem(), fields, key, names, isFields); err != nil {
+				return err
+			}
+		case isPage(key), isLimit(key):
+			v, err := strconv.ParseInt(firstValue(raw), 10, 64)
+			if err != nil {
+				return errors.NewWithCode(codes.CodeBadRequest, "invalid value for %s: %s", key, err.Error())
+			}
+			if isLimit(key) {
+				v = validateLimit(v)
+			} else {
+				v = validatePage(v)
+			}
+			if err := setSpecialField(p.Elem(), fields, key, []string{strconv.FormatInt(v, 10)}, func(name string) bool { return name == key }); err != nil {
+				return err
+			}
+		case isCursor(key):
+			if err := setSpecialField(p.Elem(), fields, key, []string{firstValue(raw)}, isCursor); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range filterNames {
+		if err := setParamField(p.Elem(), fields[name], filters[name]); err != nil {
+			return errors.NewWithCode(codes.CodeBadRequest, "invalid value for filter %s: %s", name, err.Error())
+		}
+	}
+	return nil
+}
+
+// Fields returns the columns picked by the fields param, quoted for the
+// dialect, in the requested order. It is empty when no fields were asked
+// for, and filled by Build.
+func (s *sqlClausebuilder) Fields() []string {
+	return append([]string(nil), s.fields...)
+}
+
+// resolveFields maps the names of the fields param to their db columns once
+// every param has been traversed.
+func (s *sqlClausebuilder) resolveFields() error {
+	for _, name := range s.paramFields {
+		col := s.paramToDBMap[name]
+		if isSpecialParam(name) || col == "" {
+			return errors.NewWithCode(codes.CodeBadRequest, "unknown field %s", name)
+		}
+		s.fields = append(s.fields, s.dialect.quote(col))
+	}
+	return nil
+}
+
+func filterParamName(column, operator string) string {
+	switch operator {
+	case "", "eq", "in":
+		return column
+	}
+	return column + "__" + operator
+}
+
+func isSpecialParam(name string) bool {
+	return isPage(name) || isLimit(name) || isCursor(name) || isSortBy(name) || isFields(name)
+}
+
+// collectParamFields maps every param tag of t to the index path of its
+// field, descending into nested and grouped param structs.
+func collectParamFields(paramTag, dbTag string, t reflect.Type, parent []int, fields map[string][]int) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.String() == timeType || isNullType(reflect.Zero(t)) {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Tag.Get(dbTag) == "-" {
+			continue
+		}
+		index := append(append([]int(nil), parent...), i)
+		name := f.Tag.Get(paramTag)
+		if name == "" || isGroupParam(name, reflect.Zero(f.Type)) {
+			collectParamFields(paramTag, dbTag, f.Type, index, fields)
+			continue
+		}
+		if _, ok := fields[name]; !ok {
+			fields[name] = index
+		}
+	}
+}
+
+// setSpecialField sets the field of the first param matching is, failing
+// when the param struct has none.
+func setSpecialField(v reflect.Value, fields map[string][]int, key string, raw []string, is func(string) bool) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		if is(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return errors.NewWithCode(codes.CodeBadRequest, "unknown param %s", key)
+	}
+	sort.Strings(names)
+	if err := setParamField(v, fields[names[0]], raw); err != nil {
+		return errors.NewWithCode(codes.CodeBadRequest, "invalid value for %s: %s", key, err.Error())
+	}
+	return nil
+}
+
+// setParamField parses raw into the field at index, allocating the nil
+// pointers on the way.
+func setParamField(v reflect.Value, index []int, raw []string) error {
+	for _, i := range index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+
+	if v.Kind() == reflect.Slice {
+		items := splitValues(raw)
+		slice := reflect.MakeSlice(v.Type(), 0, len(items))
+		for _, item := range items {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setValue(elem, item); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+		v.Set(slice)
+		return nil
+	}
+	return setValue(v, firstValue(raw))
+}
+
+func setValue(v reflect.Value, raw string) error {
+	if v.Kind() == reflect.Pointer {
+		elem := reflect.New(v.Type().Elem())
+		if err := setValue(elem.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+
+	switch v.Interface().(type) {
+	case time.Time:
+		t, err := parseFilterTime(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case null.Time:
+		t, err := parseFilterTime(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(null.TimeFrom(t)))
+		return nil
+	case null.Date:
+		t, err := parseFilterTime(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(null.DateFrom(t)))
+		return nil
+	case null.String:
+		v.Set(reflect.ValueOf(null.StringFrom(raw)))
+		return nil
+	case null.Int64:
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(null.Int64From(i)))
+		return nil
+	case null.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(null.Float64From(f)))
+		return nil
+	case null.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(null.BoolFrom(b)))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return errors.NewWithCode(codes.CodeInvalidValue, "unsupported param type %s", v.Type())
+	}
+	return nil
+}
+
+// parseFilterTime accepts RFC 3339 timestamps and plain dates.
+func parseFilterTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, raw)
+}
+
+// splitValues splits comma separated values and drops empty ones.
+func splitValues(raw []string) []string {
+	var items []string
+	for _, r := range raw {
+		for _, item := range strings.Split(r, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+func firstValue(raw []string) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	return raw[0]
+}
@@ -0,0 +1,177 @@
+package query
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/null"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type filterTestSearch struct {
+	Name  string `param:"name__like" db:"name"`
+	Email string `param:"email__like" db:"email"`
+}
+
+type filterTestParam struct {
+	ID          []int64           `param:"id" db:"id"`
+	Name        string            `param:"name" db:"name"`
+	Status      []string          `param:"status" db:"status"`
+	PriceGTE    null.Float64      `param:"price__gte" db:"price"`
+	PriceLTE    *float64          `param:"price__lte" db:"price"`
+	CreatedAt   []time.Time       `param:"created_at__between" db:"created_at"`
+	CreatedFrom null.Time         `param:"created_at__gte" db:"created_at"`
+	Deleted     null.Bool         `param:"deleted_at__isnull" db:"deleted_at"`
+	Stock       uint32            `param:"stock__gt" db:"stock"`
+	Search      *filterTestSearch `param:"search__or"`
+	Hidden      string            `param:"hidden" db:"-"`
+	SortBy      []string          `param:"sort_by" db:"sort_by"`
+	Fields      []string          `param:"fields"`
+	Page        int64             `param:"page" db:"page"`
+	Limit       int64             `param:"limit" db:"limit"`
+}
+
+func TestBindQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/products?filter[status]=active&filter[status]=draft&filter[price][gte]=10&sort_by=-created_at__gte,name&fields=id,name&page=2&utm_source=mail", nil)
+
+	db, _ := newMockSQLInterface(t)
+	qb := NewSQLQueryBuilder(db, "param", "db", nil)
+	var param filterTestParam
+	require.NoError(t, qb.BindQuery(c, &param))
+	assert.Equal(t, filterTestParam{
+		Status:   []string{"active", "draft"},
+		PriceGTE: null.Float64From(10),
+		SortBy:   []string{"-created_at__gte", "name"},
+		Fields:   []string{"id", "name"},
+		Page:     2,
+	}, param)
+
+	got, gotArgs, _, _, err := qb.Build(&param)
+	require.NoError(t, err)
+	assert.Equal(t, " WHERE 1=1 AND status IN (?, ?) AND price>=? ORDER BY created_at DESC, name ASC LIMIT 10, 10;", got)
+	assert.Equal(t, []interface{}{"active", "draft", float64(10)}, gotArgs)
+	assert.Equal(t, []string{"id", "name"}, qb.Fields())
+}
+
+func Test_sqlClausebuilder_BindValues(t *testing.T) {
+	price := 99.5
+	tests := []struct {
+		name     string
+		query    string
+		want     filterTestParam
+		wantCode codes.Code
+	}{
+		{
+			name:  "typed values",
+			query: "filter[id][in]=1,2&filter[id]=3&filter[name][eq]=jo&filter[price][lte]=99.5&filter[created_at][between]=2024-01-01,2024-02-01T10:00:00Z&filter[created_at][gte]=2024-01-01&filter[deleted_at][isnull]=false&filter[stock][gt]=5&limit=0",
+			want: filterTestParam{
+				ID:          []int64{3, 1, 2},
+				Name:        "jo",
+				PriceLTE:    &price,
+				CreatedAt:   []time.Time{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)},
+				CreatedFrom: null.TimeFrom(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+				Deleted:     null.BoolFrom(false),
+				Stock:       5,
+				Limit:       10,
+			},
+		},
+		{
+			name:  "grouped params",
+			query: "filter[name][like]=%25jo%25&filter[email][like]=%25jo%25",
+			want:  filterTestParam{Search: &filterTestSearch{Name: "%jo%", Email: "%jo%"}},
+		},
+		{
+			name:     "unknown column",
+			query:    "filter[password]=secret",
+			wantCode: codes.CodeBadRequest,
+		},
+		{
+			name:     "unknown operator",
+			query:    "filter[name][gte]=a",
+			wantCode: codes.CodeBadRequest,
+		},
+		{
+			name:     "column without db tag",
+			query:    "filter[hidden]=a",
+			wantCode: codes.CodeBadRequest,
+		},
+		{
+			name:     "special param as filter",
+			query:    "filter[limit]=1000",
+			wantCode: codes.CodeBadRequest,
+		},
+		{
+			name:     "unknown sort column",
+			query:    "sort_by=-password",
+			wantCode: codes.CodeBadRequest,
+		},
+		{
+			name:     "unknown field",
+			query:    "fields=id,password",
+			wantCode: codes.CodeBadRequest,
+		},
+		{
+			name:     "invalid value",
+			query:    "filter[stock][gt]=-1",
+			wantCode: codes.CodeBadRequest,
+		},
+		{
+			name:     "invalid page",
+			query:    "page=first",
+			wantCode: codes.CodeBadRequest,
+		},
+		{
+			name:     "unsupported cursor",
+			query:    "cursor=abc",
+			wantCode: codes.CodeBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+			db, _ := newMockSQLInterface(t)
+
+			var got filterTestParam
+			err = NewSQLQueryBuilder(db, "param", "db", nil).BindValues(values, &got)
+			if tt.wantCode != 0 {
+				assert.Equal(t, tt.wantCode, errors.GetCode(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_sqlClausebuilder_BindValues_InvalidParam(t *testing.T) {
+	db, _ := newMockSQLInterface(t)
+	qb := NewSQLQueryBuilder(db, "param", "db", nil)
+	assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(qb.BindValues(url.Values{}, filterTestParam{})))
+	assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(qb.BindValues(url.Values{}, nil)))
+}
+
+func Test_sqlClausebuilder_Fields(t *testing.T) {
+	type param struct {
+		ID     int64    `param:"id" db:"u.id"`
+		Fields []string `param:"fields"`
+	}
+
+	db, _ := newMockSQLInterface(t)
+	qb := NewSQLQueryBuilder(db, "param", "db", nil)
+	_, _, _, _, err := qb.Build(&param{Fields: []string{"id"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u.id"}, qb.Fields())
+
+	qb = NewSQLQueryBuilder(db, "param", "db", nil)
+	_, _, _, _, err = qb.Build(&param{Fields: []string{"id,fields"}})
+	assert.Equal(t, codes.CodeBadRequest, errors.GetCode(err))
+}
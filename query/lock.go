@@ -0,0 +1,128 @@
+package query
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/null"
+)
+
+// lockField tags the field of an update struct that guards it against
+// concurrent updates, with either lockVersion or lockTimestamp.
+const lockField = "lockField"
+
+const (
+	// lockVersion counts the updates of a row: version=version+1.
+	lockVersion = "version"
+	// lockTimestamp stamps the updates of a row: updated_at=<now>.
+	lockTimestamp = "timestamp"
+)
+
+// timeNow stamps timestamp locks, replaced in tests.
+var timeNow = time.Now
+
+// optimisticLock is the lock field of an update struct, with the value the
+// row was read with.
+type optimisticLock struct {
+	kind   string
+	column string
+	value  interface{}
+}
+
+// findOptimisticLock returns the lock field of the update struct v, or nil
+// when it has none. The field has to be set, as it holds the version the
+// update is guarded with.
+func findOptimisticLock(dbTag string, v reflect.Value) (*optimisticLock, error) {
+	var (
+		lock *optimisticLock
+		err  error
+	)
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct || isTimeType(v) || isNullType(v) {
+			return
+		}
+		for i := 0; i < v.NumField() && err == nil; i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			kind, ok := f.Tag.Lookup(lockField)
+			if !ok {
+				walk(v.Field(i))
+				continue
+			}
+			if lock != nil {
+				err = errors.NewWithCode(codes.CodeInvalidValue, "update has more than one %s", lockField)
+				return
+			}
+			column := f.Tag.Get(dbTag)
+			if column == "" || column == "-" {
+				err = errors.NewWithCode(codes.CodeInvalidValue, "%s %s has no column", lockField, f.Name)
+				return
+			}
+			var value interface{}
+			if value, err = lockValue(kind, v.Field(i)); err != nil {
+				err = errors.WrapWithCode(err, codes.CodeInvalidValue, "%s %s", lockField, f.Name)
+				return
+			}
+			lock = &optimisticLock{kind: kind, column: column, value: value}
+		}
+	}
+	walk(v)
+	return lock, err
+}
+
+func lockValue(kind string, v reflect.Value) (interface{}, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, errors.NewWithCode(codes.CodeInvalidValue, "is not set")
+		}
+		v = v.Elem()
+	}
+
+	switch kind {
+	case lockVersion:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return v.Interface(), nil
+		}
+		if n, ok := v.Interface().(null.Int64); ok && n.Valid {
+			return n.Int64, nil
+		}
+	case lockTimestamp:
+		switch t := v.Interface().(type) {
+		case time.Time:
+			if !t.IsZero() {
+				return t, nil
+			}
+		case null.Time:
+			if t.Valid && !t.Time.IsZero() {
+				return t.Time, nil
+			}
+		}
+	default:
+		return nil, errors.NewWithCode(codes.CodeInvalidValue, "unknown lock %q, use %q or %q", kind, lockVersion, lockTimestamp)
+	}
+	return nil, errors.NewWithCode(codes.CodeInvalidValue, "%s lock is not set or has unsupported type %s", kind, v.Type())
+}
+
+// lockSet adds the new version of the lock to the SET clause.
+func (s *sqlClausebuilder) lockSet(lock *optimisticLock) {
+	column := s.dialect.quote(lock.column)
+	if lock.kind == lockVersion {
+		_, _ = s.rawUpdate.WriteString(", " + column + "=" + column + "+1")
+		return
+	}
+	_, _ = s.rawUpdate.WriteString(", " + column + "=" + s.getBindVar())
+	s.updateArgs = append(s.updateArgs, timeNow())
+}
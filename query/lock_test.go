@@ -0,0 +1,141 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/null"
+	"github.com/downsized-devs/sdk-go/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type lockTestWhere struct {
+	ID int64 `param:"id" db:"id"`
+}
+
+func Test_sqlClausebuilder_BuildUpdate_OptimisticLock(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	read := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	type versioned struct {
+		Name    string `param:"name" db:"name"`
+		Version int64  `param:"version" db:"version" lockField:"version"`
+	}
+	type stamped struct {
+		Name      string    `param:"name" db:"name"`
+		UpdatedAt null.Time `param:"updated_at" db:"updated_at" lockField:"timestamp"`
+	}
+	type nested struct {
+		Meta struct {
+			Version *int32 `db:"version" lockField:"version"`
+		}
+		Name string `param:"name" db:"name"`
+	}
+	version := int32(3)
+	nestedLock := &nested{Name: "jo"}
+	nestedLock.Meta.Version = &version
+
+	tests := []struct {
+		name     string
+		update   interface{}
+		want     string
+		wantArgs []interface{}
+		wantCode codes.Code
+	}{
+		{
+			name:     "version",
+			update:   &versioned{Name: "jo", Version: 3},
+			want:     " SET name=?, version=version+1 WHERE 1=1 AND id=? AND version=?;",
+			wantArgs: []interface{}{"jo", int64(7), int64(3)},
+		},
+		{
+			name:     "timestamp",
+			update:   &stamped{Name: "jo", UpdatedAt: null.TimeFrom(read)},
+			want:     " SET name=?, updated_at=? WHERE 1=1 AND id=? AND updated_at=?;",
+			wantArgs: []interface{}{"jo", now, int64(7), read},
+		},
+		{
+			name:     "nested pointer",
+			update:   nestedLock,
+			want:     " SET name=?, version=version+1 WHERE 1=1 AND id=? AND version=?;",
+			wantArgs: []interface{}{"jo", int64(7), int32(3)},
+		},
+		{
+			name:     "lock only",
+			update:   &versioned{Version: 3},
+			wantCode: codes.CodeInvalidValue,
+		},
+		{
+			name:     "unset timestamp",
+			update:   &stamped{Name: "jo"},
+			wantCode: codes.CodeInvalidValue,
+		},
+		{
+			name:     "nil version",
+			update:   &nested{Name: "jo"},
+			wantCode: codes.CodeInvalidValue,
+		},
+		{
+			name: "unknown lock",
+			update: &struct {
+				Name    string `db:"name"`
+				Version int64  `db:"version" lockField:"revision"`
+			}{Name: "jo"},
+			wantCode: codes.CodeInvalidValue,
+		},
+		{
+			name: "two locks",
+			update: &struct {
+				Version   int64     `db:"version" lockField:"version"`
+				UpdatedAt time.Time `db:"updated_at" lockField:"timestamp"`
+			}{Version: 1, UpdatedAt: read},
+			wantCode: codes.CodeInvalidValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newMockSQLInterface(t)
+			got, gotArgs, err := NewSQLQueryBuilder(db, "param", "db", nil).BuildUpdate(tt.update, &lockTestWhere{ID: 7})
+			if tt.wantCode != 0 {
+				assert.Equal(t, tt.wantCode, errors.GetCode(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantArgs, gotArgs)
+		})
+	}
+}
+
+// TestOptimisticLock_Sqlite updates a row twice with the version it was
+// read with; the second update finds a newer version and conflicts.
+func TestOptimisticLock_Sqlite(t *testing.T) {
+	db := newTestItemDB(t)
+	ctx := context.Background()
+	_, err := db.Leader().Exec(ctx, "alterItem", `ALTER TABLE item ADD COLUMN version INTEGER NOT NULL DEFAULT 0`)
+	require.NoError(t, err)
+
+	type itemUpdate struct {
+		Name    string `db:"name"`
+		Version int64  `db:"version" lockField:"version"`
+	}
+	update := func(name string, version int64) error {
+		q, args, err := NewSQLQueryBuilder(db, "param", "db", nil).BuildUpdate(&itemUpdate{Name: name, Version: version}, &lockTestWhere{ID: 1})
+		require.NoError(t, err)
+		_, err = sql.ExecOptimistic(ctx, db.Leader(), "updateItem", "UPDATE item"+q, args...)
+		return err
+	}
+
+	require.NoError(t, update("x", 0))
+	assert.Equal(t, codes.CodeConflict, errors.GetCode(update("y", 0)))
+
+	got, err := sql.Get[int64](ctx, db.Leader(), "getVersion", `SELECT version FROM item WHERE id = 1`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got)
+}
@@ -0,0 +1,137 @@
+package query
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/sql"
+)
+
+// Pagination describes the page of a list response. CurrentPage is zero
+// for keyset pagination, which pages with NextCursor and PrevCursor instead.
+type Pagination struct {
+	CurrentPage   int64  `json:"currentPage"`
+	Limit         int64  `json:"limit"`
+	TotalElements int64  `json:"totalElements"`
+	TotalPages    int64  `json:"totalPages"`
+	NextCursor    string `json:"nextCursor,omitempty"`
+	PrevCursor    string `json:"prevCursor,omitempty"`
+}
+
+// PaginateOptions picks where Paginate runs its two queries. By default the
+// count runs on the same command as the rows. Splitting them, with
+// CountOnFollower or CountOnLeader, reads the rows and the total from
+// different nodes: a lagging follower then returns a total that does not
+// match the rows.
+type PaginateOptions struct {
+	// Follower runs the rows query, and so the count query, on the follower
+	// instead of the leader.
+	Follower bool
+	// CountOnFollower runs the count query on the follower while the rows
+	// are read from the leader.
+	CountOnFollower bool
+	// CountOnLeader runs the count query on the leader while the rows are
+	// read from the follower.
+	CountOnLeader bool
+	// ConcurrentCount runs the count query alongside the rows query.
+	ConcurrentCount bool
+}
+
+// CountQuery returns the query counting every row that selectQuery, the
+// query Build extends, matches across all pages:
+//
+//	SELECT COUNT(*) FROM (<selectQuery> WHERE ... [GROUP BY ...]) AS count_query;
+//
+// Call it after Build.
+func (s *sqlClausebuilder) CountQuery(selectQuery string) (string, []interface{}, error) {
+	if !s.built {
+		return "", nil, errors.NewWithCode(codes.CodeInvalidValue, "count query needs Build to run first")
+	}
+	return "SELECT COUNT(*) FROM (" + selectQuery + strings.TrimSuffix(s.countQuery, ";") + ") AS count_query;", s.countArgs, nil
+}
+
+// Pagination returns the pagination of the page Build was called for, given
+// the total count of rows.
+func (s *sqlClausebuilder) Pagination(total int64) Pagination {
+	p := Pagination{TotalElements: total}
+	if !s.disableLimit {
+		p.Limit = s.limit
+	}
+	if !s.useCursor {
+		p.CurrentPage = validatePage(s.page)
+	}
+	switch {
+	case p.Limit > 0:
+		p.TotalPages = (total + p.Limit - 1) / p.Limit
+	case total > 0:
+		p.TotalPages = 1
+	}
+	return p
+}
+
+// Paginate builds the query from param, then fetches the rows of the page
+// and counts the rows of every page, and returns them with their
+// Pagination. selectQuery is the query Build extends, e.g.
+// "SELECT id, name FROM item". In keyset mode it also fills the cursors.
+func Paginate[T any](ctx context.Context, qb *sqlClausebuilder, name, selectQuery string, param interface{}, opts PaginateOptions) ([]T, Pagination, error) {
+	where, args, _, _, err := qb.Build(param)
+	if err != nil {
+		return nil, Pagination{}, err
+	}
+	countQuery, countArgs, err := qb.CountQuery(selectQuery)
+	if err != nil {
+		return nil, Pagination{}, err
+	}
+
+	rowsCmd := qb.db.Leader()
+	if opts.Follower {
+		rowsCmd = qb.db.Follower()
+	}
+	countCmd := rowsCmd
+	switch {
+	case !opts.Follower && opts.CountOnFollower:
+		countCmd = qb.db.Follower()
+	case opts.Follower && opts.CountOnLeader:
+		countCmd = qb.db.Leader()
+	}
+
+	var (
+		total    int64
+		countErr error
+		wg       sync.WaitGroup
+	)
+	count := func() {
+		total, countErr = sql.Get[int64](ctx, countCmd, name+"Count", countQuery, countArgs...)
+	}
+	if opts.ConcurrentCount {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			count()
+		}()
+	}
+
+	rows, err := sql.Select[T](ctx, rowsCmd, name, selectQuery+where, args...)
+	if opts.ConcurrentCount {
+		wg.Wait()
+	} else if err == nil {
+		count()
+	}
+	if err != nil {
+		return nil, Pagination{}, err
+	}
+	if countErr != nil {
+		return nil, Pagination{}, countErr
+	}
+
+	p := qb.Pagination(total)
+	if qb.useCursor {
+		if p.NextCursor, p.PrevCursor, err = qb.BuildCursors(&rows); err != nil {
+			return nil, Pagination{}, err
+		}
+	}
+	return rows, p, nil
+}
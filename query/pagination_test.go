@@ -0,0 +1,150 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	mock_sql "github.com/downsized-devs/sdk-go/tests/mock/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type unitTestPageParam struct {
+	Score  int64    `param:"score__gte" db:"score"`
+	SortBy []string `param:"sort_by" db:"sort_by"`
+	Page   int64    `param:"page" db:"page"`
+	Limit  int64    `param:"limit" db:"limit"`
+}
+
+func Test_sqlClausebuilder_Pagination(t *testing.T) {
+	tests := []struct {
+		name  string
+		param interface{}
+		opt   *Option
+		total int64
+		want  Pagination
+	}{
+		{
+			name:  "default page",
+			param: &unitTestPageParam{},
+			total: 25,
+			want:  Pagination{CurrentPage: 1, Limit: 10, TotalElements: 25, TotalPages: 3},
+		},
+		{
+			name:  "last page",
+			param: &unitTestPageParam{Page: 5, Limit: 5},
+			total: 25,
+			want:  Pagination{CurrentPage: 5, Limit: 5, TotalElements: 25, TotalPages: 5},
+		},
+		{
+			name:  "no rows",
+			param: &unitTestPageParam{Page: 1, Limit: 5},
+			want:  Pagination{CurrentPage: 1, Limit: 5},
+		},
+		{
+			name:  "disabled limit",
+			param: &unitTestPageParam{Limit: 5},
+			opt:   &Option{DisableLimit: true},
+			total: 25,
+			want:  Pagination{CurrentPage: 1, TotalElements: 25, TotalPages: 1},
+		},
+		{
+			name:  "no limit param",
+			param: &unitTestSearchParam{Name: "a"},
+			total: 3,
+			want:  Pagination{CurrentPage: 1, TotalElements: 3, TotalPages: 1},
+		},
+		{
+			name:  "keyset",
+			param: &unitTestKeysetParam{Limit: 3, Cursor: ""},
+			total: 7,
+			want:  Pagination{Limit: 3, TotalElements: 7, TotalPages: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newMockSQLInterface(t)
+			qb := NewSQLQueryBuilder(db, "param", "db", tt.opt)
+			_, _, _, _, err := qb.Build(tt.param)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, qb.Pagination(tt.total))
+		})
+	}
+}
+
+func Test_sqlClausebuilder_CountQuery(t *testing.T) {
+	db, _ := newMockSQLInterface(t)
+	qb := NewSQLQueryBuilder(db, "param", "db", nil)
+	_, _, err := qb.CountQuery("SELECT id FROM item")
+	assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(err))
+
+	_, _, _, _, err = qb.AddGroupByQuery("score").Build(&unitTestPageParam{Score: 50, Page: 2, Limit: 3})
+	require.NoError(t, err)
+	got, gotArgs, err := qb.CountQuery("SELECT score, COUNT(*) AS n FROM item")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT COUNT(*) FROM (SELECT score, COUNT(*) AS n FROM item WHERE 1=1 AND score>=? GROUP BY score) AS count_query;", got)
+	assert.Equal(t, []interface{}{int64(50)}, gotArgs)
+}
+
+func TestPaginate_Sqlite(t *testing.T) {
+	db := newTestItemDB(t)
+	ctx := context.Background()
+
+	for _, opts := range []PaginateOptions{{}, {Follower: true, CountOnFollower: true, ConcurrentCount: true}} {
+		qb := NewSQLQueryBuilder(db, "param", "db", nil)
+		rows, p, err := Paginate[unitTestKeysetRow](ctx, qb, "listItem", "SELECT id, score, name FROM item", &unitTestPageParam{Score: 50, SortBy: []string{"id"}, Page: 2, Limit: 2}, opts)
+		require.NoError(t, err)
+		assert.Equal(t, []unitTestKeysetRow{{ID: 3, Score: 50, Name: "c"}, {ID: 4, Score: 90, Name: "d"}}, rows)
+		assert.Equal(t, Pagination{CurrentPage: 2, Limit: 2, TotalElements: 6, TotalPages: 3}, p)
+	}
+
+	qb := NewSQLQueryBuilder(db, "param", "db", nil)
+	rows, p, err := Paginate[unitTestKeysetRow](ctx, qb, "listItem", "SELECT id, score, name FROM item", &unitTestKeysetParam{SortBy: []string{"-score"}, Limit: 3}, PaginateOptions{ConcurrentCount: true})
+	require.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, int64(7), p.TotalElements)
+	assert.Equal(t, int64(3), p.TotalPages)
+	assert.NotEmpty(t, p.NextCursor)
+	assert.Empty(t, p.PrevCursor)
+
+	qb = NewSQLQueryBuilder(db, "param", "db", nil)
+	_, _, err = Paginate[unitTestKeysetRow](ctx, qb, "listItem", "SELECT id, score, name FROM missing", &unitTestPageParam{}, PaginateOptions{ConcurrentCount: true})
+	assert.Error(t, err)
+}
+
+func TestPaginate_CountRouting(t *testing.T) {
+	ctx := context.Background()
+	leader := newTestItemDB(t)
+	// a lagging follower, missing the last item
+	follower := newTestItemDB(t)
+	_, err := follower.Leader().Exec(ctx, "deleteItem", `DELETE FROM item WHERE id = 7`)
+	require.NoError(t, err)
+
+	db := mock_sql.NewMockInterface(gomock.NewController(t))
+	db.EXPECT().Leader().Return(leader.Leader()).AnyTimes()
+	db.EXPECT().Follower().Return(follower.Leader()).AnyTimes()
+
+	tests := []struct {
+		name      string
+		opts      PaginateOptions
+		wantRows  int
+		wantTotal int64
+	}{
+		{name: "leader", opts: PaginateOptions{}, wantRows: 7, wantTotal: 7},
+		{name: "count with the rows on the follower", opts: PaginateOptions{Follower: true}, wantRows: 6, wantTotal: 6},
+		{name: "count split onto the follower", opts: PaginateOptions{CountOnFollower: true}, wantRows: 7, wantTotal: 6},
+		{name: "count split onto the leader", opts: PaginateOptions{Follower: true, CountOnLeader: true, ConcurrentCount: true}, wantRows: 6, wantTotal: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qb := NewSQLQueryBuilder(db, "param", "db", nil)
+			rows, p, err := Paginate[unitTestKeysetRow](ctx, qb, "listItem", "SELECT id, score, name FROM item", &unitTestPageParam{SortBy: []string{"id"}, Limit: 10}, tt.opts)
+			require.NoError(t, err)
+			assert.Len(t, rows, tt.wantRows)
+			assert.Equal(t, tt.wantTotal, p.TotalElements)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package query
+
+import (
+	"context"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+)
+
+// WithContext sets the context the tenant of Option.TenantColumn is read
+// from, typically the request context carrying the auth info:
+//
+//	qb := query.NewSQLQueryBuilder(db, "param", "db", &query.Option{
+//		TenantColumn: "fk_company_id",
+//		TenantFromContext: func(ctx context.Context) (interface{}, error) {
+//			info, err := authLib.GetUserAuthInfo(ctx)
+//			return info.User.CompanyID, err
+//		},
+//	}).WithContext(ctx)
+func (s *sqlClausebuilder) WithContext(ctx context.Context) *sqlClausebuilder {
+	s.ctx = ctx
+	return s
+}
+
+// applyScopes adds the soft-delete and tenant conditions of the Option in
+// front of the conditions of the param. A tenant that cannot be resolved
+// fails the build rather than leaving the rows of every tenant in.
+func (s *sqlClausebuilder) applyScopes() error {
+	if s.softDeleteColumn != "" {
+		s.where(" AND ", s.dialect.quote(s.softDeleteColumn)+" IS NULL")
+	}
+	if s.tenantColumn == "" {
+		return nil
+	}
+
+	if s.tenantFromContext == nil || s.ctx == nil {
+		return errors.NewWithCode(codes.CodeInvalidValue, "tenant column %s needs TenantFromContext and WithContext", s.tenantColumn)
+	}
+	tenant, err := s.tenantFromContext(s.ctx)
+	if err != nil {
+		return errors.WrapWithCode(err, codes.CodeForbidden, "cannot resolve tenant")
+	}
+	if tenant == nil {
+		return errors.NewWithCode(codes.CodeForbidden, "no tenant in context")
+	}
+	s.where(" AND ", s.dialect.quote(s.tenantColumn)+"=?", tenant)
+	return nil
+}
@@ -0,0 +1,131 @@
+package query
+
+import (
+	"context"
+	goerr "errors"
+	"testing"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type scopeTestTenantKey struct{}
+
+func scopeTestTenant(ctx context.Context) (interface{}, error) {
+	companyID, ok := ctx.Value(scopeTestTenantKey{}).(int64)
+	if !ok {
+		return nil, goerr.New("no user auth info")
+	}
+	return companyID, nil
+}
+
+func Test_sqlClausebuilder_Build_Scopes(t *testing.T) {
+	tenantCtx := context.WithValue(context.Background(), scopeTestTenantKey{}, int64(42))
+	param := &unitTestSearchParam{Name: "%jo%"}
+
+	tests := []struct {
+		name     string
+		option   *Option
+		ctx      context.Context
+		want     string
+		wantArgs []interface{}
+		wantCode codes.Code
+	}{
+		{
+			name:     "soft delete",
+			option:   &Option{SoftDeleteColumn: "deleted_at"},
+			want:     " WHERE 1=1 AND deleted_at IS NULL AND name LIKE ?;",
+			wantArgs: []interface{}{"%jo%"},
+		},
+		{
+			name:     "with deleted",
+			option:   &Option{SoftDeleteColumn: "deleted_at", WithDeleted: true},
+			want:     " WHERE 1=1 AND name LIKE ?;",
+			wantArgs: []interface{}{"%jo%"},
+		},
+		{
+			name:     "tenant",
+			option:   &Option{SoftDeleteColumn: "u.deleted_at", TenantColumn: "u.fk_company_id", TenantFromContext: scopeTestTenant},
+			ctx:      tenantCtx,
+			want:     " WHERE 1=1 AND u.deleted_at IS NULL AND u.fk_company_id=? AND name LIKE ?;",
+			wantArgs: []interface{}{int64(42), "%jo%"},
+		},
+		{
+			name:     "tenant without context",
+			option:   &Option{TenantColumn: "fk_company_id", TenantFromContext: scopeTestTenant},
+			wantCode: codes.CodeInvalidValue,
+		},
+		{
+			name:     "tenant without resolver",
+			option:   &Option{TenantColumn: "fk_company_id"},
+			ctx:      tenantCtx,
+			wantCode: codes.CodeInvalidValue,
+		},
+		{
+			name:     "tenant missing from context",
+			option:   &Option{TenantColumn: "fk_company_id", TenantFromContext: scopeTestTenant},
+			ctx:      context.Background(),
+			wantCode: codes.CodeForbidden,
+		},
+		{
+			name: "nil tenant",
+			option: &Option{TenantColumn: "fk_company_id", TenantFromContext: func(context.Context) (interface{}, error) {
+				return nil, nil
+			}},
+			ctx:      context.Background(),
+			wantCode: codes.CodeForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newMockSQLInterface(t)
+			qb := NewSQLQueryBuilder(db, "param", "db", tt.option)
+			if tt.ctx != nil {
+				qb = qb.WithContext(tt.ctx)
+			}
+			got, gotArgs, gotCount, gotCountArgs, err := qb.Build(param)
+			if tt.wantCode != 0 {
+				assert.Equal(t, tt.wantCode, errors.GetCode(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantArgs, gotArgs)
+			assert.Contains(t, gotCount, tt.want[:len(tt.want)-len(";")])
+			assert.Equal(t, tt.wantArgs, gotCountArgs)
+		})
+	}
+}
+
+func Test_sqlClausebuilder_BuildUpdate_Scopes(t *testing.T) {
+	type update struct {
+		Name string `param:"name" db:"name"`
+	}
+	type where struct {
+		ID int64 `param:"id" db:"id"`
+	}
+	option := &Option{SoftDeleteColumn: "deleted_at", TenantColumn: "fk_company_id", TenantFromContext: scopeTestTenant}
+	ctx := context.WithValue(context.Background(), scopeTestTenantKey{}, int64(42))
+
+	t.Run("scoped", func(t *testing.T) {
+		db, _ := newMockSQLInterface(t)
+		got, gotArgs, err := NewSQLQueryBuilder(db, "param", "db", option).WithContext(ctx).BuildUpdate(&update{Name: "jo"}, &where{ID: 7})
+		require.NoError(t, err)
+		assert.Equal(t, " SET name=? WHERE 1=1 AND deleted_at IS NULL AND fk_company_id=? AND id=?;", got)
+		assert.Equal(t, []interface{}{"jo", int64(42), int64(7)}, gotArgs)
+	})
+
+	t.Run("scopes only", func(t *testing.T) {
+		db, _ := newMockSQLInterface(t)
+		_, _, err := NewSQLQueryBuilder(db, "param", "db", option).WithContext(ctx).BuildUpdate(&update{Name: "jo"}, &where{})
+		assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(err))
+	})
+
+	t.Run("unresolved tenant", func(t *testing.T) {
+		db, _ := newMockSQLInterface(t)
+		_, _, err := NewSQLQueryBuilder(db, "param", "db", option).BuildUpdate(&update{Name: "jo"}, &where{ID: 7})
+		assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(err))
+	})
+}
@@ -17,6 +17,14 @@ func isLimit(paramTagValue string) bool {
 	return paramTagValue == "limit"
 }
 
+func isCursor(paramTagValue string) bool {
+	return paramTagValue == "cursor"
+}
+
+func isFields(paramTagValue string) bool {
+	return paramTagValue == "fields"
+}
+
 func isSortBy(paramTagValue string) bool {
 	if paramTagValue == "sort_by" ||
 		paramTagValue == "sort-by" ||
@@ -2,9 +2,11 @@ package query
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/downsized-devs/sdk-go/codes"
@@ -24,6 +26,17 @@ type Option struct {
 	DisableLimit bool `form:"disableLimit"`
 	IsActive     bool
 	IsInactive   bool
+	// CursorColumn is the unique column appended to the sort columns to break
+	// ties in keyset pagination. Defaults to "id".
+	CursorColumn string
+	// SoftDeleteColumn, e.g. "deleted_at", leaves out the rows where it is
+	// set, unless WithDeleted is true.
+	SoftDeleteColumn string
+	WithDeleted      bool
+	// TenantColumn, e.g. "fk_company_id", limits the rows to the tenant
+	// TenantFromContext returns for the context given to WithContext.
+	TenantColumn      string
+	TenantFromContext func(ctx context.Context) (interface{}, error)
 }
 
 type sqlClausebuilder struct {
@@ -44,16 +57,47 @@ type sqlClausebuilder struct {
 	paginationClause              string
 	paramSortBy                   []string
 	dbSortBy                      []string
+	paramFields                   []string
+	fields                        []string
+	built                         bool
+	countQuery                    string
+	countArgs                     []interface{}
 	limit                         int64
 	page                          int64
 	db                            sql.Interface
 	aliasMap                      map[string]string
+	columnAliases                 map[string]map[string]bool
 	disableLimit                  bool
+	dialect                       dialect
+
+	// scopes
+	ctx               context.Context
+	softDeleteColumn  string
+	tenantColumn      string
+	tenantFromContext func(ctx context.Context) (interface{}, error)
+
+	// optimistic lock of the update
+	lock *optimisticLock
 
 	// cursors
 	useCursor        bool
 	rawCursor        string
 	cursorArgCounter int
+	cursorColumn     string
+	sortColumns      []keysetColumn
+	keysetColumns    []keysetColumn
+	backward         bool
+
+	// open OR / AND groups, innermost last
+	groups []*conditionGroup
+	err    error
+}
+
+// conditionGroup collects the conditions of a grouped param struct until
+// the group is closed.
+type conditionGroup struct {
+	conjunction string
+	conditions  []string
 }
 
 // Initiate new query builder object
@@ -75,17 +119,24 @@ func NewSQLQueryBuilder(db sql.Interface, paramTag, dbTag string, option *Option
 		aliasMap:        make(map[string]string),
 		limit:           0,
 		page:            0,
+		dialect:         newDialect(db),
 	}
 
 	if option != nil {
+		qb.cursorColumn = option.CursorColumn
+		if !option.WithDeleted {
+			qb.softDeleteColumn = option.SoftDeleteColumn
+		}
+		qb.tenantColumn = option.TenantColumn
+		qb.tenantFromContext = option.TenantFromContext
 		if option.DisableLimit {
 			qb.disableLimit = true
 		}
 		if option.IsActive {
-			_, _ = qb.rawQuery.WriteString(" AND status=1")
+			_, _ = qb.rawQuery.WriteString(" AND " + qb.dialect.quote("status") + "=1")
 		}
 		if option.IsInactive {
-			_, _ = qb.rawQuery.WriteString(" AND status=0")
+			_, _ = qb.rawQuery.WriteString(" AND " + qb.dialect.quote("status") + "=0")
 		}
 	}
 
@@ -122,13 +173,21 @@ func (s *sqlClausebuilder) AddAliasPrefix(alias string, ptr interface{}) *sqlCla
 	if p.Kind() != reflect.Pointer {
 		panic(errors.NewWithCode(codes.CodeInvalidValue, "passed interface{} should be a pointer"))
 	}
-	v := p.Elem()
-	var address string
-	if v.CanAddr() {
-		address = fmt.Sprint(v.Addr().Pointer())
-	}
+	s.aliasMap[aliasKey(p.Elem())] = alias
+	return s
+}
 
-	s.aliasMap[address] = alias
+// AddAliasPrefixes registers the table alias of several param structs, e.g.
+// the filters of joined tables kept in one param:
+//
+//	qb.AddAliasPrefixes(map[string]interface{}{"o": &param.Order, "c": &param.Customer})
+//
+// A column used without an alias while another struct uses it with one is
+// ambiguous and fails Build.
+func (s *sqlClausebuilder) AddAliasPrefixes(aliases map[string]interface{}) *sqlClausebuilder {
+	for alias, ptr := range aliases {
+		s.AddAliasPrefix(alias, ptr)
+	}
 	return s
 }
 
@@ -143,21 +202,40 @@ func (s *sqlClausebuilder) Build(param interface{}) (string, []interface{}, stri
 	// copy param to struct
 	s.param = p
 
+	if err := s.applyScopes(); err != nil {
+		return "", nil, "", nil, err
+	}
+
+	collectSortableParams(s.paramTag, s.fieldTag, p.Type(), s.sortableParams)
 	traverseOnParam(s.paramTag, s.dbTag, s.fieldTag, "$", "", "", s.aliasMap, s.param, s.buildSQLQueryString)
+	s.checkUnaliasedColumns()
+	if s.err != nil {
+		return "", nil, "", nil, s.err
+	}
+	if err := s.resolveFields(); err != nil {
+		return "", nil, "", nil, err
+	}
 
 	// copy buffer to get count query
-	countquery := s.rawQuery.Bytes()
+	countquery := string(s.rawQuery.Bytes())
+
+	// keyset predicate goes after the count query so that it counts every page
+	if s.useCursor {
+		if err := s.keysetPredicate(); err != nil {
+			return "", nil, "", nil, err
+		}
+	}
 
 	// group by
+	var groupBy string
 	if len(s.groupBy) > 0 {
-		s.rawQuery.WriteString(" GROUP BY " + strings.Join(s.groupBy, ", "))
+		groupBy = " GROUP BY " + strings.Join(s.groupBy, ", ")
+		s.rawQuery.WriteString(groupBy)
 	}
 
-	// page pagination
-	// Cursor-based pagination is not yet implemented; when useCursor is true
-	// and rawCursor is non-empty the caller must apply cursor filtering
-	// manually before calling Build.
-	if !s.useCursor || len(s.rawCursor) < 1 {
+	if s.useCursor {
+		s.keysetPagination()
+	} else {
 		// sort must be done first before page pagination
 		s.sort()
 		if len(s.sortClause) > 0 {
@@ -174,13 +252,23 @@ func (s *sqlClausebuilder) Build(param interface{}) (string, []interface{}, stri
 	if err != nil {
 		return "", nil, "", nil, err
 	}
-	newQuery = s.db.Leader().Rebind(newQuery)
+	newQuery = s.dialect.rebind(s.db, newQuery)
 
-	newCountQuery, newCountArgs, err := sqlx.In(string(countquery)+s.suffixQuery+";", s.args[0:len(s.args)-s.cursorArgCounter]...)
+	newCountQuery, newCountArgs, err := sqlx.In(countquery+s.suffixQuery+";", s.args[0:len(s.args)-s.cursorArgCounter]...)
 	if err != nil {
 		return "", nil, "", nil, err
 	}
-	newCountQuery = s.db.Leader().Rebind(newCountQuery)
+	newCountQuery = s.dialect.rebind(s.db, newCountQuery)
+
+	// CountQuery counts the groups, not the grouped rows
+	s.countQuery, s.countArgs = newCountQuery, newCountArgs
+	if groupBy != "" {
+		if s.countQuery, s.countArgs, err = sqlx.In(countquery+groupBy+s.suffixQuery+";", s.args[0:len(s.args)-s.cursorArgCounter]...); err != nil {
+			return "", nil, "", nil, err
+		}
+		s.countQuery = s.dialect.rebind(s.db, s.countQuery)
+	}
+	s.built = true
 
 	return newQuery, newArgs, newCountQuery, newCountArgs, nil
 }
@@ -199,23 +287,49 @@ func (s *sqlClausebuilder) BuildUpdate(update interface{}, where interface{}) (s
 	s.param = w
 	s.updateParam = u
 
+	// the lock field holds the version read, which is not written as is
+	lock, err := findOptimisticLock(s.dbTag, s.updateParam)
+	if err != nil {
+		return "", nil, err
+	}
+	s.lock = lock
+
 	// generate update set query
 	traverseOnParam(s.paramTag, s.dbTag, s.fieldTag, "$", "", "", s.aliasMap, s.updateParam, s.buildSQLUpdateString)
-	// generate where query
+	emptySet := strings.TrimSpace(s.rawUpdate.String()) == "SET"
+	// generate where query, the scopes alone do not make it a where clause
+	if err := s.applyScopes(); err != nil {
+		return "", nil, err
+	}
+	scoped, hasScopes := s.rawQuery.Len(), s.softDeleteColumn != "" || s.tenantColumn != ""
 	traverseOnParam(s.paramTag, s.dbTag, s.fieldTag, "$", "", "", s.aliasMap, s.param, s.buildSQLQueryString)
+	for column, aliases := range s.columnAliases {
+		if len(aliases) > 1 || !aliases[""] {
+			s.setErr(errors.NewWithCode(codes.CodeInvalidValue, "cannot update with aliased column %s in the where clause", column))
+		}
+	}
+	if s.err != nil {
+		return "", nil, s.err
+	}
+	emptyWhere := strings.TrimSpace(s.rawQuery.String()+s.suffixQuery) == "WHERE 1=1" || (hasScopes && s.rawQuery.Len() == scoped)
+	if emptyWhere || emptySet {
+		return "", nil, errors.NewWithCode(codes.CodeInvalidValue, "generated update or where query clause cannot be ampty")
+	}
+
+	// the update only applies to the version it was read with
+	if s.lock != nil {
+		s.lockSet(s.lock)
+		s.where(" AND ", s.dialect.quote(s.lock.column)+"="+s.getBindVar(), s.lock.value)
+	}
 
 	whereQuery, whereArgs, err := sqlx.In(s.rawQuery.String()+s.suffixQuery+";", s.args...)
 	if err != nil {
 		return "", nil, err
 	}
-	whereQuery = s.db.Leader().Rebind(whereQuery)
-
-	if strings.TrimSpace(whereQuery) == "WHERE 1=1;" || strings.TrimSpace(s.rawUpdate.String()) == "SET" {
-		return "", nil, errors.NewWithCode(codes.CodeInvalidValue, "generated update or where query clause cannot be ampty")
-	}
 
-	// combine all query
-	allQuery := s.rawUpdate.String() + whereQuery
+	// combine all query, rebound as a whole so that $n numbering continues
+	// from the SET args into the WHERE args
+	allQuery := s.dialect.rebind(s.db, s.rawUpdate.String()+whereQuery)
 
 	// combine all args
 	var allArgs []interface{}
@@ -240,11 +354,12 @@ func (s *sqlClausebuilder) sort() {
 						if name == "sign" && match[i] == "-" {
 							direction = "DESC"
 						} else if name == "col" {
-							if s.useCursor && len(s.rawCursor) > 0 && !s.sortableParams[match[i]] {
+							if s.useCursor && !s.sortableParams[match[i]] {
 								continue
 							}
 							if db, ok := s.paramToDBMap[match[i]]; ok {
-								db = db + " " + direction
+								s.sortColumns = append(s.sortColumns, keysetColumn{column: db, desc: direction == "DESC"})
+								db = s.dialect.quote(db) + " " + direction
 								s.dbSortBy = append(s.dbSortBy, db)
 							}
 						}
@@ -258,19 +373,176 @@ func (s *sqlClausebuilder) sort() {
 	}
 }
 
+// keysetPredicate restricts the query to the rows after the decoded cursor,
+// or before it for a prev cursor.
+func (s *sqlClausebuilder) keysetPredicate() error {
+	s.sort()
+
+	column := s.cursorColumn
+	if column == "" {
+		column = defaultCursorColumn
+	}
+	s.keysetColumns = s.sortColumns
+	tieBreak := true
+	for _, c := range s.sortColumns {
+		tieBreak = tieBreak && c.column != column
+	}
+	if tieBreak {
+		// the tie-breaker follows the last sort direction so that a single
+		// direction keeps the row value comparison
+		desc := len(s.sortColumns) > 0 && s.sortColumns[len(s.sortColumns)-1].desc
+		s.keysetColumns = append(s.keysetColumns[:len(s.keysetColumns):len(s.keysetColumns)], keysetColumn{column: column, desc: desc})
+	}
+
+	if len(s.rawCursor) < 1 {
+		return nil
+	}
+
+	var cursor KeysetCursor
+	if err := cursor.DecodeCursor(s.rawCursor); err != nil {
+		return err
+	}
+	if strings.Join(cursor.Columns, ",") != strings.Join(s.keysetColumnNames(), ",") {
+		return errors.NewWithCode(codes.CodeBadRequest, "cursor does not match the sort order %s", strings.Join(s.keysetColumnNames(), ", "))
+	}
+
+	s.backward = cursor.Direction == CursorPrev
+	predicate, args := keysetPredicate(s.quotedKeysetColumns(), cursor.Values, s.backward)
+	_, _ = s.rawQuery.WriteString(" AND " + predicate)
+	s.args = append(s.args, args...)
+	s.cursorArgCounter = len(args)
+	return nil
+}
+
+// keysetPagination orders by the keyset columns, reversed when paging back,
+// and limits the page without an offset. One row more than the limit is
+// fetched to tell whether another page follows.
+func (s *sqlClausebuilder) keysetPagination() {
+	order := make([]string, 0, len(s.keysetColumns))
+	for _, c := range s.quotedKeysetColumns() {
+		if s.backward {
+			c.desc = !c.desc
+		}
+		order = append(order, c.String())
+	}
+	s.sortClause = " ORDER BY " + strings.Join(order, ", ")
+	s.rawQuery.WriteString(s.sortClause)
+
+	if s.limit > 0 && !s.disableLimit {
+		s.paginationClause = fmt.Sprintf(" LIMIT %d", s.limit+1)
+		s.rawQuery.WriteString(s.paginationClause)
+	}
+}
+
+// quotedKeysetColumns returns the keyset columns as written in the query;
+// cursors keep the unquoted names.
+func (s *sqlClausebuilder) quotedKeysetColumns() []keysetColumn {
+	cols := make([]keysetColumn, 0, len(s.keysetColumns))
+	for _, c := range s.keysetColumns {
+		cols = append(cols, keysetColumn{column: s.dialect.quote(c.column), desc: c.desc})
+	}
+	return cols
+}
+
+func (s *sqlClausebuilder) keysetColumnNames() []string {
+	names := make([]string, 0, len(s.keysetColumns))
+	for _, c := range s.keysetColumns {
+		names = append(names, c.String())
+	}
+	return names
+}
+
+// BuildCursors returns the next and prev cursors around rows, a pointer to
+// the slice fetched with the query from Build in keyset mode. An empty cursor
+// means there is no such page. It drops the extra row fetched past the limit
+// and, for a page fetched with a prev cursor, puts the rows back in order.
+func (s *sqlClausebuilder) BuildCursors(rows interface{}) (string, string, error) {
+	if !s.useCursor {
+		return "", "", errors.NewWithCode(codes.CodeInvalidValue, "cursors need a cursor param")
+	}
+	p := reflect.ValueOf(rows)
+	if p.Kind() != reflect.Pointer || p.IsNil() || p.Elem().Kind() != reflect.Slice {
+		return "", "", errors.NewWithCode(codes.CodeInvalidValue, "passed rows should be a pointer to a slice")
+	}
+	v := p.Elem()
+
+	more := s.limit > 0 && !s.disableLimit && int64(v.Len()) > s.limit
+	if more {
+		v.SetLen(int(s.limit))
+	}
+	n := v.Len()
+	if n == 0 {
+		return "", "", nil
+	}
+	if s.backward {
+		swap := reflect.Swapper(v.Interface())
+		for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	hasNext, hasPrev := more, len(s.rawCursor) > 0
+	if s.backward {
+		hasNext, hasPrev = true, more
+	}
+
+	var next, prev string
+	var err error
+	if hasNext {
+		if next, err = s.encodeCursor(CursorNext, v.Index(n-1)); err != nil {
+			return "", "", err
+		}
+	}
+	if hasPrev {
+		if prev, err = s.encodeCursor(CursorPrev, v.Index(0)); err != nil {
+			return "", "", err
+		}
+	}
+	return next, prev, nil
+}
+
+func (s *sqlClausebuilder) encodeCursor(direction string, row reflect.Value) (string, error) {
+	values, err := keysetValues(row, s.keysetColumns)
+	if err != nil {
+		return "", err
+	}
+	cursor := &KeysetCursor{Direction: direction, Columns: s.keysetColumnNames(), Values: values}
+	return cursor.EncodeCursor()
+}
+
 func (s *sqlClausebuilder) pagePagination() {
 	if s.page > 0 || s.limit > 0 {
 		offset := getOffset(s.page, s.limit)
-		s.paginationClause = fmt.Sprintf(" LIMIT %d, %d", offset, s.limit)
+		s.paginationClause = s.dialect.limitOffset(s.limit, offset)
 	}
 }
 
 func (s *sqlClausebuilder) buildSQLQueryString(primitiveType int8, isLike, isMany, isSqlNull bool, fieldName, paramTag, dbTag string, args interface{}) {
+	switch primitiveType {
+	case groupStart:
+		s.openGroup(paramTag)
+		return
+	case groupEnd:
+		s.closeGroup()
+		return
+	}
+
+	s.checkColumn(paramTag, dbTag)
+
 	// map param to field name
 	s.paramToFieldMap[paramTag] = fieldName
 	// map param to db column name
 	s.paramToDBMap[paramTag] = dbTag
 
+	// the fields param needs no db tag
+	if isFields(paramTag) {
+		v, _ := args.([]string)
+		if v != nil {
+			s.paramFields = normalizeSortBy(v)
+		}
+		return
+	}
+
 	if dbTag == "" {
 		return
 	}
@@ -295,64 +567,185 @@ func (s *sqlClausebuilder) buildSQLQueryString(primitiveType int8, isLike, isMan
 		return
 	}
 
+	if isCursor(paramTag) {
+		v, _ := args.(string)
+		s.useCursor = true
+		s.rawCursor = v
+		return
+	}
+
 	// we only remap if the args is not nil
 	if args == nil {
 		return
 	}
 
+	bindVar := s.getBindVar()
+	column := s.dialect.quote(dbTag)
 	if !isMany {
+		switch {
+		case strings.Contains(paramTag, "__isnull"), strings.Contains(paramTag, "__notnull"):
+			// the bool arg picks the check; __notnull inverts it
+			isNull, ok := args.(bool)
+			if !ok {
+				s.setErr(errors.NewWithCode(codes.CodeInvalidValue, "param %s should be a bool", paramTag))
+				return
+			}
+			if strings.Contains(paramTag, "__notnull") {
+				isNull = !isNull
+			}
+			if isNull {
+				s.where(" AND ", column+" IS NULL")
+				return
+			}
+			s.where(" AND ", column+" IS NOT NULL")
+			return
+		case strings.Contains(paramTag, "__nin"):
+			s.where(" AND ", column+" NOT IN ("+bindVar+")", args)
+			return
+		}
+
 		if isLike {
 			if strings.Contains(paramTag, "__opt") {
-				_, _ = s.rawQuery.WriteString(" OR " + dbTag + " LIKE " + s.getBindVar())
-				s.args = append(s.args, args)
+				s.where(" OR ", column+s.dialect.like()+bindVar, args)
 				return
 			}
-			_, _ = s.rawQuery.WriteString(" AND " + dbTag + " LIKE " + s.getBindVar())
-			s.args = append(s.args, args)
+			s.where(" AND ", column+s.dialect.like()+bindVar, args)
 			return
 		}
 
 		switch {
 		case strings.Contains(paramTag, "__gte"):
-			_, _ = s.rawQuery.WriteString(" AND " + dbTag + ">=" + s.getBindVar())
-			s.args = append(s.args, args)
+			s.where(" AND ", column+">="+bindVar, args)
 			return
 		case strings.Contains(paramTag, "__lte"):
-			_, _ = s.rawQuery.WriteString(" AND " + dbTag + "<=" + s.getBindVar())
-			s.args = append(s.args, args)
+			s.where(" AND ", column+"<="+bindVar, args)
 			return
 		case strings.Contains(paramTag, "__lt"):
-			_, _ = s.rawQuery.WriteString(" AND " + dbTag + "<" + s.getBindVar())
-			s.args = append(s.args, args)
+			s.where(" AND ", column+"<"+bindVar, args)
 			return
 		case strings.Contains(paramTag, "__gt"):
-			_, _ = s.rawQuery.WriteString(" AND " + dbTag + ">" + s.getBindVar())
-			s.args = append(s.args, args)
+			s.where(" AND ", column+">"+bindVar, args)
 			return
 		case strings.Contains(paramTag, "__ne"):
-			_, _ = s.rawQuery.WriteString(" AND " + dbTag + "<>" + s.getBindVar())
-			s.args = append(s.args, args)
+			s.where(" AND ", column+"<>"+bindVar, args)
 			return
 		case strings.Contains(paramTag, "__opt"):
-			_, _ = s.rawQuery.WriteString(" OR " + dbTag + "=" + s.getBindVar())
-			s.args = append(s.args, args)
+			s.where(" OR ", column+"="+bindVar, args)
 			return
 		}
 
-		_, _ = s.rawQuery.WriteString(" AND " + dbTag + "=" + s.getBindVar())
-		s.args = append(s.args, args)
+		s.where(" AND ", column+"="+bindVar, args)
+		return
+	}
+
+	// sqlx.In binds a []byte as a single value, so spread a []uint8
+	if b, ok := args.([]uint8); ok {
+		vals := make([]interface{}, 0, len(b))
+		for _, v := range b {
+			vals = append(vals, v)
+		}
+		args = vals
+	}
+
+	if strings.Contains(paramTag, "__between") {
+		v := reflect.ValueOf(args)
+		if v.Len() != 2 {
+			s.setErr(errors.NewWithCode(codes.CodeInvalidValue, "param %s should have exactly 2 values, got %d", paramTag, v.Len()))
+			return
+		}
+		s.where(" AND ", column+" BETWEEN "+bindVar+" AND "+bindVar, v.Index(0).Interface(), v.Index(1).Interface())
 		return
 	}
 
 	if strings.Contains(paramTag, "__nin") {
-		_, _ = s.rawQuery.WriteString(" AND " + dbTag + " NOT IN (" + s.getBindVar() + ")")
-		s.args = append(s.args, args)
+		s.where(" AND ", column+" NOT IN ("+bindVar+")", args)
 		return
 	}
 
 	// __ in or unstated will result IN
-	_, _ = s.rawQuery.WriteString(" AND " + dbTag + " IN (" + s.getBindVar() + ")")
-	s.args = append(s.args, args)
+	s.where(" AND ", column+" IN ("+bindVar+")", args)
+}
+
+// where adds cond to the WHERE clause with its conjunction, or to the
+// innermost open group, which joins its conditions with its own conjunction.
+func (s *sqlClausebuilder) where(conjunction, cond string, args ...interface{}) {
+	s.args = append(s.args, args...)
+	if n := len(s.groups); n > 0 {
+		s.groups[n-1].conditions = append(s.groups[n-1].conditions, cond)
+		return
+	}
+	_, _ = s.rawQuery.WriteString(conjunction + cond)
+}
+
+func (s *sqlClausebuilder) openGroup(paramTag string) {
+	conjunction := " AND "
+	if strings.HasSuffix(paramTag, groupOr) {
+		conjunction = " OR "
+	}
+	s.groups = append(s.groups, &conditionGroup{conjunction: conjunction})
+}
+
+// closeGroup adds the innermost group as a single parenthesized condition to
+// its parent. A group without conditions adds nothing.
+func (s *sqlClausebuilder) closeGroup() {
+	n := len(s.groups)
+	if n == 0 {
+		return
+	}
+	g := s.groups[n-1]
+	s.groups = s.groups[:n-1]
+	if len(g.conditions) == 0 {
+		return
+	}
+	s.where(" AND ", "("+strings.Join(g.conditions, g.conjunction)+")")
+}
+
+// checkColumn fails the build when a param stands for two columns, e.g. the
+// same filter in the structs of two joined tables, and records the alias
+// the column is used with.
+func (s *sqlClausebuilder) checkColumn(paramTag, dbTag string) {
+	if dbTag == "" || isSpecialParam(paramTag) {
+		return
+	}
+	if prev := s.paramToDBMap[paramTag]; prev != "" && prev != dbTag {
+		s.setErr(errors.NewWithCode(codes.CodeInvalidValue, "param %s is ambiguous between columns %s and %s", paramTag, prev, dbTag))
+	}
+
+	alias, column := "", dbTag
+	if i := strings.LastIndex(dbTag, "."); i >= 0 {
+		alias, column = dbTag[:i], dbTag[i+1:]
+	}
+	if s.columnAliases == nil {
+		s.columnAliases = map[string]map[string]bool{}
+	}
+	if s.columnAliases[column] == nil {
+		s.columnAliases[column] = map[string]bool{}
+	}
+	s.columnAliases[column][alias] = true
+}
+
+// checkUnaliasedColumns fails the build when a column is used both with and
+// without a table alias, which a joined query cannot resolve.
+func (s *sqlClausebuilder) checkUnaliasedColumns() {
+	columns := make([]string, 0, len(s.columnAliases))
+	for column := range s.columnAliases {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		aliases := s.columnAliases[column]
+		if aliases[""] && len(aliases) > 1 {
+			s.setErr(errors.NewWithCode(codes.CodeInvalidValue, "column %s is ambiguous, it is used both with and without a table alias", column))
+			return
+		}
+	}
+}
+
+// setErr keeps the first error found while traversing the param.
+func (s *sqlClausebuilder) setErr(err error) {
+	if s.err == nil {
+		s.err = err
+	}
 }
 
 func (s *sqlClausebuilder) buildSQLUpdateString(primitiveType int8, isLike, isMany, isSqlNull bool, fieldName, paramTag, dbTag string, args interface{}) {
@@ -360,6 +753,17 @@ func (s *sqlClausebuilder) buildSQLUpdateString(primitiveType int8, isLike, isMa
 		return
 	}
 
+	// the lock column is set by lockSet
+	if s.lock != nil && dbTag == s.lock.column {
+		return
+	}
+
+	// the update is written for a single table
+	if strings.Contains(dbTag, ".") {
+		s.setErr(errors.NewWithCode(codes.CodeInvalidValue, "cannot update aliased column %s", dbTag))
+		return
+	}
+
 	// we only remap if the args is not nil and isSqlNull is false
 	if args == nil && !isSqlNull {
 		return
@@ -373,15 +777,47 @@ func (s *sqlClausebuilder) buildSQLUpdateString(primitiveType int8, isLike, isMa
 	// only append if data is singular
 	if !isMany {
 		if isSqlNull {
-			_, _ = s.rawUpdate.WriteString(separator + " " + dbTag + "=NULL")
+			_, _ = s.rawUpdate.WriteString(separator + " " + s.dialect.quote(dbTag) + "=NULL")
 			return
 		}
-		_, _ = s.rawUpdate.WriteString(separator + " " + dbTag + "=" + s.getBindVar())
+		_, _ = s.rawUpdate.WriteString(separator + " " + s.dialect.quote(dbTag) + "=" + s.getBindVar())
 		s.updateArgs = append(s.updateArgs, args)
 		return
 	}
 }
 
+// collectSortableParams marks the params whose field carries the cursor field
+// tag; only those can order a keyset paginated query.
+func collectSortableParams(paramTag, fieldTag string, t reflect.Type, sortable map[string]bool) {
+	seen := map[reflect.Type]bool{}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || seen[t] {
+			return
+		}
+		seen[t] = true
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if param := f.Tag.Get(paramTag); param != "" {
+				if _, ok := f.Tag.Lookup(fieldTag); ok {
+					sortable[param] = true
+				}
+				continue
+			}
+			walk(f.Type)
+		}
+	}
+	walk(t)
+}
+
+// getBindVar always returns `?` so that sqlx.In can expand slice args; the
+// dialect rebinds the built query afterwards.
 func (s *sqlClausebuilder) getBindVar() string {
 	return "?"
 }
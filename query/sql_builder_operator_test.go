@@ -0,0 +1,240 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/null"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unitTestOperatorParam[T any] struct {
+	From    T   `param:"v__gte" db:"v"`
+	To      T   `param:"v__lte" db:"v"`
+	Between []T `param:"v__between" db:"v"`
+	NotIn   []T `param:"v__nin" db:"v"`
+	NotOne  T   `param:"w__nin" db:"w"`
+}
+
+type unitTestOperatorCase struct {
+	name     string
+	param    interface{}
+	wantArgs []interface{}
+}
+
+// operatorCase filters v with every range and exclusion operator, using lo
+// and hi, which are bound as wantLo and wantHi.
+func operatorCase[T any](name string, lo, hi T, wantLo, wantHi interface{}) unitTestOperatorCase {
+	return unitTestOperatorCase{
+		name:     name,
+		param:    &unitTestOperatorParam[T]{From: lo, To: hi, Between: []T{lo, hi}, NotIn: []T{lo, hi}, NotOne: lo},
+		wantArgs: []interface{}{wantLo, wantHi, wantLo, wantHi, wantLo, wantHi, wantLo},
+	}
+}
+
+func Test_sqlClausebuilder_Build_Operators(t *testing.T) {
+	lo := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	hi := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []unitTestOperatorCase{
+		operatorCase("int", 1, 2, 1, 2),
+		operatorCase("int64", int64(1), int64(2), int64(1), int64(2)),
+		operatorCase("int32", int32(1), int32(2), int32(1), int32(2)),
+		operatorCase("int16", int16(1), int16(2), int16(1), int16(2)),
+		operatorCase("int8", int8(1), int8(2), int8(1), int8(2)),
+		operatorCase("uint", uint(1), uint(2), uint(1), uint(2)),
+		operatorCase("uint64", uint64(1), uint64(2), uint64(1), uint64(2)),
+		operatorCase("uint32", uint32(1), uint32(2), uint32(1), uint32(2)),
+		operatorCase("uint16", uint16(1), uint16(2), uint16(1), uint16(2)),
+		operatorCase("uint8", uint8(1), uint8(2), uint8(1), uint8(2)),
+		operatorCase("float64", 1.5, 2.5, 1.5, 2.5),
+		operatorCase("float32", float32(1.5), float32(2.5), float32(1.5), float32(2.5)),
+		operatorCase("string", "a", "b", "a", "b"),
+		operatorCase("bool", true, true, true, true),
+		operatorCase("time", lo, hi, lo, hi),
+		operatorCase("null int64", null.Int64From(1), null.Int64From(2), int64(1), int64(2)),
+		operatorCase("null float64", null.Float64From(1.5), null.Float64From(2.5), 1.5, 2.5),
+		operatorCase("null string", null.StringFrom("a"), null.StringFrom("b"), "a", "b"),
+		operatorCase("null bool", null.BoolFrom(false), null.BoolFrom(true), false, true),
+		operatorCase("null time", null.TimeFrom(lo), null.TimeFrom(hi), lo, hi),
+		operatorCase("null date", null.DateFrom(lo), null.DateFrom(hi), lo, hi),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newMockSQLInterface(t)
+			got, gotArgs, gotCount, gotCountArgs, err := NewSQLQueryBuilder(db, "param", "db", nil).Build(tt.param)
+			require.NoError(t, err)
+			wantQuery := " WHERE 1=1 AND v>=? AND v<=? AND v BETWEEN ? AND ? AND v NOT IN (?, ?) AND w NOT IN (?);"
+			assert.Equal(t, wantQuery, got)
+			assert.Equal(t, tt.wantArgs, gotArgs)
+			assert.Equal(t, wantQuery, gotCount)
+			assert.Equal(t, tt.wantArgs, gotCountArgs)
+		})
+	}
+}
+
+func Test_sqlClausebuilder_Build_NullCheck(t *testing.T) {
+	type param struct {
+		DeletedAt  null.Bool `param:"deleted_at__isnull" db:"deleted_at"`
+		ApprovedAt bool      `param:"approved_at__isnull" db:"approved_at"`
+		ArchivedAt bool      `param:"archived_at__notnull" db:"archived_at"`
+		ParentID   null.Bool `param:"parent_id__notnull" db:"parent_id"`
+	}
+
+	tests := []struct {
+		name  string
+		param *param
+		want  string
+	}{
+		{
+			name:  "unset",
+			param: &param{},
+			want:  " WHERE 1=1;",
+		},
+		{
+			name:  "is null",
+			param: &param{DeletedAt: null.BoolFrom(true), ApprovedAt: true, ParentID: null.BoolFrom(false)},
+			want:  " WHERE 1=1 AND deleted_at IS NULL AND approved_at IS NULL AND parent_id IS NULL;",
+		},
+		{
+			name:  "is not null",
+			param: &param{DeletedAt: null.BoolFrom(false), ArchivedAt: true, ParentID: null.BoolFrom(true)},
+			want:  " WHERE 1=1 AND deleted_at IS NOT NULL AND archived_at IS NOT NULL AND parent_id IS NOT NULL;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newMockSQLInterface(t)
+			got, gotArgs, _, _, err := NewSQLQueryBuilder(db, "param", "db", nil).Build(tt.param)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Empty(t, gotArgs)
+		})
+	}
+}
+
+func Test_sqlClausebuilder_Build_OperatorErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		param interface{}
+	}{
+		{
+			name: "between with one value",
+			param: &struct {
+				Age []int64 `param:"age__between" db:"age"`
+			}{Age: []int64{1}},
+		},
+		{
+			name: "between with three values",
+			param: &struct {
+				Age []int64 `param:"age__between" db:"age"`
+			}{Age: []int64{1, 2, 3}},
+		},
+		{
+			name: "is null with a string",
+			param: &struct {
+				Name string `param:"name__isnull" db:"name"`
+			}{Name: "true"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newMockSQLInterface(t)
+			_, _, _, _, err := NewSQLQueryBuilder(db, "param", "db", nil).Build(tt.param)
+			assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(err))
+		})
+	}
+}
+
+type unitTestSearchParam struct {
+	Name  string `param:"name" db:"name"`
+	Email string `param:"email" db:"email"`
+	Phone string `param:"phone" db:"phone"`
+}
+
+type unitTestAgeRangeParam struct {
+	AgeGTE int64 `param:"age__gte" db:"age"`
+	AgeLTE int64 `param:"age__lte" db:"age"`
+}
+
+type unitTestGroupParam struct {
+	Status []int64                `param:"status" db:"status"`
+	Search unitTestSearchParam    `param:"search__or"`
+	Audit  *unitTestAuditParam    `param:"audit__or"`
+	Limit  int64                  `param:"limit" db:"limit"`
+	Range  *unitTestAgeRangeParam `param:"range__and"`
+}
+
+type unitTestAuditParam struct {
+	DeletedAt null.Bool             `param:"deleted_at__isnull" db:"deleted_at"`
+	Age       unitTestAgeRangeParam `param:"age__and"`
+}
+
+func Test_sqlClausebuilder_Build_Groups(t *testing.T) {
+	tests := []struct {
+		name     string
+		param    *unitTestGroupParam
+		want     string
+		wantArgs []interface{}
+	}{
+		{
+			name:  "empty groups add nothing",
+			param: &unitTestGroupParam{Status: []int64{1}, Audit: &unitTestAuditParam{}},
+			want:  " WHERE 1=1 AND status IN (?) LIMIT 0, 10;",
+			wantArgs: []interface{}{
+				int64(1),
+			},
+		},
+		{
+			name: "or group",
+			param: &unitTestGroupParam{
+				Status: []int64{1, 2},
+				Search: unitTestSearchParam{Name: "%jo%", Email: "%jo%"},
+			},
+			want:     " WHERE 1=1 AND status IN (?, ?) AND (name LIKE ? OR email LIKE ?) LIMIT 0, 10;",
+			wantArgs: []interface{}{int64(1), int64(2), "%jo%", "%jo%"},
+		},
+		{
+			name: "nested groups",
+			param: &unitTestGroupParam{
+				Search: unitTestSearchParam{Phone: "0812"},
+				Audit: &unitTestAuditParam{
+					DeletedAt: null.BoolFrom(false),
+					Age:       unitTestAgeRangeParam{AgeGTE: 18, AgeLTE: 60},
+				},
+				Range: &unitTestAgeRangeParam{AgeGTE: 21},
+			},
+			want:     " WHERE 1=1 AND (phone=?) AND (deleted_at IS NOT NULL OR (age>=? AND age<=?)) AND (age>=?) LIMIT 0, 10;",
+			wantArgs: []interface{}{"0812", int64(18), int64(60), int64(21)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newMockSQLInterface(t)
+			got, gotArgs, gotCount, gotCountArgs, err := NewSQLQueryBuilder(db, "param", "db", nil).Build(tt.param)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantArgs, gotArgs)
+			assert.Equal(t, tt.want[:len(tt.want)-len(" LIMIT 0, 10;")]+";", gotCount)
+			assert.Equal(t, tt.wantArgs, gotCountArgs)
+		})
+	}
+}
+
+func Test_sqlClausebuilder_BuildUpdate_Groups(t *testing.T) {
+	type update struct {
+		Status int64 `param:"status" db:"status"`
+	}
+	type where struct {
+		Search unitTestSearchParam `param:"search__or"`
+	}
+
+	db, _ := newMockSQLInterface(t)
+	got, gotArgs, err := NewSQLQueryBuilder(db, "param", "db", nil).BuildUpdate(&update{Status: 2}, &where{Search: unitTestSearchParam{Name: "jo", Email: "jo@example.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, " SET status=? WHERE 1=1 AND (name=? OR email=?);", got)
+	assert.Equal(t, []interface{}{int64(2), "jo", "jo@example.com"}, gotArgs)
+}
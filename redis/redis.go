@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	goerr "errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/bsm/redislock"
@@ -41,6 +42,20 @@ type TLSConfig struct {
 	InsecureSkipVerify bool
 }
 
+// SentinelConfig connects through Redis Sentinel to the master of
+// MasterName.
+type SentinelConfig struct {
+	MasterName string
+	Addresses  []string
+	Username   string
+	Password   string
+}
+
+// ClusterConfig connects to a Redis Cluster through its seed nodes.
+type ClusterConfig struct {
+	Addresses []string
+}
+
 type Config struct {
 	Protocol   string
 	Host       string
@@ -49,11 +64,23 @@ type Config struct {
 	Password   string
 	DefaultTTL time.Duration
 	TLS        TLSConfig
+	// Sentinel is used instead of Host and Port when MasterName is set.
+	Sentinel SentinelConfig
+	// Cluster is used instead of Host and Port when it has addresses.
+	Cluster ClusterConfig
+	// ReadFromReplica sends read-only commands to the replicas of a
+	// cluster, or of the sentinel master. Writes and locks stay on the
+	// master.
+	ReadFromReplica bool
+	// KeyPrefix namespaces every key and pub/sub channel, e.g. "billing:",
+	// so that several services can share one Redis. The flushes then only
+	// delete the keys of the namespace.
+	KeyPrefix string
 }
 
 type cache struct {
 	conf  Config
-	rdb   *redis.Client
+	rdb   redis.UniversalClient
 	log   logger.Interface
 	rlock *redislock.Client
 }
@@ -68,36 +95,117 @@ func Init(cfg Config, log logger.Interface) Interface {
 }
 
 func (c *cache) connect(ctx context.Context) {
-	redisOpts := redis.Options{
-		Network:  c.conf.Protocol,
-		Addr:     fmt.Sprintf("%s:%s", c.conf.Host, c.conf.Port),
-		Username: c.conf.Username,
-		Password: c.conf.Password,
+	client := c.newClient(ctx)
+
+	err := client.Ping(ctx).Err()
+	if err != nil {
+		c.log.Fatal(ctx, fmt.Sprintf("[FATAL] cannot connect to redis on address @%s, with error: %s", c.address(), err))
 	}
+	c.rdb = client
+	c.log.Info(ctx, fmt.Sprintf("REDIS: Address @%s", c.address()))
+
+	c.rlock = redislock.New(client)
+}
 
+// newClient returns the client of the configured topology: sentinel,
+// cluster or a single node.
+func (c *cache) newClient(ctx context.Context) redis.UniversalClient {
+	var tlsConfig *tls.Config
 	if c.conf.TLS.Enabled {
 		if c.conf.TLS.InsecureSkipVerify {
 			c.log.Warn(ctx, "redis TLS: InsecureSkipVerify is enabled — TLS certificate verification is disabled; do not use this in production")
 		}
-		redisOpts.TLSConfig = &tls.Config{
+		tlsConfig = &tls.Config{
 			InsecureSkipVerify: c.conf.TLS.InsecureSkipVerify, //nolint:gosec
 		}
 	}
 
-	client := redis.NewClient(&redisOpts)
+	switch {
+	case c.conf.Sentinel.MasterName != "":
+		opts := &redis.FailoverOptions{
+			MasterName:       c.conf.Sentinel.MasterName,
+			SentinelAddrs:    c.conf.Sentinel.Addresses,
+			SentinelUsername: c.conf.Sentinel.Username,
+			SentinelPassword: c.conf.Sentinel.Password,
+			Username:         c.conf.Username,
+			Password:         c.conf.Password,
+			TLSConfig:        tlsConfig,
+		}
+		if c.conf.ReadFromReplica {
+			// read-only commands go to any node of the master's group
+			opts.RouteRandomly = true
+			return redis.NewFailoverClusterClient(opts)
+		}
+		return redis.NewFailoverClient(opts)
+
+	case len(c.conf.Cluster.Addresses) > 0:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     c.conf.Cluster.Addresses,
+			Username:  c.conf.Username,
+			Password:  c.conf.Password,
+			ReadOnly:  c.conf.ReadFromReplica,
+			TLSConfig: tlsConfig,
+		})
+	}
 
-	err := client.Ping(ctx).Err()
-	if err != nil {
-		c.log.Fatal(ctx, fmt.Sprintf("[FATAL] cannot connect to redis on address @%s:%v, with error: %s", c.conf.Host, c.conf.Port, err))
+	return redis.NewClient(&redis.Options{
+		Network:   c.conf.Protocol,
+		Addr:      fmt.Sprintf("%s:%s", c.conf.Host, c.conf.Port),
+		Username:  c.conf.Username,
+		Password:  c.conf.Password,
+		TLSConfig: tlsConfig,
+	})
+}
+
+// address describes the configured nodes for the logs.
+func (c *cache) address() string {
+	switch {
+	case c.conf.Sentinel.MasterName != "":
+		return fmt.Sprintf("%s (sentinel %s)", c.conf.Sentinel.MasterName, strings.Join(c.conf.Sentinel.Addresses, ","))
+	case len(c.conf.Cluster.Addresses) > 0:
+		return fmt.Sprintf("%s (cluster)", strings.Join(c.conf.Cluster.Addresses, ","))
 	}
-	c.rdb = client
-	c.log.Info(ctx, fmt.Sprintf("REDIS: Address @%s:%v", c.conf.Host, c.conf.Port))
+	return fmt.Sprintf("%s:%v", c.conf.Host, c.conf.Port)
+}
 
-	c.rlock = redislock.New(client)
+// forEachMaster runs fn on every master of a cluster, and on the client
+// itself otherwise, for the commands that only reach one node such as SCAN
+// and FLUSHDB.
+func (c *cache) forEachMaster(ctx context.Context, fn func(ctx context.Context, node redis.Cmdable) error) error {
+	if cluster, ok := c.rdb.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return fn(ctx, node)
+		})
+	}
+	return fn(ctx, c.rdb)
+}
+
+// key returns key in the namespace of Config.KeyPrefix.
+func (c *cache) key(key string) string {
+	return c.conf.KeyPrefix + key
 }
 
+func (c *cache) keys(keys []string) []string {
+	if c.conf.KeyPrefix == "" {
+		return keys
+	}
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, c.key(key))
+	}
+	return prefixed
+}
+
+// pattern returns the SCAN pattern in the namespace of Config.KeyPrefix,
+// matching the prefix literally.
+func (c *cache) pattern(pattern string) string {
+	return globEscaper.Replace(c.conf.KeyPrefix) + pattern
+}
+
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
 func (c *cache) Get(ctx context.Context, key string) (string, error) {
-	s, err := c.rdb.Get(ctx, key).Result()
+	s, err := c.rdb.Get(ctx, c.key(key)).Result()
 	if err != nil {
 		return s, err
 	}
@@ -111,7 +219,7 @@ func (c *cache) SetEX(ctx context.Context, key string, val string, expTime time.
 		expTime = c.conf.DefaultTTL
 	}
 
-	err := c.rdb.SetEX(ctx, key, val, expTime).Err()
+	err := c.rdb.SetEX(ctx, c.key(key), val, expTime).Err()
 	if err != nil {
 		return errors.NewWithCode(codes.CodeRedisSetex, "%s", err.Error())
 	}
@@ -121,7 +229,7 @@ func (c *cache) SetEX(ctx context.Context, key string, val string, expTime time.
 
 func (c *cache) Lock(ctx context.Context, key string, expTime time.Duration) (*redislock.Lock, error) {
 	// Obtain lock
-	lock, err := c.rlock.Obtain(ctx, key, expTime, nil)
+	lock, err := c.rlock.Obtain(ctx, c.key(key), expTime, nil)
 	if goerr.Is(err, redislock.ErrNotObtained) {
 		return nil, err
 	} else if err != nil {
@@ -144,37 +252,54 @@ func (c *cache) LockRelease(ctx context.Context, lock *redislock.Lock) error {
 	return nil
 }
 
+// Del deletes the keys matching the pattern key, see DelPattern. Use
+// DelKeys to delete keys that may contain glob characters.
 func (c *cache) Del(ctx context.Context, key string) error {
-	var keysCount int64
-	// Use SCAN with COUNT = 0 to advance the cursor
-	iter := c.rdb.Scan(ctx, 0, key, 0).Iterator()
-	for iter.Next(ctx) {
-		c.log.Info(ctx, fmt.Sprintf("deleted key: %s", iter.Val()))
-		c.rdb.Del(ctx, iter.Val())
-		keysCount++
-	}
-	if err := iter.Err(); err != nil {
-		return err
-	}
-	c.log.Info(ctx, fmt.Sprintf("successfully deleted %d numbers of key", keysCount))
-
-	return nil
+	_, err := c.DelPattern(ctx, key)
+	return err
 }
 
 func (c *cache) FlushAll(ctx context.Context) error {
-	return c.rdb.FlushAll(ctx).Err()
+	if c.conf.KeyPrefix != "" {
+		return c.flushNamespace(ctx)
+	}
+	return c.forEachMaster(ctx, func(ctx context.Context, node redis.Cmdable) error {
+		return node.FlushAll(ctx).Err()
+	})
 }
 
 func (c *cache) FlushAllAsync(ctx context.Context) error {
-	return c.rdb.FlushAllAsync(ctx).Err()
+	if c.conf.KeyPrefix != "" {
+		return c.flushNamespace(ctx)
+	}
+	return c.forEachMaster(ctx, func(ctx context.Context, node redis.Cmdable) error {
+		return node.FlushAllAsync(ctx).Err()
+	})
 }
 
 func (c *cache) FlushDB(ctx context.Context) error {
-	return c.rdb.FlushDB(ctx).Err()
+	if c.conf.KeyPrefix != "" {
+		return c.flushNamespace(ctx)
+	}
+	return c.forEachMaster(ctx, func(ctx context.Context, node redis.Cmdable) error {
+		return node.FlushDB(ctx).Err()
+	})
 }
 
 func (c *cache) FlushDBAsync(ctx context.Context) error {
-	return c.rdb.FlushDBAsync(ctx).Err()
+	if c.conf.KeyPrefix != "" {
+		return c.flushNamespace(ctx)
+	}
+	return c.forEachMaster(ctx, func(ctx context.Context, node redis.Cmdable) error {
+		return node.FlushDBAsync(ctx).Err()
+	})
+}
+
+// flushNamespace deletes every key of Config.KeyPrefix, leaving the keys of
+// the other services.
+func (c *cache) flushNamespace(ctx context.Context) error {
+	_, err := c.DelPattern(ctx, "*")
+	return err
 }
 
 func (c *cache) GetDefaultTTL(ctx context.Context) time.Duration {
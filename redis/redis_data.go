@@ -0,0 +1,425 @@
+package redis
+
+import (
+	"context"
+	goerr "errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/go-redis/redis/v8"
+)
+
+// NoExpiration is the TTL of a key that never expires.
+const NoExpiration time.Duration = -1
+
+// deleteBatchSize is the SCAN count of DelPattern, and so the most keys it
+// unlinks in one pipeline.
+const deleteBatchSize = 500
+
+// DataStructureInterface extends Interface with counters, hashes, sets,
+// sorted sets, lists, expirations, multi-key pipelines and deletes. The client
+// returned by Init implements it:
+//
+//	ds := rdb.(redis.DataStructureInterface)
+type DataStructureInterface interface {
+	Interface
+
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	IncrBy(ctx context.Context, key string, by int64, ttl time.Duration) (int64, error)
+
+	HSet(ctx context.Context, key string, values map[string]string) error
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+
+	SAdd(ctx context.Context, key string, members ...string) (int64, error)
+	SMembers(ctx context.Context, key string) ([]string, error)
+
+	ZAdd(ctx context.Context, key string, members ...ZMember) (int64, error)
+	ZRangeByScore(ctx context.Context, key string, min, max float64, offset, count int64) ([]ZMember, error)
+
+	LPush(ctx context.Context, key string, values ...string) (int64, error)
+	BRPop(ctx context.Context, timeout time.Duration, keys ...string) (string, string, error)
+
+	Expire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+
+	SetNX(ctx context.Context, key string, val string, ttl time.Duration) (bool, error)
+	MGet(ctx context.Context, keys ...string) (map[string]string, error)
+	MSet(ctx context.Context, values map[string]string, ttl time.Duration) error
+
+	DelKeys(ctx context.Context, keys ...string) (int64, error)
+	DelPattern(ctx context.Context, pattern string) (int64, error)
+}
+
+var _ DataStructureInterface = (*cache)(nil)
+
+// ZMember is a member of a sorted set with its score.
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// incrScript increments a counter and starts its TTL when it has none, so
+// that a counter created by the increment always expires.
+var incrScript = redis.NewScript(`
+local n = redis.call('INCRBY', KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return n
+`)
+
+// Incr increments the counter at key by one, see IncrBy.
+func (c *cache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return c.IncrBy(ctx, key, 1, ttl)
+}
+
+// IncrBy increments the counter at key by by and returns its new value. A
+// counter without TTL, e.g. one created by the increment, expires after
+// ttl; 0 ttl falls back to Config.DefaultTTL, and no TTL is set when both
+// are 0.
+func (c *cache) IncrBy(ctx context.Context, key string, by int64, ttl time.Duration) (int64, error) {
+	if ttl <= 0 {
+		ttl = c.conf.DefaultTTL
+	}
+
+	n, err := incrScript.Run(ctx, c.rdb, []string{c.key(key)}, by, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return 0, errors.NewWithCode(codes.CodeCacheIncrement, "%s", err.Error())
+	}
+
+	return n, nil
+}
+
+func (c *cache) HSet(ctx context.Context, key string, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	err := c.rdb.HSet(ctx, c.key(key), values).Err()
+	if err != nil {
+		return errors.NewWithCode(codes.CodeCacheSetHashKey, "%s", err.Error())
+	}
+
+	return nil
+}
+
+// HGetAll returns every field of the hash at key, empty when it is missing.
+func (c *cache) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	values, err := c.rdb.HGetAll(ctx, c.key(key)).Result()
+	if err != nil {
+		return nil, errors.NewWithCode(codes.CodeCacheGetHashKey, "%s", err.Error())
+	}
+
+	return values, nil
+}
+
+// SAdd adds members to the set at key and returns how many were new.
+func (c *cache) SAdd(ctx context.Context, key string, members ...string) (int64, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	n, err := c.rdb.SAdd(ctx, c.key(key), toInterfaces(members)...).Result()
+	if err != nil {
+		return 0, errors.NewWithCode(codes.CodeCacheAddSetMember, "%s", err.Error())
+	}
+
+	return n, nil
+}
+
+// SMembers returns the members of the set at key, empty when it is missing.
+func (c *cache) SMembers(ctx context.Context, key string) ([]string, error) {
+	members, err := c.rdb.SMembers(ctx, c.key(key)).Result()
+	if err != nil {
+		return nil, errors.NewWithCode(codes.CodeCacheGetSetMembers, "%s", err.Error())
+	}
+
+	return members, nil
+}
+
+// ZAdd adds members to the sorted set at key, or updates their score, and
+// returns how many were new.
+func (c *cache) ZAdd(ctx context.Context, key string, members ...ZMember) (int64, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	z := make([]*redis.Z, 0, len(members))
+	for _, m := range members {
+		z = append(z, &redis.Z{Score: m.Score, Member: m.Member})
+	}
+
+	n, err := c.rdb.ZAdd(ctx, c.key(key), z...).Result()
+	if err != nil {
+		return 0, errors.NewWithCode(codes.CodeCacheAddSortedSetMember, "%s", err.Error())
+	}
+
+	return n, nil
+}
+
+// ZRangeByScore returns the members of the sorted set at key scored between
+// min and max, both inclusive, lowest first. math.Inf(-1) and math.Inf(1)
+// leave a bound open. count limits the members returned after skipping
+// offset of them; 0 count returns every member.
+func (c *cache) ZRangeByScore(ctx context.Context, key string, min, max float64, offset, count int64) ([]ZMember, error) {
+	opt := &redis.ZRangeBy{Min: formatScore(min), Max: formatScore(max)}
+	if count > 0 {
+		opt.Offset, opt.Count = offset, count
+	}
+
+	z, err := c.rdb.ZRangeByScoreWithScores(ctx, c.key(key), opt).Result()
+	if err != nil {
+		return nil, errors.NewWithCode(codes.CodeCacheGetSortedSetRange, "%s", err.Error())
+	}
+
+	members := make([]ZMember, 0, len(z))
+	for _, m := range z {
+		members = append(members, ZMember{Member: toString(m.Member), Score: m.Score})
+	}
+
+	return members, nil
+}
+
+// LPush prepends values to the list at key and returns its new length.
+func (c *cache) LPush(ctx context.Context, key string, values ...string) (int64, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	n, err := c.rdb.LPush(ctx, c.key(key), toInterfaces(values)...).Result()
+	if err != nil {
+		return 0, errors.NewWithCode(codes.CodeCachePushList, "%s", err.Error())
+	}
+
+	return n, nil
+}
+
+// BRPop pops the last value of the first non-empty list of keys, waiting
+// up to timeout for one, and returns the key it came from with the value.
+// It returns Nil when the timeout passes; 0 timeout waits until ctx is done.
+func (c *cache) BRPop(ctx context.Context, timeout time.Duration, keys ...string) (string, string, error) {
+	kv, err := c.rdb.BRPop(ctx, timeout, c.keys(keys)...).Result()
+	if goerr.Is(err, redis.Nil) {
+		return "", "", err
+	} else if err != nil {
+		return "", "", errors.NewWithCode(codes.CodeCachePopList, "%s", err.Error())
+	}
+
+	return strings.TrimPrefix(kv[0], c.conf.KeyPrefix), kv[1], nil
+}
+
+// Expire sets the TTL of key, and returns false when key is missing.
+func (c *cache) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := c.rdb.Expire(ctx, c.key(key), ttl).Result()
+	if err != nil {
+		return false, errors.NewWithCode(codes.CodeCacheSetExpiration, "%s", err.Error())
+	}
+
+	return ok, nil
+}
+
+// TTL returns the remaining time to live of key, NoExpiration when it never
+// expires, and Nil when it is missing.
+func (c *cache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.rdb.PTTL(ctx, c.key(key)).Result()
+	if err != nil {
+		return 0, errors.NewWithCode(codes.CodeCacheGetExpiration, "%s", err.Error())
+	}
+
+	switch ttl {
+	case -2:
+		return 0, Nil
+	case -1:
+		return NoExpiration, nil
+	}
+
+	return ttl, nil
+}
+
+// SetNX sets key to val, expiring after ttl, only when key does not exist,
+// and reports whether it did. 0 ttl falls back to Config.DefaultTTL, and the
+// key never expires when both are 0.
+func (c *cache) SetNX(ctx context.Context, key string, val string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		ttl = c.conf.DefaultTTL
+	}
+
+	ok, err := c.rdb.SetNX(ctx, c.key(key), val, ttl).Result()
+	if err != nil {
+		return false, errors.NewWithCode(codes.CodeCacheSetSimpleKey, "%s", err.Error())
+	}
+
+	return ok, nil
+}
+
+// MGet returns the values of keys in one pipeline, leaving the missing keys
+// out of the map.
+func (c *cache) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	values := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	cmds := make([]*redis.StringCmd, 0, len(keys))
+	_, err := c.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, key := range keys {
+			cmds = append(cmds, p.Get(ctx, c.key(key)))
+		}
+		return nil
+	})
+	if err != nil && !goerr.Is(err, redis.Nil) {
+		return nil, errors.NewWithCode(codes.CodeCachePipeline, "%s", err.Error())
+	}
+
+	for i, cmd := range cmds {
+		if v, err := cmd.Result(); err == nil {
+			values[keys[i]] = v
+		}
+	}
+
+	return values, nil
+}
+
+// MSet sets every key of values in one pipeline, each expiring after ttl;
+// 0 ttl falls back to Config.DefaultTTL, and the keys never expire when
+// both are 0.
+func (c *cache) MSet(ctx context.Context, values map[string]string, ttl time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+	if ttl <= 0 {
+		ttl = c.conf.DefaultTTL
+	}
+
+	_, err := c.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for key, val := range values {
+			p.Set(ctx, c.key(key), val, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.NewWithCode(codes.CodeCachePipeline, "%s", err.Error())
+	}
+
+	return nil
+}
+
+// DelKeys unlinks keys in one pipeline and returns how many existed. The
+// memory of the keys is reclaimed in the background. It keeps going past
+// the keys it could not delete, and returns their errors joined.
+func (c *cache) DelKeys(ctx context.Context, keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	n, err := c.unlink(ctx, c.rdb, c.keys(keys))
+	if err != nil {
+		return n, errors.WrapWithCode(err, codes.CodeCacheDeleteSimpleKey, "cannot delete every key")
+	}
+
+	return n, nil
+}
+
+// DelPattern unlinks the keys matching the glob pattern, such as "user:*",
+// in pipelines of up to deleteBatchSize keys as SCAN finds them, on every
+// master of a cluster. It returns how many keys were deleted and the errors
+// met on the way, joined.
+func (c *cache) DelPattern(ctx context.Context, pattern string) (int64, error) {
+	var (
+		mu    sync.Mutex
+		total int64
+		errs  []error
+	)
+	_ = c.forEachMaster(ctx, func(ctx context.Context, node redis.Cmdable) error {
+		var cursor uint64
+		for {
+			keys, next, err := node.Scan(ctx, cursor, c.pattern(pattern), deleteBatchSize).Result()
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return nil
+			}
+
+			n, err := c.unlink(ctx, node, keys)
+			mu.Lock()
+			total += n
+			if err != nil {
+				errs = append(errs, err)
+			}
+			mu.Unlock()
+
+			if cursor = next; cursor == 0 {
+				return nil
+			}
+		}
+	})
+
+	if len(errs) > 0 {
+		return total, errors.WrapWithCode(goerr.Join(errs...), codes.CodeCacheDeleteSimpleKey, "cannot delete every key matching %s", pattern)
+	}
+
+	return total, nil
+}
+
+// unlink unlinks keys on node in one pipeline, one command per key so that
+// the keys of a cluster may sit in different slots.
+func (c *cache) unlink(ctx context.Context, node redis.Cmdable, keys []string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	cmds := make([]*redis.IntCmd, 0, len(keys))
+	_, _ = node.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, key := range keys {
+			cmds = append(cmds, p.Unlink(ctx, key))
+		}
+		return nil
+	})
+
+	var (
+		n    int64
+		errs []error
+	)
+	for i, cmd := range cmds {
+		if err := cmd.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", keys[i], err))
+			continue
+		}
+		n += cmd.Val()
+	}
+
+	return n, goerr.Join(errs...)
+}
+
+func toInterfaces(values []string) []interface{} {
+	items := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		items = append(items, v)
+	}
+	return items
+}
+
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return ""
+}
+
+// formatScore formats a sorted set score, infinities included.
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "+inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
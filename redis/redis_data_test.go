@@ -0,0 +1,299 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMiniredisCache(t *testing.T) (*cache, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return &cache{rdb: rdb, conf: Config{DefaultTTL: time.Minute}, log: newMockLogger(t)}, mr
+}
+
+func TestCache_IncrBy(t *testing.T) {
+	c, mr := newMiniredisCache(t)
+	ctx := context.Background()
+
+	n, err := c.Incr(ctx, "hits", 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = c.IncrBy(ctx, "hits", 4, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+	// the window starts with the first increment
+	assert.Equal(t, 10*time.Second, mr.TTL("hits"))
+
+	_, err = c.Incr(ctx, "fallback", 0)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, mr.TTL("fallback"))
+
+	c.conf.DefaultTTL = 0
+	_, err = c.Incr(ctx, "forever", 0)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), mr.TTL("forever"))
+
+	require.NoError(t, mr.Set("name", "jo"))
+	_, err = c.Incr(ctx, "name", time.Second)
+	assert.Equal(t, codes.CodeCacheIncrement, errors.GetCode(err))
+}
+
+func TestCache_Hash(t *testing.T) {
+	c, _ := newMiniredisCache(t)
+	ctx := context.Background()
+
+	require.NoError(t, c.HSet(ctx, "user:1", map[string]string{"name": "jo", "role": "admin"}))
+	require.NoError(t, c.HSet(ctx, "user:1", map[string]string{"role": "owner"}))
+	require.NoError(t, c.HSet(ctx, "user:1", nil))
+	got, err := c.HGetAll(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "jo", "role": "owner"}, got)
+
+	got, err = c.HGetAll(ctx, "user:2")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	require.NoError(t, c.SetEX(ctx, "plain", "v", time.Minute))
+	assert.Equal(t, codes.CodeCacheSetHashKey, errors.GetCode(c.HSet(ctx, "plain", map[string]string{"a": "b"})))
+	_, err = c.HGetAll(ctx, "plain")
+	assert.Equal(t, codes.CodeCacheGetHashKey, errors.GetCode(err))
+}
+
+func TestCache_Set(t *testing.T) {
+	c, _ := newMiniredisCache(t)
+	ctx := context.Background()
+
+	n, err := c.SAdd(ctx, "tags", "go", "redis", "go")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	n, err = c.SAdd(ctx, "tags")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	got, err := c.SMembers(ctx, "tags")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"go", "redis"}, got)
+
+	require.NoError(t, c.SetEX(ctx, "plain", "v", time.Minute))
+	_, err = c.SAdd(ctx, "plain", "a")
+	assert.Equal(t, codes.CodeCacheAddSetMember, errors.GetCode(err))
+	_, err = c.SMembers(ctx, "plain")
+	assert.Equal(t, codes.CodeCacheGetSetMembers, errors.GetCode(err))
+}
+
+func TestCache_SortedSet(t *testing.T) {
+	c, _ := newMiniredisCache(t)
+	ctx := context.Background()
+
+	n, err := c.ZAdd(ctx, "board", ZMember{Member: "a", Score: 10}, ZMember{Member: "b", Score: 30}, ZMember{Member: "c", Score: 20.5})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	n, err = c.ZAdd(ctx, "board", ZMember{Member: "a", Score: 40})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	tests := []struct {
+		name          string
+		min, max      float64
+		offset, count int64
+		want          []ZMember
+	}{
+		{
+			name: "closed range",
+			min:  20.5,
+			max:  30,
+			want: []ZMember{{Member: "c", Score: 20.5}, {Member: "b", Score: 30}},
+		},
+		{
+			name: "open range",
+			min:  math.Inf(-1),
+			max:  math.Inf(1),
+			want: []ZMember{{Member: "c", Score: 20.5}, {Member: "b", Score: 30}, {Member: "a", Score: 40}},
+		},
+		{
+			name:   "page",
+			min:    math.Inf(-1),
+			max:    math.Inf(1),
+			offset: 1,
+			count:  1,
+			want:   []ZMember{{Member: "b", Score: 30}},
+		},
+		{
+			name: "empty",
+			min:  100,
+			max:  200,
+			want: []ZMember{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.ZRangeByScore(ctx, "board", tt.min, tt.max, tt.offset, tt.count)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	require.NoError(t, c.SetEX(ctx, "plain", "v", time.Minute))
+	_, err = c.ZAdd(ctx, "plain", ZMember{Member: "a"})
+	assert.Equal(t, codes.CodeCacheAddSortedSetMember, errors.GetCode(err))
+	_, err = c.ZRangeByScore(ctx, "plain", 0, 1, 0, 0)
+	assert.Equal(t, codes.CodeCacheGetSortedSetRange, errors.GetCode(err))
+}
+
+func TestCache_List(t *testing.T) {
+	c, _ := newMiniredisCache(t)
+	ctx := context.Background()
+
+	n, err := c.LPush(ctx, "jobs", "a", "b")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	key, val, err := c.BRPop(ctx, time.Second, "urgent", "jobs")
+	require.NoError(t, err)
+	assert.Equal(t, "jobs", key)
+	assert.Equal(t, "a", val)
+
+	_, _, err = c.BRPop(ctx, time.Second, "jobs")
+	require.NoError(t, err)
+	_, _, err = c.BRPop(ctx, 100*time.Millisecond, "jobs")
+	assert.ErrorIs(t, err, Nil)
+
+	require.NoError(t, c.SetEX(ctx, "plain", "v", time.Minute))
+	_, err = c.LPush(ctx, "plain", "a")
+	assert.Equal(t, codes.CodeCachePushList, errors.GetCode(err))
+	_, _, err = c.BRPop(ctx, time.Second, "plain")
+	assert.Equal(t, codes.CodeCachePopList, errors.GetCode(err))
+}
+
+func TestCache_Expiration(t *testing.T) {
+	c, mr := newMiniredisCache(t)
+	ctx := context.Background()
+	require.NoError(t, mr.Set("k", "v"))
+
+	ttl, err := c.TTL(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, NoExpiration, ttl)
+
+	ok, err := c.Expire(ctx, "k", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ttl, err = c.TTL(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	ok, err = c.Expire(ctx, "missing", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, err = c.TTL(ctx, "missing")
+	assert.ErrorIs(t, err, Nil)
+}
+
+func TestCache_SetNX(t *testing.T) {
+	c, mr := newMiniredisCache(t)
+	ctx := context.Background()
+
+	ok, err := c.SetNX(ctx, "k", "first", 10*time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Second, mr.TTL("k"))
+
+	ok, err = c.SetNX(ctx, "k", "second", 0)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	val, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "first", val)
+}
+
+func TestCache_Pipeline(t *testing.T) {
+	c, mr := newMiniredisCache(t)
+	ctx := context.Background()
+
+	require.NoError(t, c.MSet(ctx, map[string]string{"a": "1", "b": "2"}, 10*time.Second))
+	require.NoError(t, c.MSet(ctx, nil, 0))
+	assert.Equal(t, 10*time.Second, mr.TTL("a"))
+
+	got, err := c.MGet(ctx, "a", "missing", "b")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, got)
+
+	got, err = c.MGet(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestCache_DataStructure_Error(t *testing.T) {
+	c := &cache{rdb: newBlackholeClient(), conf: Config{DefaultTTL: time.Minute}}
+	ctx := context.Background()
+
+	_, err := c.MGet(ctx, "a")
+	assert.Equal(t, codes.CodeCachePipeline, errors.GetCode(err))
+	assert.Equal(t, codes.CodeCachePipeline, errors.GetCode(c.MSet(ctx, map[string]string{"a": "1"}, 0)))
+	_, err = c.Expire(ctx, "a", time.Minute)
+	assert.Equal(t, codes.CodeCacheSetExpiration, errors.GetCode(err))
+	_, err = c.TTL(ctx, "a")
+	assert.Equal(t, codes.CodeCacheGetExpiration, errors.GetCode(err))
+	_, err = c.SetNX(ctx, "a", "1", 0)
+	assert.Equal(t, codes.CodeCacheSetSimpleKey, errors.GetCode(err))
+}
+
+func TestCache_DelKeys(t *testing.T) {
+	c, mr := newMiniredisCache(t)
+	ctx := context.Background()
+	for _, key := range []string{"a", "b", "user:*"} {
+		require.NoError(t, mr.Set(key, "v"))
+	}
+
+	n, err := c.DelKeys(ctx, "a", "missing", "user:*")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	// the key is not a pattern
+	assert.Equal(t, []string{"b"}, mr.Keys())
+
+	n, err = c.DelKeys(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestCache_DelPattern(t *testing.T) {
+	c, mr := newMiniredisCache(t)
+	ctx := context.Background()
+	for i := 0; i < 2*deleteBatchSize+10; i++ {
+		require.NoError(t, mr.Set(fmt.Sprintf("session:%d", i), "v"))
+	}
+	require.NoError(t, mr.Set("user:1", "v"))
+
+	n, err := c.DelPattern(ctx, "session:*")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2*deleteBatchSize+10), n)
+	assert.Equal(t, []string{"user:1"}, mr.Keys())
+
+	n, err = c.DelPattern(ctx, "session:*")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	require.NoError(t, c.Del(ctx, "user:*"))
+	assert.Empty(t, mr.Keys())
+}
+
+func TestCache_Delete_Error(t *testing.T) {
+	c := &cache{rdb: newBlackholeClient()}
+	ctx := context.Background()
+
+	_, err := c.DelKeys(ctx, "a")
+	assert.Equal(t, codes.CodeCacheDeleteSimpleKey, errors.GetCode(err))
+	_, err = c.DelPattern(ctx, "a:*")
+	assert.Equal(t, codes.CodeCacheDeleteSimpleKey, errors.GetCode(err))
+}
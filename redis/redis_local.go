@@ -0,0 +1,316 @@
+package redis
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/instrument"
+	"github.com/downsized-devs/sdk-go/logger"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultLocalCacheName       = "redis"
+	defaultLocalCacheMaxEntries = 10000
+	defaultLocalCacheTTL        = time.Minute
+	defaultLocalCacheChannel    = "sdk-go:redis:invalidate"
+
+	invalidateKeyPrefix = "del:"
+	invalidateAll       = "flush"
+)
+
+// localCacheRetryDelay is the wait before listening again to invalidations
+// after the subscription failed.
+var localCacheRetryDelay = time.Second
+
+// LocalCacheInterface is an Interface keeping the values read in memory.
+type LocalCacheInterface interface {
+	Interface
+	// Close stops listening to invalidations.
+	Close() error
+}
+
+type LocalCacheConfig struct {
+	// Name labels the metrics of the cache. Defaults to "redis".
+	Name string
+	// MaxEntries bounds the keys kept in memory, the least recently used is
+	// evicted first. Defaults to 10000.
+	MaxEntries int
+	// TTL bounds how long a key is kept in memory, and so how stale it can
+	// be when an invalidation is missed. Defaults to one minute.
+	TTL time.Duration
+	// Channel is the pub/sub channel of the invalidations, shared by every
+	// replica. Defaults to "sdk-go:redis:invalidate".
+	Channel string
+}
+
+type localEntry struct {
+	key       string
+	val       string
+	expiresAt time.Time
+}
+
+// localCache serves Get from memory in front of redis. SetEX, Del and the
+// flushes drop the local copies of every replica through pub/sub.
+type localCache struct {
+	PubSubInterface
+	log   logger.Interface
+	instr instrument.InterfaceV2
+	cfg   LocalCacheConfig
+	ps    *redis.PubSub
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	// gen changes on every invalidation, so that a value read from redis
+	// before an invalidation is not kept after it.
+	gen uint64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewLocalCache returns rds with an in-memory LRU tier for Get:
+//
+//	local, err := redis.NewLocalCache(ctx, rdb.(redis.PubSubInterface), log, instr, redis.LocalCacheConfig{Name: "config"})
+//
+// Values written by SetEX or deleted by Del, on any replica, are dropped
+// from memory through pub/sub. Keys written otherwise, e.g. through
+// DataStructureInterface, stay stale for up to LocalCacheConfig.TTL. The
+// hits, misses and evictions are counted with instrument.InterfaceV2 when
+// instr implements it. ctx is only used to subscribe.
+func NewLocalCache(ctx context.Context, rds PubSubInterface, log logger.Interface, instr instrument.Interface, cfg LocalCacheConfig) (LocalCacheInterface, error) {
+	if cfg.Name == "" {
+		cfg.Name = defaultLocalCacheName
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = defaultLocalCacheMaxEntries
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultLocalCacheTTL
+	}
+	if cfg.Channel == "" {
+		cfg.Channel = defaultLocalCacheChannel
+	}
+
+	ps, err := rds.Subscribe(ctx, cfg.Channel)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &localCache{
+		PubSubInterface: rds,
+		log:             log,
+		cfg:             cfg,
+		ps:              ps,
+		entries:         map[string]*list.Element{},
+		lru:             list.New(),
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+	l.instr, _ = instr.(instrument.InterfaceV2)
+
+	go l.listen()
+
+	return l, nil
+}
+
+func (l *localCache) Get(ctx context.Context, key string) (string, error) {
+	if val, ok := l.lookup(key); ok {
+		l.count(instrument.CacheHit)
+		return val, nil
+	}
+	l.count(instrument.CacheMiss)
+
+	gen := l.generation()
+	val, err := l.PubSubInterface.Get(ctx, key)
+	if err != nil {
+		return val, err
+	}
+	l.store(key, val, gen)
+
+	return val, nil
+}
+
+func (l *localCache) SetEX(ctx context.Context, key string, val string, expTime time.Duration) error {
+	err := l.PubSubInterface.SetEX(ctx, key, val, expTime)
+	l.invalidate(ctx, key)
+	return err
+}
+
+func (l *localCache) Del(ctx context.Context, key string) error {
+	err := l.PubSubInterface.Del(ctx, key)
+	if strings.ContainsAny(key, `*?[\`) {
+		// a pattern: every key may match it
+		l.invalidateAll(ctx)
+	} else {
+		l.invalidate(ctx, key)
+	}
+	return err
+}
+
+func (l *localCache) FlushAll(ctx context.Context) error {
+	err := l.PubSubInterface.FlushAll(ctx)
+	l.invalidateAll(ctx)
+	return err
+}
+
+func (l *localCache) FlushAllAsync(ctx context.Context) error {
+	err := l.PubSubInterface.FlushAllAsync(ctx)
+	l.invalidateAll(ctx)
+	return err
+}
+
+func (l *localCache) FlushDB(ctx context.Context) error {
+	err := l.PubSubInterface.FlushDB(ctx)
+	l.invalidateAll(ctx)
+	return err
+}
+
+func (l *localCache) FlushDBAsync(ctx context.Context) error {
+	err := l.PubSubInterface.FlushDBAsync(ctx)
+	l.invalidateAll(ctx)
+	return err
+}
+
+func (l *localCache) Close() error {
+	close(l.stop)
+	err := l.ps.Close()
+	<-l.done
+	return err
+}
+
+// listen applies the invalidations published by every replica. Memory is
+// purged whenever the subscription breaks, as invalidations may be missed
+// until it is back.
+func (l *localCache) listen() {
+	defer close(l.done)
+
+	ctx := context.Background()
+	for {
+		msg, err := l.ps.Receive(ctx)
+		if err != nil {
+			select {
+			case <-l.stop:
+				return
+			default:
+			}
+			l.log.Warn(ctx, fmt.Sprintf("REDIS: [LOCAL] invalidations of %s interrupted: %s", l.cfg.Name, err))
+			l.drop("")
+			select {
+			case <-l.stop:
+				return
+			case <-time.After(localCacheRetryDelay):
+			}
+			continue
+		}
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			// subscribed again after a reconnection
+			l.drop("")
+		case *redis.Message:
+			if key, ok := strings.CutPrefix(m.Payload, invalidateKeyPrefix); ok {
+				l.drop(key)
+			} else if m.Payload == invalidateAll {
+				l.drop("")
+			}
+		}
+	}
+}
+
+func (l *localCache) invalidate(ctx context.Context, key string) {
+	l.drop(key)
+	l.publish(ctx, invalidateKeyPrefix+key)
+}
+
+func (l *localCache) invalidateAll(ctx context.Context) {
+	l.drop("")
+	l.publish(ctx, invalidateAll)
+}
+
+// publish tells the other replicas to drop their copies. A failure is only
+// logged, the write itself went through and the copies expire after TTL.
+func (l *localCache) publish(ctx context.Context, message string) {
+	if err := l.PubSubInterface.Publish(ctx, l.cfg.Channel, message); err != nil {
+		l.log.Error(ctx, fmt.Sprintf("REDIS: [LOCAL] cannot publish invalidation %s of %s: %s", message, l.cfg.Name, err))
+	}
+}
+
+func (l *localCache) lookup(key string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := e.Value.(*localEntry)
+	if !timeNow().Before(entry.expiresAt) {
+		l.lru.Remove(e)
+		delete(l.entries, key)
+		return "", false
+	}
+	l.lru.MoveToFront(e)
+
+	return entry.val, true
+}
+
+// store keeps val in memory unless key was invalidated since gen.
+func (l *localCache) store(key, val string, gen uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if gen != l.gen {
+		return
+	}
+
+	expiresAt := timeNow().Add(l.cfg.TTL)
+	if e, ok := l.entries[key]; ok {
+		entry := e.Value.(*localEntry)
+		entry.val, entry.expiresAt = val, expiresAt
+		l.lru.MoveToFront(e)
+		return
+	}
+	l.entries[key] = l.lru.PushFront(&localEntry{key: key, val: val, expiresAt: expiresAt})
+
+	for l.lru.Len() > l.cfg.MaxEntries {
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+		delete(l.entries, oldest.Value.(*localEntry).key)
+		l.count(instrument.CacheEviction)
+	}
+}
+
+// drop removes key from memory, or every key when it is empty.
+func (l *localCache) drop(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.gen++
+	if key == "" {
+		l.entries = map[string]*list.Element{}
+		l.lru.Init()
+		return
+	}
+	if e, ok := l.entries[key]; ok {
+		l.lru.Remove(e)
+		delete(l.entries, key)
+	}
+}
+
+func (l *localCache) generation() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.gen
+}
+
+func (l *localCache) count(event string) {
+	if l.instr != nil {
+		l.instr.CacheCounter(l.cfg.Name, event)
+	}
+}
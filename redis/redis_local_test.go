@@ -0,0 +1,217 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/instrument"
+	mock_instrument "github.com/downsized-devs/sdk-go/tests/mock/instrument"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// newReplicaCache returns another client of the miniredis mr, as used by
+// another replica of the service.
+func newReplicaCache(t *testing.T, mr *miniredis.Miniredis) *cache {
+	t.Helper()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return &cache{rdb: rdb, conf: Config{DefaultTTL: time.Minute}, log: newMockLogger(t)}
+}
+
+func newTestLocalCache(t *testing.T, rds PubSubInterface, instr instrument.Interface, cfg LocalCacheConfig) LocalCacheInterface {
+	t.Helper()
+	l, err := NewLocalCache(context.Background(), rds, newMockLogger(t), instr, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+func TestLocalCache_Get(t *testing.T) {
+	c, mr := newMiniredisCache(t)
+	ctx := context.Background()
+	instr := mock_instrument.NewMockInterfaceV2(gomock.NewController(t))
+	instr.EXPECT().CacheCounter("config", instrument.CacheMiss).Times(2)
+	instr.EXPECT().CacheCounter("config", instrument.CacheHit).Times(3)
+	l := newTestLocalCache(t, c, instr, LocalCacheConfig{Name: "config"})
+
+	require.NoError(t, mr.Set("flag", "on"))
+
+	for i := 0; i < 3; i++ {
+		val, err := l.Get(ctx, "flag")
+		require.NoError(t, err)
+		assert.Equal(t, "on", val)
+	}
+
+	// served from memory: redis is not read again
+	mr.Del("flag")
+	val, err := l.Get(ctx, "flag")
+	require.NoError(t, err)
+	assert.Equal(t, "on", val)
+
+	_, err = l.Get(ctx, "missing")
+	assert.ErrorIs(t, err, Nil)
+}
+
+func TestLocalCache_TTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	c, mr := newMiniredisCache(t)
+	ctx := context.Background()
+	l := newTestLocalCache(t, c, nil, LocalCacheConfig{TTL: 10 * time.Second})
+
+	require.NoError(t, mr.Set("flag", "on"))
+	val, err := l.Get(ctx, "flag")
+	require.NoError(t, err)
+	assert.Equal(t, "on", val)
+
+	// written behind the local cache
+	require.NoError(t, mr.Set("flag", "off"))
+	val, _ = l.Get(ctx, "flag")
+	assert.Equal(t, "on", val)
+
+	now = now.Add(10 * time.Second)
+	val, _ = l.Get(ctx, "flag")
+	assert.Equal(t, "off", val)
+}
+
+func TestLocalCache_Eviction(t *testing.T) {
+	c, mr := newMiniredisCache(t)
+	ctx := context.Background()
+	instr := mock_instrument.NewMockInterfaceV2(gomock.NewController(t))
+	instr.EXPECT().CacheCounter("redis", instrument.CacheMiss).Times(4)
+	instr.EXPECT().CacheCounter("redis", instrument.CacheHit).Times(1)
+	instr.EXPECT().CacheCounter("redis", instrument.CacheEviction).Times(2)
+	l := newTestLocalCache(t, c, instr, LocalCacheConfig{MaxEntries: 2})
+
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, mr.Set(key, key))
+	}
+
+	_, _ = l.Get(ctx, "a")
+	_, _ = l.Get(ctx, "b")
+	_, _ = l.Get(ctx, "a") // hit, b is now the least recently used
+	_, _ = l.Get(ctx, "c") // evicts b
+
+	// read again from redis, evicting a
+	require.NoError(t, mr.Set("b", "B"))
+	val, err := l.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, "B", val)
+}
+
+func TestLocalCache_Invalidation(t *testing.T) {
+	c, mr := newMiniredisCache(t)
+	ctx := context.Background()
+	local := newTestLocalCache(t, c, nil, LocalCacheConfig{})
+	replica := newTestLocalCache(t, newReplicaCache(t, mr), nil, LocalCacheConfig{})
+
+	require.NoError(t, local.SetEX(ctx, "flag", "on", time.Minute))
+	for _, l := range []LocalCacheInterface{local, replica} {
+		val, err := l.Get(ctx, "flag")
+		require.NoError(t, err)
+		assert.Equal(t, "on", val)
+	}
+
+	tests := []struct {
+		name  string
+		write func() error
+		want  string
+	}{
+		{
+			name:  "set on another replica",
+			write: func() error { return local.SetEX(ctx, "flag", "off", time.Minute) },
+			want:  "off",
+		},
+		{
+			name:  "delete on another replica",
+			write: func() error { return local.Del(ctx, "flag") },
+		},
+		{
+			name: "delete a pattern on another replica",
+			write: func() error {
+				if err := replica.SetEX(ctx, "flag", "on", time.Minute); err != nil {
+					return err
+				}
+				if _, err := replica.Get(ctx, "flag"); err != nil {
+					return err
+				}
+				return local.Del(ctx, "fl*")
+			},
+		},
+		{
+			name: "flush on another replica",
+			write: func() error {
+				if err := replica.SetEX(ctx, "flag", "on", time.Minute); err != nil {
+					return err
+				}
+				if _, err := replica.Get(ctx, "flag"); err != nil {
+					return err
+				}
+				return local.FlushDB(ctx)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.write())
+			assert.Eventually(t, func() bool {
+				val, err := replica.Get(ctx, "flag")
+				if tt.want == "" {
+					return errors.Is(err, Nil)
+				}
+				return err == nil && val == tt.want
+			}, time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestLocalCache_DropsStaleRead(t *testing.T) {
+	c, _ := newMiniredisCache(t)
+	l := newTestLocalCache(t, c, nil, LocalCacheConfig{}).(*localCache)
+
+	gen := l.generation()
+	// invalidated while the value was read from redis
+	l.drop("flag")
+	l.store("flag", "on", gen)
+
+	_, ok := l.lookup("flag")
+	assert.False(t, ok)
+}
+
+func TestNewLocalCache_SubscribeError(t *testing.T) {
+	c := &cache{rdb: newBlackholeClient(), log: newMockLogger(t)}
+	_, err := NewLocalCache(context.Background(), c, newMockLogger(t), nil, LocalCacheConfig{})
+	assert.Equal(t, codes.CodeCacheSubscribe, errors.GetCode(err))
+}
+
+func TestLocalCache_Resubscribe(t *testing.T) {
+	localCacheRetryDelay = 10 * time.Millisecond
+	t.Cleanup(func() { localCacheRetryDelay = time.Second })
+
+	c, mr := newMiniredisCache(t)
+	ctx := context.Background()
+	replica := newTestLocalCache(t, c, nil, LocalCacheConfig{})
+
+	require.NoError(t, mr.Set("flag", "on"))
+	_, err := replica.Get(ctx, "flag")
+	require.NoError(t, err)
+
+	// the invalidation is missed while the replica is disconnected
+	mr.Close()
+	require.NoError(t, mr.Restart())
+	require.NoError(t, mr.Set("flag", "off"))
+
+	assert.Eventually(t, func() bool {
+		val, err := replica.Get(ctx, "flag")
+		return err == nil && val == "off"
+	}, time.Second, 10*time.Millisecond)
+}
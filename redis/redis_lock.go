@@ -0,0 +1,336 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	goerr "errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bsm/redislock"
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrLockNotHeld is returned when releasing a lock that expired or was
+// taken by another holder.
+var ErrLockNotHeld = redislock.ErrLockNotHeld
+
+// RetryStrategy paces the attempts to obtain a held lock.
+type RetryStrategy = redislock.RetryStrategy
+
+// Retry strategies of LockOptions.Retry.
+var (
+	NoRetry            = redislock.NoRetry
+	LinearBackoff      = redislock.LinearBackoff
+	ExponentialBackoff = redislock.ExponentialBackoff
+	LimitRetry         = redislock.LimitRetry
+)
+
+// LockInterface extends Interface with locks that retry, keep themselves
+// alive and carry a fencing token. The client returned by Init implements
+// it:
+//
+//	locker := rdb.(redis.LockInterface)
+type LockInterface interface {
+	Interface
+
+	LockWithOptions(ctx context.Context, key string, ttl time.Duration, opts LockOptions) (*Lock, error)
+	WithLock(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context, lock *Lock) error) error
+	WithLockOptions(ctx context.Context, key string, ttl time.Duration, opts LockOptions, fn func(ctx context.Context, lock *Lock) error) error
+}
+
+var _ LockInterface = (*cache)(nil)
+
+type LockOptions struct {
+	// Retry paces the attempts while the lock is held elsewhere, e.g.
+	// ExponentialBackoff(10*time.Millisecond, time.Second). They stop when
+	// ctx is done or, when ctx has no deadline, after ttl. Defaults to
+	// NoRetry.
+	Retry RetryStrategy
+	// AutoRefresh extends the TTL of the lock in the background until it
+	// is released.
+	AutoRefresh bool
+	// RefreshInterval is how often AutoRefresh extends the TTL. Defaults
+	// to a third of the TTL.
+	RefreshInterval time.Duration
+}
+
+// Lock is a lock obtained through LockInterface.
+type Lock struct {
+	c            *cache
+	key          string
+	redisKey     string
+	value        string
+	ttl          time.Duration
+	fencingToken int64
+
+	lost     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// fencingKeyTTL is how long the fencing counter of a lock is kept after the
+// lock was last obtained.
+const fencingKeyTTL = 30 * 24 * time.Hour
+
+// obtainScript sets the lock and increments its fencing counter in one
+// step, so that the tokens follow the order the lock was obtained in. The
+// counter expires ARGV[3] milliseconds after the lock was last obtained.
+var obtainScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	local token = redis.call('INCR', KEYS[2])
+	redis.call('PEXPIRE', KEYS[2], ARGV[3])
+	return token
+end
+return 0
+`)
+
+var refreshScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// LockWithOptions obtains the lock of key for ttl. It returns
+// ErrNotObtained when the lock is still held elsewhere after the retries.
+func (c *cache) LockWithOptions(ctx context.Context, key string, ttl time.Duration, opts LockOptions) (*Lock, error) {
+	value, err := randomLockValue()
+	if err != nil {
+		return nil, errors.NewWithCode(codes.CodeFailedLock, "%s", err.Error())
+	}
+
+	retry := opts.Retry
+	if retry == nil {
+		retry = NoRetry()
+	}
+	// make sure we don't retry forever
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ttl)
+		defer cancel()
+	}
+
+	redisKey := c.key(key)
+	fencingTTL := max(fencingKeyTTL, ttl)
+	var timer *time.Timer
+	for {
+		token, err := obtainScript.Run(ctx, c.rdb, []string{redisKey, fencingKey(c.conf.KeyPrefix, redisKey)}, value, ttl.Milliseconds(), fencingTTL.Milliseconds()).Int64()
+		if err != nil {
+			return nil, errors.NewWithCode(codes.CodeFailedLock, "%s", err.Error())
+		}
+		if token > 0 {
+			lock := &Lock{
+				c:            c,
+				key:          key,
+				redisKey:     redisKey,
+				value:        value,
+				ttl:          ttl,
+				fencingToken: token,
+				lost:         make(chan struct{}),
+				stop:         make(chan struct{}),
+				done:         make(chan struct{}),
+			}
+			if opts.AutoRefresh {
+				interval := opts.RefreshInterval
+				if interval <= 0 {
+					interval = ttl / 3
+				}
+				go lock.watch(interval)
+			} else {
+				close(lock.done)
+			}
+			return lock, nil
+		}
+
+		backoff := retry.NextBackoff()
+		if backoff < 1 {
+			return nil, ErrNotObtained
+		}
+		if timer == nil {
+			timer = time.NewTimer(backoff)
+			defer timer.Stop()
+		} else {
+			timer.Reset(backoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ErrNotObtained
+		case <-timer.C:
+		}
+	}
+}
+
+// WithLock runs fn while holding the lock of key, refreshed in the
+// background every third of ttl. See WithLockOptions.
+func (c *cache) WithLock(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context, lock *Lock) error) error {
+	return c.WithLockOptions(ctx, key, ttl, LockOptions{AutoRefresh: true}, fn)
+}
+
+// WithLockOptions obtains the lock of key, runs fn and releases the lock,
+// even when fn panics. The ctx of fn is cancelled when the lock is lost: on
+// a failed refresh with AutoRefresh, or after ttl without it. The error of
+// fn is returned first; otherwise ErrLockNotHeld tells that the lock was
+// lost before fn returned, and fn did not run alone.
+func (c *cache) WithLockOptions(ctx context.Context, key string, ttl time.Duration, opts LockOptions, fn func(ctx context.Context, lock *Lock) error) (err error) {
+	lock, err := c.LockWithOptions(ctx, key, ttl, opts)
+	if err != nil {
+		return err
+	}
+
+	var fnCtx context.Context
+	var cancel context.CancelFunc
+	if opts.AutoRefresh {
+		fnCtx, cancel = context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-lock.Lost():
+				cancel()
+			case <-fnCtx.Done():
+			}
+		}()
+	} else {
+		fnCtx, cancel = context.WithTimeout(ctx, ttl)
+	}
+
+	defer func() {
+		cancel()
+		releaseErr := lock.Release(context.WithoutCancel(ctx))
+		if releaseErr == nil {
+			return
+		}
+		if err == nil {
+			err = releaseErr
+		} else {
+			c.log.Warn(ctx, fmt.Sprintf("REDIS: [LOCK] cannot release %s: %s", key, releaseErr))
+		}
+	}()
+
+	return fn(fnCtx, lock)
+}
+
+// Key returns the key of the lock.
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// FencingToken returns the number of times the lock of this key was
+// obtained, this time included. Pass it along the writes done under the
+// lock, and have their store reject a token lower than the last one seen,
+// so that a holder that lost the lock cannot overwrite its successor.
+//
+// The counter is dropped 30 days after the lock was last obtained, or
+// earlier when redis evicts it, and the tokens then start again from 1.
+// A store that keeps tokens for longer must be reset along with it.
+func (l *Lock) FencingToken() int64 {
+	return l.fencingToken
+}
+
+// Lost is closed when AutoRefresh could not extend the lock before it
+// expired, or found it taken by another holder.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Refresh extends the lock to expire after ttl. It returns ErrNotObtained
+// when the lock is no longer held.
+func (l *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	ok, err := refreshScript.Run(ctx, l.c.rdb, []string{l.redisKey}, l.value, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return errors.NewWithCode(codes.CodeFailedRefreshLock, "%s", err.Error())
+	}
+	if ok == 0 {
+		return ErrNotObtained
+	}
+
+	return nil
+}
+
+// Release stops the refresh and releases the lock. It returns
+// ErrLockNotHeld when the lock had already expired or been taken.
+func (l *Lock) Release(ctx context.Context) error {
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.done
+
+	ok, err := releaseScript.Run(ctx, l.c.rdb, []string{l.redisKey}, l.value).Int64()
+	if err != nil {
+		return errors.NewWithCode(codes.CodeFailedReleaseLock, "%s", err.Error())
+	}
+	if ok == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// watch refreshes the lock every interval until it is released. A failing
+// refresh is retried until the lock expires.
+func (l *Lock) watch(interval time.Duration) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx := context.Background()
+	expiresAt := time.Now().Add(l.ttl)
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		refreshCtx, cancel := context.WithTimeout(ctx, interval)
+		err := l.Refresh(refreshCtx, l.ttl)
+		cancel()
+		switch {
+		case err == nil:
+			expiresAt = time.Now().Add(l.ttl)
+		case goerr.Is(err, ErrNotObtained):
+			l.c.log.Warn(ctx, fmt.Sprintf("REDIS: [LOCK] %s lost", l.key))
+			close(l.lost)
+			return
+		case !time.Now().Before(expiresAt):
+			l.c.log.Warn(ctx, fmt.Sprintf("REDIS: [LOCK] %s expired: %s", l.key, err))
+			close(l.lost)
+			return
+		default:
+			l.c.log.Warn(ctx, fmt.Sprintf("REDIS: [LOCK] cannot refresh %s: %s", l.key, err))
+		}
+	}
+}
+
+// fencingKey returns the key of the fencing counter of the lock redisKey,
+// in the same cluster slot and under the same prefix, so that the namespaced
+// deletes and flushes reach it.
+func fencingKey(prefix, redisKey string) string {
+	if start := strings.IndexByte(redisKey, '{'); start >= 0 {
+		if end := strings.IndexByte(redisKey[start+1:], '}'); end > 0 {
+			// redisKey has a hash tag, which the suffixed key keeps
+			return redisKey + ":fencing"
+		}
+	}
+	return prefix + "{" + redisKey + "}:fencing"
+}
+
+func randomLockValue() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
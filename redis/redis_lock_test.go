@@ -0,0 +1,203 @@
+package redis
+
+import (
+	"context"
+	goerr "errors"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_LockWithOptions(t *testing.T) {
+	c, mr := newMiniredisCache(t)
+	ctx := context.Background()
+
+	lock, err := c.LockWithOptions(ctx, "job", time.Minute, LockOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "job", lock.Key())
+	assert.Equal(t, int64(1), lock.FencingToken())
+	assert.Equal(t, time.Minute, mr.TTL("job"))
+
+	_, err = c.LockWithOptions(ctx, "job", time.Minute, LockOptions{})
+	assert.True(t, goerr.Is(err, ErrNotObtained))
+
+	require.NoError(t, lock.Release(ctx))
+	assert.False(t, mr.Exists("job"))
+	assert.True(t, goerr.Is(lock.Release(ctx), ErrLockNotHeld))
+
+	// the token keeps growing across holders
+	lock, err = c.LockWithOptions(ctx, "job", time.Minute, LockOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), lock.FencingToken())
+	require.NoError(t, lock.Release(ctx))
+}
+
+func TestCache_LockWithOptions_Retry(t *testing.T) {
+	c, _ := newMiniredisCache(t)
+	ctx := context.Background()
+
+	held, err := c.LockWithOptions(ctx, "job", time.Minute, LockOptions{})
+	require.NoError(t, err)
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		_ = held.Release(ctx)
+	}()
+
+	tests := []struct {
+		name    string
+		retry   RetryStrategy
+		wantErr error
+	}{
+		{
+			name:    "gives up after the retries",
+			retry:   LimitRetry(LinearBackoff(time.Millisecond), 2),
+			wantErr: ErrNotObtained,
+		},
+		{
+			name:  "obtained once released",
+			retry: ExponentialBackoff(time.Millisecond, 10*time.Millisecond),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lock, err := c.LockWithOptions(ctx, "job", time.Minute, LockOptions{Retry: tt.retry})
+			if tt.wantErr != nil {
+				assert.True(t, goerr.Is(err, tt.wantErr))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), lock.FencingToken())
+			require.NoError(t, lock.Release(ctx))
+		})
+	}
+}
+
+func TestCache_LockWithOptions_AutoRefresh(t *testing.T) {
+	c, mr := newMiniredisCache(t)
+	ctx := context.Background()
+
+	lock, err := c.LockWithOptions(ctx, "job", 300*time.Millisecond, LockOptions{
+		AutoRefresh:     true,
+		RefreshInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	mr.FastForward(200 * time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return mr.TTL("job") == 300*time.Millisecond
+	}, time.Second, 5*time.Millisecond)
+
+	// taken over once expired
+	require.NoError(t, mr.Set("job", "another holder"))
+	select {
+	case <-lock.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lost lock was not reported")
+	}
+	assert.True(t, goerr.Is(lock.Release(ctx), ErrLockNotHeld))
+	got, _ := mr.Get("job")
+	assert.Equal(t, "another holder", got)
+}
+
+func TestCache_WithLock(t *testing.T) {
+	c, mr := newMiniredisCache(t)
+	ctx := context.Background()
+	errJob := goerr.New("job failed")
+
+	tests := []struct {
+		name    string
+		fn      func(ctx context.Context, lock *Lock) error
+		wantErr error
+	}{
+		{
+			name: "runs fn under the lock",
+			fn: func(ctx context.Context, lock *Lock) error {
+				assert.True(t, mr.Exists("job"))
+				assert.Positive(t, lock.FencingToken())
+				return nil
+			},
+		},
+		{
+			name:    "returns the error of fn",
+			fn:      func(ctx context.Context, lock *Lock) error { return errJob },
+			wantErr: errJob,
+		},
+		{
+			name: "cancels fn when the lock is lost",
+			fn: func(ctx context.Context, lock *Lock) error {
+				mr.Del("job")
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Second):
+					t.Error("ctx was not cancelled")
+				}
+				return nil
+			},
+			wantErr: ErrLockNotHeld,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.WithLockOptions(ctx, "job", 300*time.Millisecond, LockOptions{
+				AutoRefresh:     true,
+				RefreshInterval: 10 * time.Millisecond,
+			}, tt.fn)
+			if tt.wantErr != nil {
+				assert.True(t, goerr.Is(err, tt.wantErr), err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.False(t, mr.Exists("job"), "lock was not released")
+		})
+	}
+
+	t.Run("held elsewhere", func(t *testing.T) {
+		lock, err := c.LockWithOptions(ctx, "job", time.Minute, LockOptions{})
+		require.NoError(t, err)
+		defer lock.Release(ctx) //nolint:errcheck
+
+		err = c.WithLock(ctx, "job", time.Minute, func(ctx context.Context, lock *Lock) error {
+			t.Error("fn ran without the lock")
+			return nil
+		})
+		assert.True(t, goerr.Is(err, ErrNotObtained))
+	})
+
+	t.Run("released on panic", func(t *testing.T) {
+		assert.Panics(t, func() {
+			_ = c.WithLock(ctx, "job", time.Minute, func(ctx context.Context, lock *Lock) error {
+				panic("boom")
+			})
+		})
+		assert.False(t, mr.Exists("job"))
+	})
+}
+
+func TestCache_LockWithOptions_Error(t *testing.T) {
+	c := cache{rdb: newBlackholeClient(), log: newMockLogger(t)}
+	_, err := c.LockWithOptions(context.Background(), "job", time.Minute, LockOptions{})
+	assert.Equal(t, codes.CodeFailedLock, errors.GetCode(err))
+}
+
+func TestFencingKey(t *testing.T) {
+	tests := []struct {
+		prefix string
+		key    string
+		want   string
+	}{
+		{key: "job", want: "{job}:fencing"},
+		{key: "{tenant:1}:job", want: "{tenant:1}:job:fencing"},
+		{key: "job:{}", want: "{job:{}}:fencing"},
+		{prefix: "billing:", key: "billing:job", want: "billing:{billing:job}:fencing"},
+		{prefix: "billing:", key: "billing:{tenant:1}:job", want: "billing:{tenant:1}:job:fencing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.want, fencingKey(tt.prefix, tt.key))
+		})
+	}
+}
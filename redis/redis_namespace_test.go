@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/bsm/redislock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newNamespacedCache(t *testing.T, mr *miniredis.Miniredis, prefix string) *cache {
+	t.Helper()
+	c := newReplicaCache(t, mr)
+	c.conf.KeyPrefix = prefix
+	c.rlock = redislock.New(c.rdb)
+	return c
+}
+
+func sortedKeys(mr *miniredis.Miniredis) []string {
+	keys := mr.Keys()
+	sort.Strings(keys)
+	return keys
+}
+
+func TestCache_KeyPrefix(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c := newNamespacedCache(t, mr, "billing:")
+	ctx := context.Background()
+
+	require.NoError(t, c.SetEX(ctx, "invoice:1", "v", time.Minute))
+	val, err := c.Get(ctx, "invoice:1")
+	require.NoError(t, err)
+	assert.Equal(t, "v", val)
+
+	_, err = c.Incr(ctx, "hits", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, c.MSet(ctx, map[string]string{"a": "1"}, time.Minute))
+	got, err := c.MGet(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1"}, got)
+
+	_, err = c.LPush(ctx, "jobs", "job-1")
+	require.NoError(t, err)
+	key, job, err := c.BRPop(ctx, time.Second, "jobs")
+	require.NoError(t, err)
+	assert.Equal(t, "jobs", key)
+	assert.Equal(t, "job-1", job)
+
+	lock, err := c.Lock(ctx, "cron", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, mr.Exists("billing:cron"))
+	require.NoError(t, c.LockRelease(ctx, lock))
+
+	fenced, err := c.LockWithOptions(ctx, "cron", time.Minute, LockOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "cron", fenced.Key())
+	assert.True(t, mr.Exists("billing:cron"))
+	require.NoError(t, fenced.Release(ctx))
+
+	assert.Equal(t, []string{"billing:a", "billing:hits", "billing:invoice:1", "billing:{billing:cron}:fencing"}, sortedKeys(mr))
+	assert.Equal(t, fencingKeyTTL, mr.TTL("billing:{billing:cron}:fencing"))
+
+	// the namespaced flush drops the fencing counter too
+	require.NoError(t, c.FlushAll(ctx))
+	assert.Empty(t, sortedKeys(mr))
+}
+
+func TestCache_KeyPrefix_PubSub(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c := newNamespacedCache(t, mr, "billing:")
+	ctx := context.Background()
+
+	ps, err := c.Subscribe(ctx, "events")
+	require.NoError(t, err)
+	defer ps.Close()
+	assert.Equal(t, 1, mr.PubSubNumSub("billing:events")["billing:events"])
+
+	require.NoError(t, c.Publish(ctx, "events", "hello"))
+	msg, err := ps.ReceiveMessage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", msg.Payload)
+}
+
+func TestCache_KeyPrefix_Delete(t *testing.T) {
+	mr := miniredis.RunT(t)
+	billing := newNamespacedCache(t, mr, "billing:")
+	// glob characters of the prefix are matched literally
+	glob := newNamespacedCache(t, mr, "b*:")
+	ctx := context.Background()
+
+	for _, key := range []string{"billing:user:1", "billing:user:2", "billing:plan", "b*:user:1", "orders:user:1"} {
+		require.NoError(t, mr.Set(key, "v"))
+	}
+
+	n, err := billing.DelPattern(ctx, "user:*")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, err = glob.DelKeys(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Equal(t, []string{"billing:plan", "orders:user:1"}, sortedKeys(mr))
+
+	require.NoError(t, glob.SetEX(ctx, "plan", "v", time.Minute))
+	require.NoError(t, glob.FlushDB(ctx))
+	assert.Equal(t, []string{"billing:plan", "orders:user:1"}, sortedKeys(mr))
+
+	// the other services keep their keys
+	require.NoError(t, billing.FlushAll(ctx))
+	assert.Equal(t, []string{"orders:user:1"}, sortedKeys(mr))
+}
@@ -0,0 +1,44 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/go-redis/redis/v8"
+)
+
+// PubSubInterface extends Interface with publish/subscribe. The client
+// returned by Init implements it:
+//
+//	ps := rdb.(redis.PubSubInterface)
+type PubSubInterface interface {
+	Interface
+
+	Publish(ctx context.Context, channel string, message string) error
+	Subscribe(ctx context.Context, channels ...string) (*redis.PubSub, error)
+}
+
+var _ PubSubInterface = (*cache)(nil)
+
+// Publish sends message to every subscriber of channel.
+func (c *cache) Publish(ctx context.Context, channel string, message string) error {
+	err := c.rdb.Publish(ctx, c.key(channel), message).Err()
+	if err != nil {
+		return errors.NewWithCode(codes.CodeCachePublish, "%s", err.Error())
+	}
+
+	return nil
+}
+
+// Subscribe subscribes to channels and returns once redis confirmed it. The
+// subscription reconnects by itself, and must be closed by the caller.
+func (c *cache) Subscribe(ctx context.Context, channels ...string) (*redis.PubSub, error) {
+	ps := c.rdb.Subscribe(ctx, c.keys(channels)...)
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return nil, errors.NewWithCode(codes.CodeCacheSubscribe, "%s", err.Error())
+	}
+
+	return ps, nil
+}
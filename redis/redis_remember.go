@@ -0,0 +1,198 @@
+package redis
+
+import (
+	"context"
+	stdsql "database/sql"
+	"fmt"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/logger"
+	"github.com/downsized-devs/sdk-go/parser"
+	"golang.org/x/sync/singleflight"
+)
+
+const defaultRefreshTimeout = 30 * time.Second
+
+// timeNow dates the cached entries, replaced in tests.
+var timeNow = time.Now
+
+type CacheAsideConfig struct {
+	// NotFoundTTL is how long a not-found result is cached when
+	// RememberOptions.NotFoundTTL is zero. Zero does not cache them.
+	NotFoundTTL time.Duration
+	// RefreshTimeout bounds each load, shared by the callers of a key, and
+	// the background refresh of a stale entry. Defaults to 30s.
+	RefreshTimeout time.Duration
+	// IsNotFound reports whether a loader error means the value does not
+	// exist. Defaults to matching database/sql.ErrNoRows, which is also
+	// sql.ErrNotFound, and Nil.
+	IsNotFound func(err error) bool
+}
+
+type RememberOptions struct {
+	// TTL is how long the value stays cached. Zero uses the redis
+	// DefaultTTL.
+	TTL time.Duration
+	// SoftTTL is how long the value stays fresh. A stale value is still
+	// returned, and refreshed in the background. Zero, or a SoftTTL not
+	// below TTL, keeps the value fresh until it expires.
+	SoftTTL time.Duration
+	// NotFoundTTL overrides CacheAsideConfig.NotFoundTTL.
+	NotFoundTTL time.Duration
+}
+
+// CacheAside caches the values of loaders in redis as JSON. Concurrent
+// loads of a key in the process share one loader call.
+type CacheAside struct {
+	redis Interface
+	json  parser.JsonInterface
+	log   logger.Interface
+	cfg   CacheAsideConfig
+	group singleflight.Group
+}
+
+// cacheEntry is the cached form of a value. FreshUntil is in unix
+// milliseconds, zero when the value does not go stale.
+type cacheEntry[T any] struct {
+	Value      T     `json:"value"`
+	FreshUntil int64 `json:"freshUntil,omitempty"`
+	NotFound   bool  `json:"notFound,omitempty"`
+}
+
+func NewCacheAside(rds Interface, json parser.JsonInterface, log logger.Interface, cfg CacheAsideConfig) *CacheAside {
+	if cfg.RefreshTimeout <= 0 {
+		cfg.RefreshTimeout = defaultRefreshTimeout
+	}
+	if cfg.IsNotFound == nil {
+		cfg.IsNotFound = func(err error) bool {
+			return errors.Is(err, stdsql.ErrNoRows) || errors.Is(err, Nil)
+		}
+	}
+	return &CacheAside{redis: rds, json: json, log: log, cfg: cfg}
+}
+
+// Remember returns the value cached at key, or loads it, caches it for
+// ttl and returns it:
+//
+//	user, err := redis.Remember(ctx, cache, "user:"+id, time.Hour, func(ctx context.Context) (User, error) {
+//		return sql.Get[User](ctx, db.Follower(), "getUser", "SELECT id, name FROM users WHERE id = ?", id)
+//	})
+//
+// See RememberWithOptions.
+func Remember[T any](ctx context.Context, c *CacheAside, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	return RememberWithOptions(ctx, c, key, RememberOptions{TTL: ttl}, loader)
+}
+
+// RememberWithOptions is Remember with a soft TTL and a not-found TTL. A
+// not-found loader error, cached or not, is returned as a
+// codes.CodeCacheNotFound error. Other loader errors are returned as they
+// are and not cached. Redis errors are logged and fall back to the loader,
+// so that the cache never fails a read. The loader does not run with ctx,
+// which would end it for every caller of key, but with its values and
+// CacheAsideConfig.RefreshTimeout; a caller whose ctx is done stops waiting
+// with a codes.CodeContextCanceled error.
+func RememberWithOptions[T any](ctx context.Context, c *CacheAside, key string, opts RememberOptions, loader func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+
+	if entry, ok := readEntry[T](ctx, c, key); ok {
+		if entry.NotFound {
+			return zero, errors.NewWithCode(codes.CodeCacheNotFound, "%s not found", key)
+		}
+		if entry.FreshUntil > 0 && timeNow().UnixMilli() >= entry.FreshUntil {
+			// the first caller past the soft TTL starts the refresh, the
+			// others keep the stale value meanwhile
+			c.group.DoChan(key, func() (interface{}, error) {
+				refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.cfg.RefreshTimeout)
+				defer cancel()
+				v, err := loadEntry(refreshCtx, c, key, opts, loader)
+				if err != nil && errors.GetCode(err) != codes.CodeCacheNotFound {
+					c.log.Warn(ctx, fmt.Sprintf("REDIS: [CACHE] cannot refresh %s: %s", key, err))
+				}
+				return v, err
+			})
+		}
+		return entry.Value, nil
+	}
+
+	// the load is shared by every caller of key, so that it outlives the
+	// caller that started it; each caller only waits while its ctx lasts
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.cfg.RefreshTimeout)
+		defer cancel()
+		return loadEntry(loadCtx, c, key, opts, loader)
+	})
+	var res singleflight.Result
+	select {
+	case <-ctx.Done():
+		return zero, errors.WrapWithCode(ctx.Err(), codes.CodeContextCanceled, "%s not loaded: %s", key, ctx.Err().Error())
+	case res = <-ch:
+	}
+	v, err := res.Val, res.Err
+	if err != nil || v == nil {
+		return zero, err
+	}
+	value, ok := v.(T)
+	if !ok {
+		return zero, errors.NewWithCode(codes.CodeCacheInvalidCastType, "%s is loaded as %T, not %T", key, v, zero)
+	}
+	return value, nil
+}
+
+// loadEntry runs loader and caches its value, or its not-found result.
+func loadEntry[T any](ctx context.Context, c *CacheAside, key string, opts RememberOptions, loader func(ctx context.Context) (T, error)) (interface{}, error) {
+	value, err := loader(ctx)
+	if err != nil {
+		if !c.cfg.IsNotFound(err) {
+			return nil, err
+		}
+		ttl := opts.NotFoundTTL
+		if ttl <= 0 {
+			ttl = c.cfg.NotFoundTTL
+		}
+		if ttl > 0 {
+			writeEntry(ctx, c, key, cacheEntry[T]{NotFound: true}, ttl)
+		}
+		return nil, errors.WrapWithCode(err, codes.CodeCacheNotFound, "%s not found", key)
+	}
+
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = c.redis.GetDefaultTTL(ctx)
+	}
+	entry := cacheEntry[T]{Value: value}
+	if opts.SoftTTL > 0 && opts.SoftTTL < ttl {
+		entry.FreshUntil = timeNow().Add(opts.SoftTTL).UnixMilli()
+	}
+	writeEntry(ctx, c, key, entry, ttl)
+	return value, nil
+}
+
+// readEntry reports whether key was cached, with its entry when it was.
+func readEntry[T any](ctx context.Context, c *CacheAside, key string) (cacheEntry[T], bool) {
+	var entry cacheEntry[T]
+	val, err := c.redis.Get(ctx, key)
+	if errors.Is(err, Nil) {
+		return entry, false
+	} else if err != nil {
+		c.log.Warn(ctx, fmt.Sprintf("REDIS: [CACHE] cannot read %s: %s", key, err))
+		return entry, false
+	}
+	if err := c.json.Unmarshal([]byte(val), &entry); err != nil {
+		c.log.Warn(ctx, fmt.Sprintf("REDIS: [CACHE] cannot decode %s: %s", key, err))
+		return entry, false
+	}
+	return entry, true
+}
+
+func writeEntry[T any](ctx context.Context, c *CacheAside, key string, entry cacheEntry[T], ttl time.Duration) {
+	val, err := c.json.Marshal(entry)
+	if err != nil {
+		c.log.Warn(ctx, fmt.Sprintf("REDIS: [CACHE] cannot encode %s: %s", key, err))
+		return
+	}
+	if err := c.redis.SetEX(ctx, key, string(val), ttl); err != nil {
+		c.log.Warn(ctx, fmt.Sprintf("REDIS: [CACHE] cannot store %s: %s", key, err))
+	}
+}
@@ -0,0 +1,234 @@
+package redis
+
+import (
+	"context"
+	stdsql "database/sql"
+	goerr "errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rememberTestUser struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func newTestCacheAside(t *testing.T, cfg CacheAsideConfig) (*CacheAside, *cache) {
+	t.Helper()
+	c, _ := newMiniredisCache(t)
+	log := newMockLogger(t)
+	return NewCacheAside(c, parser.Init(log, parser.Options{}).JsonParser(), log, cfg), c
+}
+
+func TestRemember(t *testing.T) {
+	ca, c := newTestCacheAside(t, CacheAsideConfig{})
+	ctx := context.Background()
+
+	var calls int32
+	loader := func(ctx context.Context) (rememberTestUser, error) {
+		atomic.AddInt32(&calls, 1)
+		return rememberTestUser{ID: 1, Name: "jo"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := Remember(ctx, ca, "user:1", time.Hour, loader)
+		require.NoError(t, err)
+		assert.Equal(t, rememberTestUser{ID: 1, Name: "jo"}, got)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	ttl, err := c.TTL(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, ttl)
+
+	// errors are returned and not cached
+	failing := errors.NewWithCode(codes.CodeSQLRead, "db down")
+	_, err = Remember(ctx, ca, "user:2", time.Hour, func(ctx context.Context) (rememberTestUser, error) {
+		return rememberTestUser{}, failing
+	})
+	assert.Equal(t, codes.CodeSQLRead, errors.GetCode(err))
+	_, err = c.Get(ctx, "user:2")
+	assert.ErrorIs(t, err, Nil)
+
+	// an undecodable entry is loaded again
+	require.NoError(t, c.SetEX(ctx, "user:3", "{", time.Hour))
+	got, err := Remember(ctx, ca, "user:3", time.Hour, loader)
+	require.NoError(t, err)
+	assert.Equal(t, "jo", got.Name)
+}
+
+func TestRemember_Singleflight(t *testing.T) {
+	ca, _ := newTestCacheAside(t, CacheAsideConfig{})
+	ctx := context.Background()
+
+	var calls int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (int64, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int64, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := Remember(ctx, ca, "answer", time.Minute, loader)
+			assert.NoError(t, err)
+			results[i] = v
+		}(i)
+	}
+	// let the callers pile up behind the first load
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, v := range results {
+		assert.Equal(t, int64(42), v)
+	}
+}
+
+func TestRemember_CallerCancelled(t *testing.T) {
+	ca, _ := newTestCacheAside(t, CacheAsideConfig{})
+
+	var calls int32
+	started, release := make(chan struct{}), make(chan struct{})
+	loader := func(ctx context.Context) (int64, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		select {
+		case <-release:
+			return 42, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	firstCtx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := Remember(firstCtx, ca, "answer", time.Minute, loader)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan int64, 1)
+	go func() {
+		v, err := Remember(context.Background(), ca, "answer", time.Minute, loader)
+		assert.NoError(t, err)
+		second <- v
+	}()
+
+	// let the second caller join the load, then the first one goes away
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assert.Equal(t, codes.CodeContextCanceled, errors.GetCode(<-firstErr))
+
+	close(release)
+	assert.Equal(t, int64(42), <-second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRemember_SoftTTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	ca, _ := newTestCacheAside(t, CacheAsideConfig{})
+	ctx := context.Background()
+	opts := RememberOptions{TTL: time.Hour, SoftTTL: time.Minute}
+
+	var version int32
+	refreshed := make(chan struct{}, 1)
+	loader := func(ctx context.Context) (int32, error) {
+		v := atomic.AddInt32(&version, 1)
+		if v > 1 {
+			select {
+			case refreshed <- struct{}{}:
+			default:
+			}
+		}
+		return v, nil
+	}
+
+	got, err := RememberWithOptions(ctx, ca, "config", opts, loader)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), got)
+
+	// still fresh
+	got, err = RememberWithOptions(ctx, ca, "config", opts, loader)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), got)
+
+	// stale: served at once, refreshed in the background
+	now = now.Add(2 * time.Minute)
+	got, err = RememberWithOptions(ctx, ca, "config", opts, loader)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), got)
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("stale entry was not refreshed")
+	}
+	// a read racing the refresh may start another one, so only check that
+	// the stale value was replaced
+	assert.Eventually(t, func() bool {
+		got, err := RememberWithOptions(ctx, ca, "config", opts, loader)
+		return err == nil && got > 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRemember_NotFound(t *testing.T) {
+	ca, c := newTestCacheAside(t, CacheAsideConfig{NotFoundTTL: 30 * time.Second})
+	ctx := context.Background()
+
+	var calls int32
+	loader := func(ctx context.Context) (rememberTestUser, error) {
+		atomic.AddInt32(&calls, 1)
+		return rememberTestUser{}, stdsql.ErrNoRows
+	}
+
+	_, err := Remember(ctx, ca, "user:9", time.Hour, loader)
+	assert.Equal(t, codes.CodeCacheNotFound, errors.GetCode(err))
+	assert.True(t, goerr.Is(err, stdsql.ErrNoRows))
+
+	// cached not-found result
+	_, err = Remember(ctx, ca, "user:9", time.Hour, loader)
+	assert.Equal(t, codes.CodeCacheNotFound, errors.GetCode(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	ttl, err := c.TTL(ctx, "user:9")
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, ttl)
+
+	// no negative caching
+	ca.cfg.NotFoundTTL = 0
+	_, err = Remember(ctx, ca, "user:11", time.Hour, loader)
+	assert.Equal(t, codes.CodeCacheNotFound, errors.GetCode(err))
+	_, err = c.Get(ctx, "user:11")
+	assert.ErrorIs(t, err, Nil)
+}
+
+func TestRemember_RedisDown(t *testing.T) {
+	log := newMockLogger(t)
+	c := &cache{rdb: newBlackholeClient(), conf: Config{DefaultTTL: time.Minute}}
+	ca := NewCacheAside(c, parser.Init(log, parser.Options{}).JsonParser(), log, CacheAsideConfig{})
+
+	got, err := Remember(context.Background(), ca, "user:1", 0, func(ctx context.Context) (string, error) {
+		return "jo", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "jo", got)
+}
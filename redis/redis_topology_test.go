@@ -0,0 +1,103 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_newClient(t *testing.T) {
+	tests := []struct {
+		name        string
+		conf        Config
+		wantCluster bool
+		wantAddr    string
+		wantAddress string
+	}{
+		{
+			name:        "single node",
+			conf:        Config{Protocol: "tcp", Host: "10.0.0.1", Port: "6379"},
+			wantAddr:    "10.0.0.1:6379",
+			wantAddress: "10.0.0.1:6379",
+		},
+		{
+			name: "sentinel",
+			conf: Config{
+				Host:     "ignored",
+				Sentinel: SentinelConfig{MasterName: "main", Addresses: []string{"10.0.0.1:26379", "10.0.0.2:26379"}},
+			},
+			wantAddr:    "FailoverClient",
+			wantAddress: "main (sentinel 10.0.0.1:26379,10.0.0.2:26379)",
+		},
+		{
+			name: "sentinel replicas",
+			conf: Config{
+				Sentinel:        SentinelConfig{MasterName: "main", Addresses: []string{"10.0.0.1:26379"}},
+				ReadFromReplica: true,
+			},
+			wantCluster: true,
+			wantAddress: "main (sentinel 10.0.0.1:26379)",
+		},
+		{
+			name:        "cluster",
+			conf:        Config{Cluster: ClusterConfig{Addresses: []string{"10.0.0.1:7000", "10.0.0.2:7000"}}, ReadFromReplica: true},
+			wantCluster: true,
+			wantAddress: "10.0.0.1:7000,10.0.0.2:7000 (cluster)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &cache{conf: tt.conf, log: newMockLogger(t)}
+			client := c.newClient(context.Background())
+			defer client.Close()
+
+			assert.Equal(t, tt.wantAddress, c.address())
+			if tt.wantCluster {
+				cluster, ok := client.(*redis.ClusterClient)
+				require.True(t, ok)
+				assert.Equal(t, tt.conf.ReadFromReplica, cluster.Options().ReadOnly || cluster.Options().RouteRandomly)
+				return
+			}
+			single, ok := client.(*redis.Client)
+			require.True(t, ok)
+			assert.Equal(t, tt.wantAddr, single.Options().Addr)
+		})
+	}
+}
+
+// TestInit_Cluster runs the Interface on a cluster client seeded with a
+// miniredis node, which serves every slot.
+func TestInit_Cluster(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := Init(Config{Cluster: ClusterConfig{Addresses: []string{mr.Addr()}}, DefaultTTL: time.Minute}, newMockLogger(t))
+	c := rdb.(*cache)
+	t.Cleanup(func() { _ = c.rdb.Close() })
+	require.IsType(t, &redis.ClusterClient{}, c.rdb)
+	ctx := context.Background()
+
+	require.NoError(t, rdb.Ping(ctx))
+	require.NoError(t, rdb.SetEX(ctx, "user:1", "jo", 0))
+	got, err := rdb.Get(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, "jo", got)
+
+	lock, err := rdb.Lock(ctx, "lock:job", time.Minute)
+	require.NoError(t, err)
+	_, err = rdb.Lock(ctx, "lock:job", time.Minute)
+	assert.ErrorIs(t, err, ErrNotObtained)
+	require.NoError(t, rdb.LockRelease(ctx, lock))
+
+	require.NoError(t, rdb.SetEX(ctx, "user:2", "al", 0))
+	require.NoError(t, rdb.Del(ctx, "user:*"))
+	assert.False(t, mr.Exists("user:1"))
+	assert.False(t, mr.Exists("user:2"))
+
+	require.NoError(t, rdb.SetEX(ctx, "k", "v", 0))
+	require.NoError(t, rdb.FlushDB(ctx))
+	assert.Empty(t, mr.Keys())
+}
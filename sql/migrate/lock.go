@@ -0,0 +1,159 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/sql"
+)
+
+const lockPollInterval = 200 * time.Millisecond
+
+// withLock runs fn while holding the advisory lock for cfg.LockName, so that
+// only one replica migrates at a time.
+//
+// Postgres and MySQL locks are session scoped, so they are taken on a
+// transaction that pins one pooled connection for the duration of fn. SQLite
+// has no advisory locks; a lock file next to the database is used instead. A
+// runner that crashes leaves its lock file behind, which then blocks every
+// later run until it is removed by hand; the file names its holder.
+func (m *migrator) withLock(ctx context.Context, fn func() error) error {
+	lockCtx, cancel := context.WithTimeout(ctx, m.cfg.LockTimeout)
+	defer cancel()
+
+	var (
+		unlock func()
+		err    error
+	)
+	switch m.driver {
+	case "postgres", "mysql":
+		unlock, err = m.sessionLock(ctx, lockCtx)
+	case "sqlite3":
+		unlock, err = m.fileLock(ctx, lockCtx)
+	default:
+		unlock = func() {}
+	}
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fn()
+}
+
+func (m *migrator) sessionLock(ctx, lockCtx context.Context) (func(), error) {
+	tx, err := m.db.Leader().BeginTx(ctx, "migrateLock", sql.TxOptions{})
+	if err != nil {
+		return nil, errors.WrapWithCode(err, codes.CodeSQLTxBegin, "cannot start migration lock transaction")
+	}
+
+	lockQuery, unlockQuery, key := `SELECT pg_try_advisory_lock($1)`, `SELECT pg_advisory_unlock($1)`, interface{}(lockKey(m.cfg.LockName))
+	if m.driver == "mysql" {
+		lockQuery, unlockQuery, key = `SELECT COALESCE(GET_LOCK(?, 0), 0) = 1`, `SELECT RELEASE_LOCK(?)`, m.cfg.LockName
+	}
+
+	for {
+		var acquired bool
+		if err := tx.Get("migrateLock", lockQuery, &acquired, key); err != nil {
+			tx.Rollback()
+			return nil, errors.WrapWithCode(err, codes.CodeSQLMigration, "cannot take migration lock %s", m.cfg.LockName)
+		}
+		if acquired {
+			break
+		}
+
+		select {
+		case <-lockCtx.Done():
+			tx.Rollback()
+			return nil, errors.NewWithCode(codes.CodeSQLMigration, "timed out waiting for migration lock %s", m.cfg.LockName)
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	return func() {
+		var released bool
+		if err := tx.Get("migrateUnlock", unlockQuery, &released, key); err != nil {
+			m.log.Error(ctx, errors.WrapWithCode(err, codes.CodeSQLMigration, "cannot release migration lock %s", m.cfg.LockName))
+		}
+		tx.Rollback()
+	}, nil
+}
+
+func (m *migrator) fileLock(ctx, lockCtx context.Context) (func(), error) {
+	file, err := m.sqliteFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// in-memory databases live in a single process, nothing to coordinate
+	if file == "" {
+		return func() {}, nil
+	}
+
+	lockPath := fmt.Sprintf("%s.%s.lock", file, m.cfg.LockName)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_, _ = f.WriteString(lockHolder())
+			_ = f.Close()
+			break
+		}
+		if !os.IsExist(err) {
+			return nil, errors.WrapWithCode(err, codes.CodeSQLMigration, "cannot create migration lock file %s", lockPath)
+		}
+
+		select {
+		case <-lockCtx.Done():
+			holder, _ := os.ReadFile(lockPath)
+			return nil, errors.NewWithCode(codes.CodeSQLMigration, "timed out waiting for migration lock file %s held by %q; remove it if that runner is gone", lockPath, string(holder))
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	return func() {
+		if err := os.Remove(lockPath); err != nil {
+			m.log.Error(ctx, errors.WrapWithCode(err, codes.CodeSQLMigration, "cannot remove migration lock file %s", lockPath))
+		}
+	}, nil
+}
+
+// lockHolder describes the current process, for the lock file it holds.
+func lockHolder() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("pid %d on %s since %s", os.Getpid(), host, time.Now().UTC().Format(time.RFC3339))
+}
+
+// sqliteFile returns the file backing the main sqlite database, or an empty
+// string for an in-memory database.
+func (m *migrator) sqliteFile(ctx context.Context) (string, error) {
+	rows, err := m.db.Leader().Query(ctx, "migrateDatabaseList", `PRAGMA database_list`)
+	if err != nil {
+		return "", errors.WrapWithCode(err, codes.CodeSQLRead, "cannot read sqlite database list")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			seq        int
+			name, file string
+		)
+		if err := rows.Scan(&seq, &name, &file); err != nil {
+			return "", errors.WrapWithCode(err, codes.CodeSQLRowScan, "cannot scan sqlite database list")
+		}
+		if name == "main" {
+			return file, nil
+		}
+	}
+	return "", rows.Err()
+}
+
+// lockKey maps a lock name onto the bigint key space of pg_advisory_lock.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,431 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/logger"
+	"github.com/downsized-devs/sdk-go/sql"
+)
+
+const (
+	defaultTable       = "schema_migrations"
+	defaultLockName    = "sdk_go_migrate"
+	defaultLockTimeout = time.Minute
+)
+
+// migration files are named <version>_<name>.up.sql and <version>_<name>.down.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Interface interface {
+	// Up applies every pending migration in version order and returns them.
+	Up(ctx context.Context) ([]Migration, error)
+	// Down reverts the n most recently applied migrations and returns them.
+	Down(ctx context.Context, n int) ([]Migration, error)
+	// Status lists every known migration and whether it has been applied.
+	Status(ctx context.Context) ([]Status, error)
+}
+
+type Config struct {
+	// Table records the applied versions. Defaults to "schema_migrations".
+	Table string
+	// Dir is the directory inside the fs.FS holding the migration files.
+	// Defaults to the root.
+	Dir string
+	// LockName identifies the advisory lock that keeps replicas from
+	// migrating concurrently. Defaults to "sdk_go_migrate".
+	LockName string
+	// LockTimeout bounds the wait for the advisory lock. Defaults to 1m.
+	LockTimeout time.Duration
+	// DryRun logs and returns the migrations that would run without
+	// executing them or creating the version table. A missing version table
+	// reads as no migration applied.
+	DryRun bool
+}
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type migrator struct {
+	cfg    Config
+	db     sql.Interface
+	fsys   fs.FS
+	log    logger.Interface
+	driver string
+}
+
+// Init returns a migration runner that reads versioned migration files from
+// fsys, typically an embed.FS, and applies them through db's leader.
+func Init(cfg Config, db sql.Interface, fsys fs.FS, log logger.Interface) Interface {
+	if cfg.Table == "" {
+		cfg.Table = defaultTable
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "."
+	}
+	if cfg.LockName == "" {
+		cfg.LockName = defaultLockName
+	}
+	if cfg.LockTimeout <= 0 {
+		cfg.LockTimeout = defaultLockTimeout
+	}
+
+	return &migrator{
+		cfg:    cfg,
+		db:     db,
+		fsys:   fsys,
+		log:    log,
+		driver: sql.DriverName(db.Leader()),
+	}
+}
+
+func (m *migrator) Up(ctx context.Context) ([]Migration, error) {
+	migrations, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	err = m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, mg := range migrations {
+			if _, ok := applied[mg.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, mg, true); err != nil {
+				return err
+			}
+			pending = append(pending, mg)
+		}
+		return nil
+	})
+
+	return pending, err
+}
+
+func (m *migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	if n <= 0 {
+		return nil, errors.NewWithCode(codes.CodeSQLMigration, "down count must be positive, got %d", n)
+	}
+
+	migrations, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]Migration, len(migrations))
+	for _, mg := range migrations {
+		byVersion[mg.Version] = mg
+	}
+
+	var reverted []Migration
+	err = m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, v := range versions {
+			if len(reverted) == n {
+				break
+			}
+			mg, ok := byVersion[v]
+			if !ok {
+				return errors.NewWithCode(codes.CodeSQLMigration, "applied migration %d has no file", v)
+			}
+			if mg.Down == "" {
+				return errors.NewWithCode(codes.CodeSQLMigration, "migration %d_%s has no down file", mg.Version, mg.Name)
+			}
+			if err := m.apply(ctx, mg, false); err != nil {
+				return err
+			}
+			reverted = append(reverted, mg)
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+func (m *migrator) Status(ctx context.Context) ([]Status, error) {
+	migrations, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Status, 0, len(migrations))
+	for _, mg := range migrations {
+		appliedAt, ok := applied[mg.Version]
+		result = append(result, Status{
+			Version:   mg.Version,
+			Name:      mg.Name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+	return result, nil
+}
+
+// load reads and pairs the migration files, sorted by version.
+func (m *migrator) load() ([]Migration, error) {
+	entries, err := fs.ReadDir(m.fsys, m.cfg.Dir)
+	if err != nil {
+		return nil, errors.WrapWithCode(err, codes.CodeSQLMigration, "cannot read migration dir %s", m.cfg.Dir)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		match := fileNamePattern.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, errors.WrapWithCode(err, codes.CodeSQLMigration, "invalid migration version %s", match[1])
+		}
+		content, err := fs.ReadFile(m.fsys, path.Join(m.cfg.Dir, e.Name()))
+		if err != nil {
+			return nil, errors.WrapWithCode(err, codes.CodeSQLMigration, "cannot read migration %s", e.Name())
+		}
+
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mg
+		} else if mg.Name != match[2] {
+			return nil, errors.NewWithCode(codes.CodeSQLMigration, "migration version %d is used by both %s and %s", version, mg.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			mg.Up = string(content)
+		} else {
+			mg.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if mg.Up == "" {
+			return nil, errors.NewWithCode(codes.CodeSQLMigration, "migration %d_%s has no up file", mg.Version, mg.Name)
+		}
+		migrations = append(migrations, *mg)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func (m *migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.Leader().Exec(ctx, "migrateCreateTable", fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL)`, m.cfg.Table))
+	if err != nil {
+		return errors.WrapWithCode(err, codes.CodeSQLMigration, "cannot create migration table %s", m.cfg.Table)
+	}
+	return nil
+}
+
+// tableExists tells whether the version table was created, without creating
+// it. Unknown drivers are assumed to have it.
+func (m *migrator) tableExists(ctx context.Context) (bool, error) {
+	var query string
+	switch m.driver {
+	case "postgres":
+		query = `SELECT to_regclass($1) IS NOT NULL`
+	case "mysql":
+		query = `SELECT COUNT(*) > 0 FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?`
+	case "sqlite3":
+		query = `SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?`
+	default:
+		return true, nil
+	}
+
+	var exists bool
+	if err := m.db.Leader().Get(ctx, "migrateTableExists", query, &exists, m.cfg.Table); err != nil {
+		return false, errors.WrapWithCode(err, codes.CodeSQLRead, "cannot look up migration table %s", m.cfg.Table)
+	}
+	return exists, nil
+}
+
+// applied returns the applied versions with their apply time. The version
+// table is created first, except on a dry run.
+func (m *migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	if !m.cfg.DryRun {
+		if err := m.ensureTable(ctx); err != nil {
+			return nil, err
+		}
+	} else if exists, err := m.tableExists(ctx); err != nil || !exists {
+		return map[int64]time.Time{}, err
+	}
+
+	rows, err := m.db.Leader().Query(ctx, "migrateApplied", fmt.Sprintf(`SELECT version, applied_at FROM %s`, m.cfg.Table))
+	if err != nil {
+		return nil, errors.WrapWithCode(err, codes.CodeSQLRead, "cannot read migration table %s", m.cfg.Table)
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, errors.WrapWithCode(err, codes.CodeSQLRowScan, "cannot scan migration table %s", m.cfg.Table)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// apply runs one direction of mg and records it, inside a single
+// transaction. MySQL commits DDL implicitly, so a failing MySQL migration may
+// be left partly applied.
+func (m *migrator) apply(ctx context.Context, mg Migration, up bool) error {
+	direction, script := "up", mg.Up
+	if !up {
+		direction, script = "down", mg.Down
+	}
+
+	if m.cfg.DryRun {
+		m.log.Info(ctx, fmt.Sprintf("MIGRATE: [DRY RUN] %s %d_%s", direction, mg.Version, mg.Name))
+		return nil
+	}
+
+	m.log.Info(ctx, fmt.Sprintf("MIGRATE: %s %d_%s", direction, mg.Version, mg.Name))
+	leader := m.db.Leader()
+	err := sql.WithTx(ctx, leader, "migrate", sql.TxOptions{MaxRetries: -1}, func(tx sql.CommandTx) error {
+		for _, stmt := range splitStatements(script) {
+			if _, err := tx.Exec("migrateExec", stmt); err != nil {
+				return err
+			}
+		}
+
+		if up {
+			_, err := tx.Exec("migrateRecord", tx.Rebind(fmt.Sprintf(`INSERT INTO %s (version, name, applied_at) VALUES (?, ?, ?)`, m.cfg.Table)), mg.Version, mg.Name, time.Now().UTC())
+			return err
+		}
+		_, err := tx.Exec("migrateRecord", tx.Rebind(fmt.Sprintf(`DELETE FROM %s WHERE version = ?`, m.cfg.Table)), mg.Version)
+		return err
+	})
+	if err != nil {
+		return errors.WrapWithCode(err, codes.CodeSQLMigration, "migration %s %d_%s failed: %s", direction, mg.Version, mg.Name, err.Error())
+	}
+	return nil
+}
+
+// splitStatements splits a script on semicolons that are outside quotes,
+// comments and postgres dollar-quoted bodies ($$ … $$ or $tag$ … $tag$), so
+// that drivers without multi-statement support can run it. Block comments and
+// dollar-quoted bodies are kept as they are.
+func splitStatements(script string) []string {
+	var (
+		stmts   []string
+		current strings.Builder
+		quote   rune
+	)
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			current.WriteRune(r)
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+			current.WriteRune(r)
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			current.WriteRune('\n')
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := indexRunes(runes, i+2, []rune("*/"))
+			current.WriteString(string(runes[i:end]))
+			i = end - 1
+		case r == '$' && dollarTag(runes, i) != nil:
+			tag := dollarTag(runes, i)
+			end := indexRunes(runes, i+len(tag), tag)
+			current.WriteString(string(runes[i:end]))
+			i = end - 1
+		case r == ';':
+			stmts = appendStatement(stmts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	return appendStatement(stmts, current.String())
+}
+
+// dollarTag returns the dollar quote ($$ or $tag$) starting at runes[i], or
+// nil when there is none, e.g. on a $1 placeholder.
+func dollarTag(runes []rune, i int) []rune {
+	for j := i + 1; j < len(runes); j++ {
+		r := runes[j]
+		switch {
+		case r == '$':
+			return runes[i : j+1]
+		case r == '_' || unicode.IsLetter(r) || (j > i+1 && unicode.IsDigit(r)):
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// indexRunes returns the index right after the first sub in runes from
+// start, or len(runes) when it is not closed.
+func indexRunes(runes []rune, start int, sub []rune) int {
+	for i := start; i+len(sub) <= len(runes); i++ {
+		if string(runes[i:i+len(sub)]) == string(sub) {
+			return i + len(sub)
+		}
+	}
+	return len(runes)
+}
+
+func appendStatement(stmts []string, stmt string) []string {
+	if stmt = strings.TrimSpace(stmt); stmt != "" {
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
@@ -0,0 +1,244 @@
+package migrate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/instrument"
+	"github.com/downsized-devs/sdk-go/sql"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var testMigrations = fstest.MapFS{
+	"migrations/0001_create_user.up.sql":   {Data: []byte("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT NOT NULL);\n-- seed; with a semicolon in a comment\nINSERT INTO user (name) VALUES ('a;b');")},
+	"migrations/0001_create_user.down.sql": {Data: []byte("DROP TABLE user;")},
+	"migrations/0002_add_email.up.sql":     {Data: []byte("ALTER TABLE user ADD COLUMN email TEXT;")},
+	"migrations/0002_add_email.down.sql":   {Data: []byte("ALTER TABLE user DROP COLUMN email;")},
+	"migrations/README.md":                 {Data: []byte("ignored")},
+}
+
+func newMockLogger(t *testing.T) *mock_log.MockInterface {
+	t.Helper()
+	log := mock_log.NewMockInterface(gomock.NewController(t))
+	log.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+	return log
+}
+
+func newTestDB(t *testing.T) (sql.Interface, string) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "migrate.db")
+	db := sql.Init(sql.Config{
+		Driver:                      "sqlite3",
+		Leader:                      sql.ConnConfig{DB: file},
+		FollowerHealthCheckInterval: -1,
+	}, newMockLogger(t), instrument.Init(instrument.Config{}))
+	t.Cleanup(db.Stop)
+	return db, file
+}
+
+func versions(migrations []Migration) []int64 {
+	result := make([]int64, 0, len(migrations))
+	for _, mg := range migrations {
+		result = append(result, mg.Version)
+	}
+	return result
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	db, _ := newTestDB(t)
+	m := Init(Config{Dir: "migrations"}, db, testMigrations, newMockLogger(t))
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, versions(applied))
+
+	var name string
+	require.NoError(t, db.Leader().Get(ctx, "rUser", `SELECT name FROM user`, &name))
+	assert.Equal(t, "a;b", name)
+
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied, "second Up is a no-op")
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, 2)
+	assert.True(t, status[0].Applied && status[1].Applied)
+	assert.False(t, status[1].AppliedAt.IsZero())
+
+	reverted, err := m.Down(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, versions(reverted))
+
+	status, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, status[0].Applied)
+	assert.False(t, status[1].Applied)
+
+	reverted, err = m.Down(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, versions(reverted))
+	assert.Error(t, db.Leader().Get(ctx, "rUser", `SELECT name FROM user`, &name), "table dropped")
+}
+
+func TestMigrator_DryRun(t *testing.T) {
+	ctx := context.Background()
+	db, _ := newTestDB(t)
+	m := Init(Config{Dir: "migrations", DryRun: true}, db, testMigrations, newMockLogger(t))
+
+	planned, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, versions(planned))
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, status[0].Applied || status[1].Applied)
+
+	var tables int
+	require.NoError(t, db.Leader().Get(ctx, "rTables", `SELECT COUNT(*) FROM sqlite_master WHERE name = ?`, &tables, defaultTable))
+	assert.Equal(t, 0, tables, "no DDL on a dry run")
+
+	_, err = Init(Config{Dir: "migrations"}, db, testMigrations, newMockLogger(t)).Up(ctx)
+	require.NoError(t, err)
+	planned, err = m.Down(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, versions(planned))
+
+	status, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, status[0].Applied && status[1].Applied, "nothing reverted")
+}
+
+func TestMigrator_FailedMigrationRollsBack(t *testing.T) {
+	ctx := context.Background()
+	db, _ := newTestDB(t)
+	fsys := fstest.MapFS{
+		"0001_ok.up.sql":     {Data: []byte("CREATE TABLE ok (id INTEGER);")},
+		"0002_broken.up.sql": {Data: []byte("CREATE TABLE broken (id INTEGER); INSERT INTO missing VALUES (1);")},
+	}
+	m := Init(Config{}, db, fsys, newMockLogger(t))
+
+	applied, err := m.Up(ctx)
+	assert.Equal(t, codes.CodeSQLMigration, errors.GetCode(err))
+	assert.Equal(t, []int64{1}, versions(applied))
+
+	var n int
+	assert.Error(t, db.Leader().Get(ctx, "rBroken", `SELECT COUNT(*) FROM broken`, &n), "partial migration rolled back")
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, status[0].Applied)
+	assert.False(t, status[1].Applied)
+}
+
+func TestMigrator_Load(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr bool
+	}{
+		{
+			name:    "missing up file",
+			fsys:    fstest.MapFS{"0001_a.down.sql": {Data: []byte("SELECT 1")}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate version",
+			fsys: fstest.MapFS{
+				"0001_a.up.sql": {Data: []byte("SELECT 1")},
+				"0001_b.up.sql": {Data: []byte("SELECT 1")},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "empty dir",
+			fsys:    fstest.MapFS{},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &migrator{cfg: Config{Dir: "."}, fsys: tt.fsys}
+			_, err := m.load()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMigrator_Down_InvalidCount(t *testing.T) {
+	db, _ := newTestDB(t)
+	_, err := Init(Config{}, db, testMigrations, newMockLogger(t)).Down(context.Background(), 0)
+	assert.Equal(t, codes.CodeSQLMigration, errors.GetCode(err))
+}
+
+func TestMigrator_FileLock(t *testing.T) {
+	db, file := newTestDB(t)
+	m := Init(Config{Dir: "migrations", LockTimeout: 300 * time.Millisecond}, db, testMigrations, newMockLogger(t))
+
+	lockPath := file + "." + defaultLockName + ".lock"
+	require.NoError(t, os.WriteFile(lockPath, nil, 0o600))
+
+	_, err := m.Up(context.Background())
+	assert.Equal(t, codes.CodeSQLMigration, errors.GetCode(err), "held lock times out")
+	assert.ErrorContains(t, err, lockPath)
+
+	require.NoError(t, os.Remove(lockPath))
+	_, err = m.Up(context.Background())
+	assert.NoError(t, err)
+	assert.NoFileExists(t, lockPath, "lock released")
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{name: "empty", script: " \n ", want: nil},
+		{name: "single without semicolon", script: "SELECT 1", want: []string{"SELECT 1"}},
+		{name: "multiple", script: "SELECT 1;\nSELECT 2;", want: []string{"SELECT 1", "SELECT 2"}},
+		{name: "quoted semicolons", script: "INSERT INTO t VALUES ('a;b', \"c;d\", `e;f`);", want: []string{"INSERT INTO t VALUES ('a;b', \"c;d\", `e;f`)"}},
+		{name: "comments", script: "-- drop; it\nSELECT 1; -- trailing;\n", want: []string{"SELECT 1"}},
+		{name: "block comments", script: "/* drop; it */ SELECT 1; SELECT /*+ MAX_EXECUTION_TIME(1); */ 2;", want: []string{"/* drop; it */ SELECT 1", "SELECT /*+ MAX_EXECUTION_TIME(1); */ 2"}},
+		{
+			name: "plpgsql function",
+			script: `CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+	NEW.updated_at := now(); -- keep; it
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER touch BEFORE UPDATE ON t FOR EACH ROW EXECUTE FUNCTION touch();`,
+			want: []string{
+				"CREATE FUNCTION touch() RETURNS trigger AS $$\nBEGIN\n\tNEW.updated_at := now(); -- keep; it\n\tRETURN NEW;\nEND;\n$$ LANGUAGE plpgsql",
+				"CREATE TRIGGER touch BEFORE UPDATE ON t FOR EACH ROW EXECUTE FUNCTION touch()",
+			},
+		},
+		{name: "tagged dollar quote", script: "DO $body$ BEGIN PERFORM '$$;'; END $body$; SELECT 1;", want: []string{"DO $body$ BEGIN PERFORM '$$;'; END $body$", "SELECT 1"}},
+		{name: "placeholders", script: "UPDATE t SET a = $1; SELECT $2;", want: []string{"UPDATE t SET a = $1", "SELECT $2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, splitStatements(tt.script))
+		})
+	}
+}
+
+func TestLockKey(t *testing.T) {
+	assert.Equal(t, lockKey("a"), lockKey("a"))
+	assert.NotEqual(t, lockKey("a"), lockKey("b"))
+}
@@ -31,6 +31,36 @@ type Config struct {
 	Name          string
 	Leader        ConnConfig
 	Follower      ConnConfig
+	// Followers lists the read replicas. When set it takes precedence over
+	// Follower.
+	Followers []ConnConfig
+	// FollowerSelection picks the replica used by each read made through
+	// Follower():
+	// FollowerSelectionRoundRobin (default), FollowerSelectionLeastConnections
+	// or FollowerSelectionRandom.
+	FollowerSelection string
+	// FollowerHealthCheckInterval is how often every replica is pinged. A
+	// failing replica leaves the rotation until a later ping succeeds. Zero
+	// uses the default of 10s, a negative value disables the health check.
+	FollowerHealthCheckInterval time.Duration
+	// ReadYourWrites sends Follower() reads to the leader after a write made
+	// in the same request.
+	ReadYourWrites ReadYourWritesConfig
+	// SlowQueryThreshold logs at warn level, whether or not LogQuery is set,
+	// every query that runs longer than it. Zero disables the slow query log.
+	SlowQueryThreshold time.Duration
+	// SlowQueryExplain attaches the EXPLAIN plan of each slow query to its log
+	// entry. The plan is fetched in the background on a pooled connection.
+	SlowQueryExplain bool
+	// SlowQueryRedactColumns lists the columns, such as password or token,
+	// whose values are masked in slow query logs.
+	SlowQueryRedactColumns []string
+	// ConnectRetry retries the leader connection at start-up with an
+	// exponential backoff.
+	ConnectRetry ConnectRetryConfig
+	// CircuitBreaker fails calls fast with CodeSQLCircuitOpen while a
+	// connection keeps failing.
+	CircuitBreaker CircuitBreakerConfig
 }
 
 type ConnConfig struct {
@@ -60,13 +90,32 @@ type Interface interface {
 type sqlDB struct {
 	endOnce    *sync.Once
 	leader     Command
-	follower   Command
+	followers  *followerPool
+	reader     Command
 	cfg        Config
 	log        logger.Interface
 	instrument instrument.Interface
 }
 
 func Init(cfg Config, log logger.Interface, instr instrument.Interface) Interface {
+	sql := newSQLDB(cfg, log, instr)
+	if err := sql.initDB(); err != nil {
+		sql.log.Fatal(context.Background(), fmt.Sprintf("[FATAL] %s", err))
+	}
+	return sql
+}
+
+// InitWithError works like Init but returns a CodeSQLInit error instead of
+// exiting the process when the database cannot be reached.
+func InitWithError(cfg Config, log logger.Interface, instr instrument.Interface) (Interface, error) {
+	sql := newSQLDB(cfg, log, instr)
+	if err := sql.initDB(); err != nil {
+		return nil, err
+	}
+	return sql, nil
+}
+
+func newSQLDB(cfg Config, log logger.Interface, instr instrument.Interface) *sqlDB {
 	if cfg.Driver == "sqlmock" {
 		cfg.UseInstrument = false
 	}
@@ -74,23 +123,35 @@ func Init(cfg Config, log logger.Interface, instr instrument.Interface) Interfac
 	if cfg.Name == "" {
 		cfg.Name = cfg.Driver
 	}
-	sql := &sqlDB{
+	return &sqlDB{
 		endOnce:    &sync.Once{},
 		log:        log,
 		cfg:        cfg,
 		instrument: instr,
 	}
-
-	sql.initDB()
-	return sql
 }
 
 func (s *sqlDB) Leader() Command {
 	return s.leader
 }
 
+// Follower returns a healthy read replica picked by cfg.FollowerSelection, or
+// the leader when no replica is configured or every replica is down. With
+// cfg.ReadYourWrites enabled or several replicas, the replica is chosen per
+// call instead, and writes go to the leader. With cfg.ReadYourWrites enabled,
+// reads whose context carries a recent leader write go to the leader too.
 func (s *sqlDB) Follower() Command {
-	return s.follower
+	if s.reader != nil {
+		return s.reader
+	}
+	return s.pickFollower()
+}
+
+func (s *sqlDB) pickFollower() Command {
+	if f := s.followers.pick(); f != nil {
+		return f.cmd
+	}
+	return s.leader
 }
 
 func (s *sqlDB) Stop() {
@@ -101,49 +162,122 @@ func (s *sqlDB) Stop() {
 				s.log.Error(ctx, err)
 			}
 		}
-		if s.follower != nil {
-			if err := s.follower.Close(); err != nil {
-				s.log.Error(ctx, err)
-			}
-		}
+		s.followers.stop(ctx)
 	})
 }
 
-func (s *sqlDB) initDB() {
+func (s *sqlDB) initDB() error {
 	ctx := context.Background()
-	db, err := s.connect(true)
+	leaderLabel := s.statsLabel(connTypeLeader, 0, 1)
+	db, err := s.connectLeader(ctx)
 	if err != nil {
-		s.log.Fatal(ctx, fmt.Sprintf("[FATAL] cannot connect to db %s leader: %s on port %d, with error: %s", s.cfg.Leader.DB, s.cfg.Leader.Host, s.cfg.Leader.Port, err))
+		return errors.WrapWithCode(err, codes.CodeSQLInit, "cannot connect to db %s leader: %s on port %d, with error: %s", s.cfg.Leader.DB, s.cfg.Leader.Host, s.cfg.Leader.Port, err)
 	}
 	s.log.Info(ctx, fmt.Sprintf("SQL: [LEADER] driver=%s db=%s @%s:%v ssl=%v", s.cfg.Driver, s.cfg.Leader.DB, s.cfg.Leader.Host, s.cfg.Leader.Port, s.cfg.Leader.SSL))
-	s.leader = initCommand(db, s.cfg.Name, s.instrument, s.log, true, s.cfg.UseInstrument, s.cfg.LogQuery)
+	s.leader = initCommand(db, s.cfg.Name, s.instrument, s.log, true, s.cfg.UseInstrument, s.cfg.LogQuery, initSlowQueryLog(s.cfg, db, s.log, true),
+		initCircuitBreaker(s.cfg.CircuitBreaker, leaderLabel, connTypeLeader, s.log, s.instrument, s.cfg.UseInstrument))
 
-	if s.isFollowerEnabled() {
-		db, err = s.connect(false)
+	followerConfs := s.followerConfigs()
+	followers := make([]*follower, 0, len(followerConfs))
+	for i, conf := range followerConfs {
+		label := s.statsLabel(connTypeFollower, i, len(followerConfs))
+		db, err := s.connect(conf)
+		if db == nil {
+			s.closeOnInitError(ctx, followers)
+			return errors.WrapWithCode(err, codes.CodeSQLInit, "cannot connect to db %s follower: %s on port %d, with error: %s", conf.DB, conf.Host, conf.Port, err)
+		}
+		breaker := initCircuitBreaker(s.cfg.CircuitBreaker, label, connTypeFollower, s.log, s.instrument, s.cfg.UseInstrument)
+		f := &follower{
+			cmd:     initCommand(db, s.cfg.Name, s.instrument, s.log, false, s.cfg.UseInstrument, s.cfg.LogQuery, initSlowQueryLog(s.cfg, db, s.log, false), breaker),
+			db:      db,
+			addr:    fmt.Sprintf("%s:%v", conf.Host, conf.Port),
+			breaker: breaker,
+		}
+		// a replica that is down at start-up joins the rotation once the
+		// health check reaches it
+		f.healthy.Store(err == nil)
 		if err != nil {
-			s.log.Fatal(ctx, fmt.Sprintf("[FATAL] cannot connect to db %s leader: %s on port %d, with error: %s", s.cfg.Leader.DB, s.cfg.Leader.Host, s.cfg.Leader.Port, err))
+			s.log.Warn(ctx, fmt.Sprintf("SQL: [FOLLOWER] db=%s @%s is unhealthy: %s", conf.DB, f.addr, err))
 		}
-		s.log.Info(ctx, fmt.Sprintf("SQL: [FOLLOWER] driver=%s db=%s @%s:%v ssl=%v", s.cfg.Driver, s.cfg.Follower.DB, s.cfg.Follower.Host, s.cfg.Follower.Port, s.cfg.Leader.SSL))
-		s.follower = initCommand(db, s.cfg.Name, s.instrument, s.log, false, s.cfg.UseInstrument, s.cfg.LogQuery)
-	} else {
-		s.follower = s.leader
+		s.log.Info(ctx, fmt.Sprintf("SQL: [FOLLOWER] driver=%s db=%s @%s:%v ssl=%v", s.cfg.Driver, conf.DB, conf.Host, conf.Port, conf.SSL))
+		followers = append(followers, f)
+	}
+
+	// the collectors are registered once every pool is open, so that a
+	// failed init can be retried without registering them twice
+	s.registerDBStats(db, leaderLabel, s.cfg.Leader)
+	for i, f := range followers {
+		s.registerDBStats(f.db, s.statsLabel(connTypeFollower, i, len(followers)), followerConfs[i])
+	}
+
+	s.followers = initFollowerPool(followers, s.cfg.Driver, s.cfg.FollowerSelection, s.cfg.ReadYourWrites.MaxLag, s.log)
+	// a Command kept by the caller must not pin one of several replicas
+	if s.cfg.ReadYourWrites.Enabled || len(followers) > 1 {
+		s.reader = &consistentCommand{s: s}
+	}
+	interval := s.cfg.FollowerHealthCheckInterval
+	if interval == 0 {
+		interval = defaultFollowerHealthCheckInterval
 	}
+	if len(followers) > 0 && interval > 0 {
+		s.followers.startHealthCheck(interval)
+	}
+	return nil
 }
 
-func (s *sqlDB) connect(toLeader bool) (*sqlx.DB, error) {
-	conf := s.cfg.Leader
-	if !toLeader {
-		conf = s.cfg.Follower
+// connectLeader connects to the leader, pinging it up to
+// cfg.ConnectRetry.MaxAttempts times with an exponential backoff.
+func (s *sqlDB) connectLeader(ctx context.Context) (*sqlx.DB, error) {
+	retry := s.cfg.ConnectRetry
+	backoff := retry.InitialBackoff
+	if backoff <= 0 {
+		backoff = defaultConnectRetryInitialBackoff
+	}
+	maxBackoff := retry.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultConnectRetryMaxBackoff
 	}
 
-	if !toLeader {
-		if s.cfg.Leader.MockDB != nil {
-			return sqlx.NewDb(s.cfg.Leader.MockDB, s.cfg.Driver), nil
+	db, err := s.connect(s.cfg.Leader)
+	for attempt := 1; err != nil && db != nil && attempt < retry.MaxAttempts; attempt++ {
+		s.log.Warn(ctx, fmt.Sprintf("SQL: [LEADER] connection attempt %d of %d failed, retrying in %s: %s", attempt, retry.MaxAttempts, backoff, err))
+		time.Sleep(backoff)
+		backoff = min(2*backoff, maxBackoff)
+
+		if err = db.PingContext(ctx); err != nil {
+			err = errors.NewWithCode(codes.CodeSQLInit, "%s", err.Error())
 		}
-	} else {
-		if s.cfg.Follower.MockDB != nil {
-			return sqlx.NewDb(s.cfg.Follower.MockDB, s.cfg.Driver), nil
+	}
+	if err != nil {
+		if db != nil {
+			if closeErr := db.Close(); closeErr != nil {
+				s.log.Error(ctx, closeErr)
+			}
 		}
+		return nil, err
+	}
+	return db, nil
+}
+
+// closeOnInitError closes the pools opened before initDB failed.
+func (s *sqlDB) closeOnInitError(ctx context.Context, followers []*follower) {
+	for _, f := range followers {
+		if err := f.cmd.Close(); err != nil {
+			s.log.Error(ctx, err)
+		}
+	}
+	if err := s.leader.Close(); err != nil {
+		s.log.Error(ctx, err)
+	}
+	s.leader = nil
+}
+
+// connect opens the pool described by conf. A pool that opens but fails its
+// first ping is returned together with the error so that callers can decide
+// whether to keep it.
+func (s *sqlDB) connect(conf ConnConfig) (*sqlx.DB, error) {
+	if conf.MockDB != nil {
+		return sqlx.NewDb(conf.MockDB, s.cfg.Driver), nil
 	}
 
 	uri, err := s.getURI(conf)
@@ -151,31 +285,51 @@ func (s *sqlDB) connect(toLeader bool) (*sqlx.DB, error) {
 		return nil, err
 	}
 
-	db, err := sql.Open(s.cfg.Driver, uri)
+	db, err := sql.Open(openDriverName(s.cfg.Driver), uri)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := db.Ping(); err != nil {
-		return nil, errors.NewWithCode(codes.CodeSQLInit, "%s", err.Error())
-	}
-
 	sqlxDB := sqlx.NewDb(db, s.cfg.Driver)
 	sqlxDB.SetMaxOpenConns(conf.Options.MaxOpen)
 	sqlxDB.SetMaxIdleConns(conf.Options.MaxIdle)
 	sqlxDB.SetConnMaxLifetime(conf.Options.MaxLifeTime)
 
-	if s.cfg.UseInstrument {
-		if toLeader {
-			s.instrument.RegisterDBStats(sqlxDB.DB, fmt.Sprintf("%s_%s", s.cfg.Name, connTypeLeader))
-		} else {
-			s.instrument.RegisterDBStats(sqlxDB.DB, fmt.Sprintf("%s_%s", s.cfg.Name, connTypeFollower))
-		}
+	if err := db.Ping(); err != nil {
+		return sqlxDB, errors.NewWithCode(codes.CodeSQLInit, "%s", err.Error())
 	}
 
 	return sqlxDB, nil
 }
 
+func (s *sqlDB) registerDBStats(db *sqlx.DB, statsLabel string, conf ConnConfig) {
+	if s.cfg.UseInstrument && conf.MockDB == nil {
+		s.instrument.RegisterDBStats(db.DB, statsLabel)
+	}
+}
+
+// statsLabel names a pool for instrument.RegisterDBStats. A single follower
+// keeps the historical "<name>_follower" label; several followers are
+// suffixed with their index.
+func (s *sqlDB) statsLabel(connType string, idx, total int) string {
+	if connType == connTypeFollower && total > 1 {
+		return fmt.Sprintf("%s_%s_%d", s.cfg.Name, connType, idx)
+	}
+	return fmt.Sprintf("%s_%s", s.cfg.Name, connType)
+}
+
+// followerConfigs returns cfg.Followers, or the legacy single Follower when
+// it points somewhere other than the leader.
+func (s *sqlDB) followerConfigs() []ConnConfig {
+	if len(s.cfg.Followers) > 0 {
+		return s.cfg.Followers
+	}
+	if s.isFollowerEnabled() {
+		return []ConnConfig{s.cfg.Follower}
+	}
+	return nil
+}
+
 func (s *sqlDB) isFollowerEnabled() bool {
 	isHostNotEmpty := s.cfg.Follower.Host != ""
 	isHostDifferent := (s.cfg.Follower.Host != s.cfg.Leader.Host && s.cfg.Follower.Port == s.cfg.Leader.Port)
@@ -206,3 +360,23 @@ func (s *sqlDB) getURI(conf ConnConfig) (string, error) {
 		return "", fmt.Errorf(`DB Driver [%s] is not supported`, s.cfg.Driver)
 	}
 }
+
+// openDriverName maps cfg.Driver to the name the driver registered with
+// database/sql. The bundled modernc driver registers as "sqlite", while sqlx
+// only knows the bindvar style of "sqlite3".
+func openDriverName(driver string) string {
+	if driver == "sqlite3" {
+		return "sqlite"
+	}
+	return driver
+}
+
+// DriverName returns the driver behind cmd ("mysql", "postgres" or
+// "sqlite3"), or an empty string when cmd was not created by this package.
+// It accepts both Command and CommandTx values.
+func DriverName(cmd interface{}) string {
+	if d, ok := cmd.(interface{ driverName() string }); ok {
+		return d.driverName()
+	}
+	return ""
+}
@@ -0,0 +1,233 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/instrument"
+	"github.com/downsized-devs/sdk-go/logger"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerOpenTimeout      = 30 * time.Second
+
+	defaultConnectRetryInitialBackoff = time.Second
+	defaultConnectRetryMaxBackoff     = 30 * time.Second
+)
+
+// mysql server errors meaning the server cannot take connections:
+// ER_CON_COUNT_ERROR and ER_SERVER_SHUTDOWN
+var mysqlConnectionNumbers = map[uint16]bool{
+	1040: true,
+	1053: true,
+}
+
+type ConnectRetryConfig struct {
+	// MaxAttempts is how many times the leader is pinged at start-up before
+	// giving up. Zero or one means a single attempt.
+	MaxAttempts int
+	// InitialBackoff is the delay before the second attempt, doubled on each
+	// following one. Defaults to 1s.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts. Defaults to 30s.
+	MaxBackoff time.Duration
+}
+
+type CircuitBreakerConfig struct {
+	// Enabled gives the leader and every follower their own circuit breaker.
+	Enabled bool
+	// FailureThreshold is the number of consecutive connection failures that
+	// opens the breaker. Defaults to 5.
+	FailureThreshold int
+	// OpenTimeout is how long an open breaker fails fast before letting a
+	// single probe call through. Defaults to 30s.
+	OpenTimeout time.Duration
+}
+
+// circuitBreaker fails Command calls fast while a connection keeps failing.
+// Only connection failures count; query errors such as constraint violations
+// or ErrNotFound do not. A nil *circuitBreaker lets every call through.
+type circuitBreaker struct {
+	name          string
+	connType      string
+	threshold     int
+	openTimeout   time.Duration
+	log           logger.Interface
+	instrument    instrument.Interface
+	useInstrument bool
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func initCircuitBreaker(cfg CircuitBreakerConfig, name, connType string, log logger.Interface, instr instrument.Interface, useInstr bool) *circuitBreaker {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	b := &circuitBreaker{
+		name:          name,
+		connType:      connType,
+		threshold:     cfg.FailureThreshold,
+		openTimeout:   cfg.OpenTimeout,
+		log:           log,
+		instrument:    instr,
+		useInstrument: useInstr,
+		state:         instrument.CircuitBreakerClosed,
+	}
+	if b.threshold <= 0 {
+		b.threshold = defaultBreakerFailureThreshold
+	}
+	if b.openTimeout <= 0 {
+		b.openTimeout = defaultBreakerOpenTimeout
+	}
+	return b
+}
+
+// allow returns a CodeSQLCircuitOpen error when the call must fail fast. Once
+// the open timeout has passed, a single call is let through as a probe.
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case instrument.CircuitBreakerOpen:
+		if now().Sub(b.openedAt) < b.openTimeout {
+			return b.openError()
+		}
+		b.transition(instrument.CircuitBreakerHalfOpen)
+		b.probing = true
+		return nil
+	case instrument.CircuitBreakerHalfOpen:
+		if b.probing {
+			return b.openError()
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record feeds the outcome of an allowed call to the breaker.
+func (b *circuitBreaker) record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	failed := isConnectionError(err)
+	switch b.state {
+	case instrument.CircuitBreakerHalfOpen:
+		b.probing = false
+		if failed {
+			b.open()
+		} else {
+			b.failures = 0
+			b.transition(instrument.CircuitBreakerClosed)
+		}
+	case instrument.CircuitBreakerClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.threshold {
+			b.open()
+		}
+	}
+}
+
+// rejecting reports whether allow would fail fast.
+func (b *circuitBreaker) rejecting() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return (b.state == instrument.CircuitBreakerOpen && now().Sub(b.openedAt) < b.openTimeout) ||
+		(b.state == instrument.CircuitBreakerHalfOpen && b.probing)
+}
+
+func (b *circuitBreaker) open() {
+	b.openedAt = now()
+	b.transition(instrument.CircuitBreakerOpen)
+}
+
+func (b *circuitBreaker) openError() error {
+	return errors.NewWithCode(codes.CodeSQLCircuitOpen, "circuit breaker of %s %s is open", b.name, b.connType)
+}
+
+func (b *circuitBreaker) transition(state string) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+
+	ctx := context.Background()
+	msg := fmt.Sprintf("SQL: [CIRCUIT BREAKER] %s %s is %s", b.name, b.connType, state)
+	if state == instrument.CircuitBreakerOpen {
+		b.log.Warn(ctx, fmt.Sprintf("%s after %d connection failures, failing fast for %s", msg, b.failures, b.openTimeout))
+	} else {
+		b.log.Info(ctx, msg)
+	}
+
+	if !b.useInstrument {
+		return
+	}
+	if instr, ok := b.instrument.(instrument.InterfaceV2); ok {
+		instr.DatabaseCircuitBreakerState(b.name, b.connType, state)
+	}
+}
+
+// isConnectionError reports whether err means the database could not be
+// reached, as opposed to a query being rejected. The deadline or the
+// cancellation of the caller's ctx is left out: slow queries under short
+// request timeouts do not make the database unreachable.
+func isConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// connection_exception, and operator_intervention but query_canceled
+		return pqErr.Code.Class() == "08" || (pqErr.Code.Class() == "57" && pqErr.Code != "57014")
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlConnectionNumbers[mysqlErr.Number]
+	}
+
+	return false
+}
@@ -0,0 +1,161 @@
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	goerr "errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/instrument"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	current := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	instr := newMockInstrument(t)
+	transitions := []string{instrument.CircuitBreakerOpen, instrument.CircuitBreakerHalfOpen, instrument.CircuitBreakerOpen, instrument.CircuitBreakerHalfOpen, instrument.CircuitBreakerClosed}
+	for _, state := range transitions {
+		instr.EXPECT().DatabaseCircuitBreakerState("testdb_leader", connTypeLeader, state)
+	}
+	b := initCircuitBreaker(CircuitBreakerConfig{Enabled: true, FailureThreshold: 2, OpenTimeout: time.Minute}, "testdb_leader", connTypeLeader, newMockLogger(t), instr, true)
+
+	// query errors and successes keep the breaker closed
+	b.record(ErrNotFound)
+	b.record(driver.ErrBadConn)
+	b.record(nil)
+	b.record(driver.ErrBadConn)
+	assert.NoError(t, b.allow())
+
+	b.record(driver.ErrBadConn)
+	assert.Equal(t, codes.CodeSQLCircuitOpen, errors.GetCode(b.allow()))
+	assert.True(t, b.rejecting())
+
+	// a single probe goes through once the open timeout has passed
+	current = current.Add(time.Minute)
+	assert.False(t, b.rejecting())
+	assert.NoError(t, b.allow())
+	assert.Equal(t, codes.CodeSQLCircuitOpen, errors.GetCode(b.allow()))
+	b.record(driver.ErrBadConn)
+	assert.Equal(t, codes.CodeSQLCircuitOpen, errors.GetCode(b.allow()))
+
+	current = current.Add(time.Minute)
+	assert.NoError(t, b.allow())
+	b.record(nil)
+	assert.NoError(t, b.allow())
+	assert.False(t, b.rejecting())
+}
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	b := initCircuitBreaker(CircuitBreakerConfig{}, "testdb_leader", connTypeLeader, nil, nil, false)
+	assert.Nil(t, b)
+	b.record(driver.ErrBadConn)
+	assert.NoError(t, b.allow())
+	assert.False(t, b.rejecting())
+}
+
+func TestCircuitBreaker_Command(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	c.breaker = initCircuitBreaker(CircuitBreakerConfig{Enabled: true, FailureThreshold: 1}, "testdb_leader", connTypeLeader, c.log, nil, false)
+
+	_, err := c.Exec(context.Background(), "insertAccount", `INSERT INTO missing (name) VALUES (?)`, "alice")
+	assert.Error(t, err)
+	assert.NoError(t, c.Ping(context.Background()))
+
+	c.breaker.record(driver.ErrBadConn)
+	_, err = c.Exec(context.Background(), "insertAccount", `INSERT INTO account (name) VALUES (?)`, "alice")
+	assert.Equal(t, codes.CodeSQLCircuitOpen, errors.GetCode(err))
+	_, err = Select[testAccount](context.Background(), c, "selectAccount", `SELECT id, name, balance FROM account`)
+	assert.Equal(t, codes.CodeSQLCircuitOpen, errors.GetCode(err))
+	assert.Equal(t, 0, countAccounts(t, &command{db: c.db, log: c.log}))
+}
+
+func Test_isConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil"},
+		{name: "not found", err: ErrNotFound},
+		{name: "query error", err: goerr.New("no such table: missing")},
+		{name: "bad conn", err: driver.ErrBadConn, want: true},
+		{name: "wrapped bad conn", err: fmt.Errorf("exec: %w", driver.ErrBadConn), want: true},
+		{name: "caller deadline", err: context.DeadlineExceeded},
+		{name: "wrapped caller deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded)},
+		{name: "caller cancellation", err: context.Canceled},
+		{name: "net timeout of the caller ctx", err: &net.OpError{Op: "read", Err: context.DeadlineExceeded}},
+		{name: "connection refused", err: fmt.Errorf("dial: %w", syscall.ECONNREFUSED), want: true},
+		{name: "connection reset", err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, want: true},
+		{name: "mysql invalid conn", err: mysql.ErrInvalidConn, want: true},
+		{name: "net", err: &net.OpError{Op: "dial", Err: goerr.New("connection refused")}, want: true},
+		{name: "postgres connection failure", err: &pq.Error{Code: "08006"}, want: true},
+		{name: "postgres admin shutdown", err: &pq.Error{Code: "57P01"}, want: true},
+		{name: "postgres query canceled", err: &pq.Error{Code: "57014"}},
+		{name: "postgres unique violation", err: &pq.Error{Code: "23505"}},
+		{name: "mysql too many connections", err: &mysql.MySQLError{Number: 1040}, want: true},
+		{name: "mysql duplicate entry", err: &mysql.MySQLError{Number: 1062}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isConnectionError(tt.err))
+		})
+	}
+}
+
+func TestInitWithError(t *testing.T) {
+	log := newRecordingLogger(t)
+	db, err := InitWithError(Config{
+		Driver:       "sqlite3",
+		Leader:       ConnConfig{DB: filepath.Join(t.TempDir(), "missing", "init.db")},
+		ConnectRetry: ConnectRetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+	}, log, nil)
+	assert.Nil(t, db)
+	assert.Equal(t, codes.CodeSQLInit, errors.GetCode(err))
+	assert.Len(t, log.messages(), 2)
+
+	db, err = InitWithError(Config{
+		Driver:                      "sqlite3",
+		Leader:                      ConnConfig{DB: filepath.Join(t.TempDir(), "init.db")},
+		FollowerHealthCheckInterval: -1,
+		CircuitBreaker:              CircuitBreakerConfig{Enabled: true},
+	}, newMockLogger(t), nil)
+	require.NoError(t, err)
+	defer db.Stop()
+	assert.NoError(t, db.Leader().Ping(context.Background()))
+}
+
+func TestInitWithError_RetryWithInstrument(t *testing.T) {
+	instr := instrument.Init(instrument.Config{Metrics: instrument.MetricsConfig{Enabled: true}})
+	cfg := Config{
+		Driver:                      "sqlite3",
+		Leader:                      ConnConfig{DB: filepath.Join(t.TempDir(), "missing", "init.db")},
+		UseInstrument:               true,
+		FollowerHealthCheckInterval: -1,
+	}
+
+	// a failed init leaves no DB stats collector behind to register twice
+	for i := 0; i < 2; i++ {
+		db, err := InitWithError(cfg, newMockLogger(t), instr)
+		assert.Nil(t, db)
+		assert.Equal(t, codes.CodeSQLInit, errors.GetCode(err))
+	}
+
+	cfg.Leader.DB = filepath.Join(t.TempDir(), "init.db")
+	db, err := InitWithError(cfg, newMockLogger(t), instr)
+	require.NoError(t, err)
+	defer db.Stop()
+	assert.NoError(t, db.Leader().Ping(context.Background()))
+}
@@ -0,0 +1,327 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+)
+
+// Bind parameters allowed in a single statement by each driver.
+const (
+	postgresMaxPlaceholders = 65535
+	mysqlMaxPlaceholders    = 65535
+	sqliteMaxPlaceholders   = 32766
+)
+
+type BulkOptions struct {
+	// Table receives the rows.
+	Table string
+	// Columns limits the written columns to these `db` tags. Defaults to
+	// every `db` tagged field of the row struct.
+	Columns []string
+	// OmitColumns drops `db` tags from the written columns, such as an
+	// auto-increment id.
+	OmitColumns []string
+	// BatchSize caps the rows sent in one INSERT. Zero, or a value above the
+	// driver placeholder limit, uses as many rows as the limit allows.
+	BatchSize int
+	// ConflictColumns turns the insert into an upsert on this unique key,
+	// using ON CONFLICT on postgres and sqlite. MySQL ignores the list and
+	// uses ON DUPLICATE KEY UPDATE, which matches every unique key.
+	ConflictColumns []string
+	// UpdateColumns are overwritten when a row conflicts. They must be written
+	// columns outside ConflictColumns. Defaults to every written column
+	// outside ConflictColumns; when none is left conflicting rows are skipped.
+	UpdateColumns []string
+}
+
+// BulkInsert writes rows with multi-row INSERTs chunked to the driver
+// placeholder limit, all inside a single transaction started on cmd, and
+// returns the total affected rows. See BulkInsertTx.
+func BulkInsert[T any](ctx context.Context, cmd Command, name string, rows []T, opts BulkOptions) (int64, error) {
+	var affected int64
+	err := WithTx(ctx, cmd, name, TxOptions{}, func(tx CommandTx) error {
+		var err error
+		affected, err = BulkInsertTx(ctx, tx, name, rows, opts)
+		return err
+	})
+	return affected, err
+}
+
+// BulkInsertTx writes rows through tx with multi-row INSERTs chunked to the
+// driver placeholder limit and returns the total affected rows. T is a
+// struct, or a pointer to one, whose `db` tags name the columns.
+//
+// MySQL reports 2 affected rows for each row updated by an upsert and 0 for
+// each row left unchanged.
+func BulkInsertTx[T any](ctx context.Context, tx CommandTx, name string, rows []T, opts BulkOptions) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	w, err := newBulkWriter(reflect.TypeOf(rows).Elem(), DriverName(tx), opts)
+	if err != nil {
+		return 0, err
+	}
+	for i, row := range rows {
+		if !reflect.Indirect(reflect.ValueOf(row)).IsValid() {
+			return 0, errors.NewWithCode(codes.CodeInvalidValue, "cannot insert nil row %d into %s", i, opts.Table)
+		}
+	}
+
+	var affected int64
+	for start := 0; start < len(rows); start += w.batchSize {
+		end := min(start+w.batchSize, len(rows))
+
+		args := make([]interface{}, 0, (end-start)*len(w.fields))
+		for _, row := range rows[start:end] {
+			args = w.appendArgs(args, reflect.ValueOf(row))
+		}
+
+		res, err := execTx(ctx, tx, name, tx.Rebind(w.query(end-start)), args...)
+		if err != nil {
+			return affected, errors.WrapWithCode(err, codes.CodeSQLTxExec, "bulk insert into %s failed at row %d: %s", opts.Table, start, err.Error())
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return affected, errors.WrapWithCode(err, codes.CodeSQLTxExec, "%s", err.Error())
+		}
+		affected += n
+	}
+
+	return affected, nil
+}
+
+// execTx runs query with the per-call context when tx supports it.
+func execTx(ctx context.Context, tx CommandTx, name, query string, args ...interface{}) (sql.Result, error) {
+	if txCtx, ok := tx.(CommandTxContext); ok {
+		return txCtx.ExecContext(ctx, name, query, args...)
+	}
+	return tx.Exec(name, query, args...)
+}
+
+type bulkWriter struct {
+	driver    string
+	opts      BulkOptions
+	columns   []string
+	fields    [][]int
+	batchSize int
+}
+
+func newBulkWriter(rowType reflect.Type, driver string, opts BulkOptions) (*bulkWriter, error) {
+	if opts.Table == "" {
+		return nil, errors.NewWithCode(codes.CodeSQLBuilder, "bulk insert needs a table")
+	}
+	structType := rowType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return nil, errors.NewWithCode(codes.CodeSQLBuilder, "bulk insert rows must be structs, got %s", rowType)
+	}
+
+	tagged := map[string][]int{}
+	var order []string
+	collectDBFields(structType, nil, tagged, &order)
+
+	w := &bulkWriter{driver: driver, opts: opts}
+	picked, err := pickColumns(order, opts.Columns, opts.OmitColumns)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range picked {
+		w.columns = append(w.columns, order[i])
+		w.fields = append(w.fields, tagged[order[i]])
+	}
+	if len(opts.ConflictColumns) == 0 && len(opts.UpdateColumns) > 0 {
+		return nil, errors.NewWithCode(codes.CodeSQLBuilder, "update columns need conflict columns")
+	}
+	if err := checkUpsertColumns(order, w.columns, opts); err != nil {
+		return nil, err
+	}
+
+	w.batchSize, err = batchRows(driver, opts.Table, len(w.columns), opts.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// pickColumns returns the positions in available of the written columns:
+// columns, or every available one when empty, without omit.
+func pickColumns(available, columns, omit []string) ([]int, error) {
+	position := make(map[string]int, len(available))
+	for i, col := range available {
+		position[col] = i
+	}
+	if len(columns) == 0 {
+		columns = available
+	}
+	skip := map[string]bool{}
+	for _, col := range omit {
+		skip[col] = true
+	}
+
+	var picked []int
+	for _, col := range columns {
+		if skip[col] {
+			continue
+		}
+		i, ok := position[col]
+		if !ok {
+			return nil, errors.NewWithCode(codes.CodeSQLBuilder, "unknown column %s", col)
+		}
+		picked = append(picked, i)
+	}
+	if len(picked) == 0 {
+		return nil, errors.NewWithCode(codes.CodeSQLBuilder, "no column to write")
+	}
+	return picked, nil
+}
+
+// checkUpsertColumns rejects conflict columns missing from available, update
+// columns that are not written, and update columns that are also conflict
+// columns.
+func checkUpsertColumns(available, written []string, opts BulkOptions) error {
+	isAvailable := make(map[string]bool, len(available))
+	for _, col := range available {
+		isAvailable[col] = true
+	}
+	conflict := make(map[string]bool, len(opts.ConflictColumns))
+	for _, col := range opts.ConflictColumns {
+		if !isAvailable[col] {
+			return errors.NewWithCode(codes.CodeSQLBuilder, "unknown column %s", col)
+		}
+		conflict[col] = true
+	}
+
+	isWritten := make(map[string]bool, len(written))
+	for _, col := range written {
+		isWritten[col] = true
+	}
+	for _, col := range opts.UpdateColumns {
+		if !isWritten[col] {
+			return errors.NewWithCode(codes.CodeSQLBuilder, "unknown column %s", col)
+		}
+		if conflict[col] {
+			return errors.NewWithCode(codes.CodeSQLBuilder, "column %s is both a conflict and an update column", col)
+		}
+	}
+	return nil
+}
+
+// batchRows returns how many rows of columns fit in one statement under the
+// driver placeholder limit, capped by batchSize when it is positive.
+func batchRows(driver, table string, columns, batchSize int) (int, error) {
+	limit := postgresMaxPlaceholders
+	switch driver {
+	case "mysql":
+		limit = mysqlMaxPlaceholders
+	case "sqlite3":
+		limit = sqliteMaxPlaceholders
+	}
+	rows := limit / columns
+	if batchSize > 0 && batchSize < rows {
+		rows = batchSize
+	}
+	if rows == 0 {
+		return 0, errors.NewWithCode(codes.CodeSQLBuilder, "%s has more than %d columns", table, limit)
+	}
+	return rows, nil
+}
+
+// collectDBFields maps every `db` tag of t, including those of embedded
+// structs, to its field index, keeping the declaration order.
+func collectDBFields(t reflect.Type, parent []int, tagged map[string][]int, order *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+
+		tag, _, _ := strings.Cut(f.Tag.Get("db"), ",")
+		if tag == "-" {
+			continue
+		}
+		if tag == "" {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				collectDBFields(f.Type, index, tagged, order)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if _, ok := tagged[tag]; !ok {
+			*order = append(*order, tag)
+		}
+		tagged[tag] = index
+	}
+}
+
+func (w *bulkWriter) appendArgs(args []interface{}, row reflect.Value) []interface{} {
+	row = reflect.Indirect(row)
+	for _, index := range w.fields {
+		args = append(args, row.FieldByIndex(index).Interface())
+	}
+	return args
+}
+
+// query builds the statement for n rows with `?` bindvars.
+func (w *bulkWriter) query(n int) string {
+	var b strings.Builder
+
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(w.columns)), ", ") + ")"
+	fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES ", w.opts.Table, strings.Join(w.columns, ", "))
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(tuple)
+	}
+
+	if len(w.opts.ConflictColumns) > 0 {
+		b.WriteString(w.conflictClause())
+	}
+	return b.String()
+}
+
+func (w *bulkWriter) conflictClause() string {
+	update := w.opts.UpdateColumns
+	if len(update) == 0 {
+		conflict := map[string]bool{}
+		for _, col := range w.opts.ConflictColumns {
+			conflict[col] = true
+		}
+		for _, col := range w.columns {
+			if !conflict[col] {
+				update = append(update, col)
+			}
+		}
+	}
+
+	set := make([]string, 0, len(update))
+	if w.driver == "mysql" {
+		if len(update) == 0 {
+			// a self assignment turns the duplicate into a no-op
+			return fmt.Sprintf(" ON DUPLICATE KEY UPDATE %s = %s", w.columns[0], w.columns[0])
+		}
+		for _, col := range update {
+			set = append(set, fmt.Sprintf("%s = VALUES(%s)", col, col))
+		}
+		return " ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+	}
+
+	target := strings.Join(w.opts.ConflictColumns, ", ")
+	if len(update) == 0 {
+		return fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", target)
+	}
+	for _, col := range update {
+		set = append(set, fmt.Sprintf("%s = EXCLUDED.%s", col, col))
+	}
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", target, strings.Join(set, ", "))
+}
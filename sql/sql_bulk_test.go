@@ -0,0 +1,215 @@
+package sql
+
+import (
+	"context"
+	goerr "errors"
+	"reflect"
+	"testing"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bulkAudit struct {
+	CreatedBy string `db:"created_by"`
+}
+
+type bulkRow struct {
+	ID      int64  `db:"id"`
+	Name    string `db:"name"`
+	Balance int64  `db:"balance"`
+	Ignored string `db:"-"`
+	bulkAudit
+}
+
+func Test_bulkWriter_query(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  string
+		opts    BulkOptions
+		rows    int
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "insert",
+			driver: "postgres",
+			opts:   BulkOptions{Table: "account"},
+			rows:   2,
+			want:   "INSERT INTO account (id, name, balance, created_by) VALUES (?, ?, ?, ?), (?, ?, ?, ?)",
+		},
+		{
+			name:   "selected columns",
+			driver: "mysql",
+			opts:   BulkOptions{Table: "account", Columns: []string{"name", "id"}},
+			rows:   1,
+			want:   "INSERT INTO account (name, id) VALUES (?, ?)",
+		},
+		{
+			name:   "omitted columns",
+			driver: "mysql",
+			opts:   BulkOptions{Table: "account", OmitColumns: []string{"id", "created_by"}},
+			rows:   1,
+			want:   "INSERT INTO account (name, balance) VALUES (?, ?)",
+		},
+		{
+			name:   "postgres upsert",
+			driver: "postgres",
+			opts:   BulkOptions{Table: "account", OmitColumns: []string{"created_by"}, ConflictColumns: []string{"id"}},
+			rows:   1,
+			want:   "INSERT INTO account (id, name, balance) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, balance = EXCLUDED.balance",
+		},
+		{
+			name:   "sqlite upsert with update columns",
+			driver: "sqlite3",
+			opts:   BulkOptions{Table: "account", Columns: []string{"id", "name", "balance"}, ConflictColumns: []string{"id"}, UpdateColumns: []string{"balance"}},
+			rows:   1,
+			want:   "INSERT INTO account (id, name, balance) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET balance = EXCLUDED.balance",
+		},
+		{
+			name:   "postgres upsert without update columns",
+			driver: "postgres",
+			opts:   BulkOptions{Table: "account", Columns: []string{"id"}, ConflictColumns: []string{"id"}},
+			rows:   1,
+			want:   "INSERT INTO account (id) VALUES (?) ON CONFLICT (id) DO NOTHING",
+		},
+		{
+			name:   "mysql upsert",
+			driver: "mysql",
+			opts:   BulkOptions{Table: "account", Columns: []string{"id", "name"}, ConflictColumns: []string{"id"}},
+			rows:   1,
+			want:   "INSERT INTO account (id, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name)",
+		},
+		{
+			name:   "mysql upsert without update columns",
+			driver: "mysql",
+			opts:   BulkOptions{Table: "account", Columns: []string{"id"}, ConflictColumns: []string{"id"}},
+			rows:   1,
+			want:   "INSERT INTO account (id) VALUES (?) ON DUPLICATE KEY UPDATE id = id",
+		},
+		{
+			name:    "missing table",
+			driver:  "postgres",
+			wantErr: true,
+		},
+		{
+			name:    "unknown column",
+			driver:  "postgres",
+			opts:    BulkOptions{Table: "account", Columns: []string{"email"}},
+			wantErr: true,
+		},
+		{
+			name:    "update columns without conflict columns",
+			driver:  "postgres",
+			opts:    BulkOptions{Table: "account", UpdateColumns: []string{"name"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown conflict column",
+			driver:  "postgres",
+			opts:    BulkOptions{Table: "account", ConflictColumns: []string{"email"}},
+			wantErr: true,
+		},
+		{
+			name:    "update column not written",
+			driver:  "postgres",
+			opts:    BulkOptions{Table: "account", Columns: []string{"id", "name"}, ConflictColumns: []string{"id"}, UpdateColumns: []string{"balance"}},
+			wantErr: true,
+		},
+		{
+			name:    "update column also a conflict column",
+			driver:  "sqlite3",
+			opts:    BulkOptions{Table: "account", ConflictColumns: []string{"id"}, UpdateColumns: []string{"id", "name"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := newBulkWriter(reflect.TypeOf(&bulkRow{}), tt.driver, tt.opts)
+			if tt.wantErr {
+				assert.Equal(t, codes.CodeSQLBuilder, errors.GetCode(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, w.query(tt.rows))
+		})
+	}
+}
+
+func Test_newBulkWriter_batchSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		driver    string
+		batchSize int
+		want      int
+	}{
+		{name: "postgres limit", driver: "postgres", want: 65535 / 4},
+		{name: "mysql limit", driver: "mysql", want: 65535 / 4},
+		{name: "sqlite limit", driver: "sqlite3", want: 32766 / 4},
+		{name: "configured", driver: "postgres", batchSize: 100, want: 100},
+		{name: "configured above limit", driver: "sqlite3", batchSize: 1 << 20, want: 32766 / 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := newBulkWriter(reflect.TypeOf(bulkRow{}), tt.driver, BulkOptions{Table: "account", BatchSize: tt.batchSize})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, w.batchSize)
+		})
+	}
+}
+
+func TestBulkInsert(t *testing.T) {
+	opts := BulkOptions{Table: "account", OmitColumns: []string{"created_by"}, BatchSize: 2}
+	rows := []bulkRow{{ID: 1, Name: "a", Balance: 1}, {ID: 2, Name: "b", Balance: 2}, {ID: 3, Name: "c", Balance: 3}, {ID: 4, Name: "d", Balance: 4}, {ID: 5, Name: "e", Balance: 5}}
+
+	c := newTestSqliteCommand(t)
+	ctx := context.Background()
+
+	affected, err := BulkInsert(ctx, c, "bulkInsertAccount", rows, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), affected)
+	assert.Equal(t, 5, countAccounts(t, c))
+
+	// a conflicting chunk rolls the whole insert back
+	_, err = BulkInsert(ctx, c, "bulkInsertAccount", []*bulkRow{{ID: 6, Name: "f"}, {ID: 7, Name: "g"}, {ID: 1, Name: "a"}}, opts)
+	assert.Equal(t, codes.CodeSQLTxExec, errors.GetCode(err))
+	assert.Equal(t, 5, countAccounts(t, c))
+
+	upsert := opts
+	upsert.ConflictColumns = []string{"id"}
+	upsert.UpdateColumns = []string{"balance"}
+	affected, err = BulkInsert(ctx, c, "bulkUpsertAccount", []bulkRow{{ID: 1, Name: "ignored", Balance: 100}, {ID: 6, Name: "f", Balance: 6}}, upsert)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+	assert.Equal(t, 6, countAccounts(t, c))
+
+	got, err := Get[testAccount](ctx, c, "getAccount", `SELECT id, name, balance FROM account WHERE id = 1`)
+	assert.NoError(t, err)
+	assert.Equal(t, testAccount{ID: 1, Name: "a", Balance: 100}, got)
+
+	affected, err = BulkInsert(ctx, c, "bulkInsertAccount", []bulkRow{}, opts)
+	assert.NoError(t, err)
+	assert.Zero(t, affected)
+
+	// a nil row fails before any batch is written
+	_, err = BulkInsert(ctx, c, "bulkInsertAccount", []*bulkRow{{ID: 7, Name: "g"}, {ID: 8, Name: "h"}, nil}, opts)
+	assert.Equal(t, codes.CodeInvalidValue, errors.GetCode(err))
+	assert.Equal(t, 6, countAccounts(t, c))
+}
+
+func TestBulkInsertTx(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	ctx := context.Background()
+	errAbort := goerr.New("abort")
+
+	err := WithTx(ctx, c, "tx", TxOptions{}, func(tx CommandTx) error {
+		affected, err := BulkInsertTx(ctx, tx, "bulkInsertAccount", []bulkRow{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, BulkOptions{Table: "account", OmitColumns: []string{"created_by"}})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), affected)
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+	assert.Equal(t, 0, countAccounts(t, c))
+}
@@ -0,0 +1,309 @@
+package sql
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/appcontext"
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/logger"
+	"github.com/downsized-devs/sdk-go/redis"
+)
+
+const (
+	defaultCacheKeyPrefix     = "sqlcache"
+	defaultCacheLockTTL       = 5 * time.Second
+	defaultCacheLockRetry     = 50 * time.Millisecond
+	defaultCacheTagVersionTTL = 24 * time.Hour
+)
+
+type QueryCacheConfig struct {
+	// KeyPrefix namespaces every cache key. Defaults to "sqlcache".
+	KeyPrefix string
+	// TTL is how long a result stays cached when CacheOptions.TTL is zero.
+	// Defaults to the redis DefaultTTL.
+	TTL time.Duration
+	// LockTTL bounds how long a single caller holds the fill lock of a key
+	// while it runs the query. Defaults to 5s.
+	LockTTL time.Duration
+	// LockWait is how long other callers wait for that fill before running
+	// the query themselves. Defaults to LockTTL.
+	LockWait time.Duration
+	// LockRetryInterval is how often waiting callers look for the filled
+	// result. Defaults to 50ms.
+	LockRetryInterval time.Duration
+	// TagVersionTTL is how long a tag version is kept. An expired version
+	// only turns the entries of that tag into misses. Defaults to 24h.
+	TagVersionTTL time.Duration
+}
+
+type CacheOptions struct {
+	// TTL overrides QueryCacheConfig.TTL for this query.
+	TTL time.Duration
+	// Tags group cached results so that InvalidateTags drops them together,
+	// e.g. the table names the query reads from.
+	Tags []string
+}
+
+// QueryCache caches query results in redis as JSON, keyed by query name and
+// a hash of the query and its args. Reads made with a no-cache
+// appcontext.CacheControl skip the lookup and refresh the cached value.
+//
+// Writes made through Exec, NamedExec and WithTx invalidate their tags once
+// they succeed. Other writes to a tagged table must be followed by
+// InvalidateTags, or the cached results stay stale until their TTL.
+type QueryCache struct {
+	redis redis.Interface
+	log   logger.Interface
+	cfg   QueryCacheConfig
+}
+
+func NewQueryCache(rds redis.Interface, log logger.Interface, cfg QueryCacheConfig) *QueryCache {
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = defaultCacheKeyPrefix
+	}
+	if cfg.LockTTL <= 0 {
+		cfg.LockTTL = defaultCacheLockTTL
+	}
+	if cfg.LockWait <= 0 {
+		cfg.LockWait = cfg.LockTTL
+	}
+	if cfg.LockRetryInterval <= 0 {
+		cfg.LockRetryInterval = defaultCacheLockRetry
+	}
+	if cfg.TagVersionTTL <= 0 {
+		cfg.TagVersionTTL = defaultCacheTagVersionTTL
+	}
+	return &QueryCache{redis: rds, log: log, cfg: cfg}
+}
+
+// Get is the cached counterpart of Command.Get: dest is filled from the
+// cache when possible, and from cmd otherwise. ErrNotFound is not cached.
+func (c *QueryCache) Get(ctx context.Context, cmd Command, name string, query string, dest interface{}, opts CacheOptions, args ...interface{}) error {
+	return c.fetch(ctx, name, query, opts, args, dest, func() error {
+		return cmd.Get(ctx, name, query, dest, args...)
+	})
+}
+
+// CachedGet is the cached counterpart of Get.
+func CachedGet[T any](ctx context.Context, c *QueryCache, q Queryer, name string, query string, opts CacheOptions, args ...interface{}) (T, error) {
+	var result T
+	err := c.fetch(ctx, name, query, opts, args, &result, func() error {
+		var err error
+		result, err = Get[T](ctx, q, name, query, args...)
+		return err
+	})
+	return result, err
+}
+
+// CachedSelect is the cached counterpart of Select.
+func CachedSelect[T any](ctx context.Context, c *QueryCache, q Queryer, name string, query string, opts CacheOptions, args ...interface{}) ([]T, error) {
+	var result []T
+	err := c.fetch(ctx, name, query, opts, args, &result, func() error {
+		var err error
+		result, err = Select[T](ctx, q, name, query, args...)
+		return err
+	})
+	return result, err
+}
+
+// Exec runs query on cmd and, once it succeeds, invalidates tags. An error
+// invalidating the tags is returned with the result of the write, which went
+// through.
+func (c *QueryCache) Exec(ctx context.Context, cmd Command, name string, query string, tags []string, args ...interface{}) (sql.Result, error) {
+	res, err := cmd.Exec(ctx, name, query, args...)
+	if err != nil {
+		return res, err
+	}
+	return res, c.InvalidateTags(ctx, tags...)
+}
+
+// NamedExec is the NamedExec counterpart of Exec.
+func (c *QueryCache) NamedExec(ctx context.Context, cmd Command, name string, query string, tags []string, arg interface{}) (sql.Result, error) {
+	res, err := cmd.NamedExec(ctx, name, query, arg)
+	if err != nil {
+		return res, err
+	}
+	return res, c.InvalidateTags(ctx, tags...)
+}
+
+// WithTx runs fn in a transaction as the package WithTx does and, once it
+// has committed, invalidates tags. Nothing is invalidated when it rolls back.
+func (c *QueryCache) WithTx(ctx context.Context, cmd Command, name string, opts TxOptions, tags []string, fn func(tx CommandTx) error) error {
+	if err := WithTx(ctx, cmd, name, opts, fn); err != nil {
+		return err
+	}
+	return c.InvalidateTags(ctx, tags...)
+}
+
+// InvalidateTags drops every result cached with one of tags. Call it after
+// writes made outside Exec, NamedExec and WithTx, once the transaction has
+// committed.
+func (c *QueryCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		if err := c.redis.SetEX(ctx, c.tagKey(tag), newTagVersion(), c.cfg.TagVersionTTL); err != nil {
+			return errors.WrapWithCode(err, codes.CodeRedisSetex, "cannot invalidate cache tag %s: %s", tag, err.Error())
+		}
+	}
+	return nil
+}
+
+// fetch fills dest, a pointer, from the cache or through load. Redis errors
+// are logged and fall back to load so that the cache never fails a read.
+func (c *QueryCache) fetch(ctx context.Context, name, query string, opts CacheOptions, args []interface{}, dest interface{}, load func() error) error {
+	key, err := c.key(ctx, name, query, opts.Tags, args)
+	if err != nil {
+		c.log.Warn(ctx, fmt.Sprintf("SQL: [CACHE] %s bypassed: %s", name, err))
+		return load()
+	}
+
+	if appcontext.GetCacheControl(ctx) {
+		return c.loadAndStore(ctx, key, opts, dest, load)
+	}
+
+	if c.read(ctx, key, dest) {
+		return nil
+	}
+
+	// only one caller runs the query; the others wait for its result
+	lock, err := c.redis.Lock(ctx, key+":lock", c.cfg.LockTTL)
+	switch {
+	case err == nil:
+		defer func() {
+			if err := c.redis.LockRelease(ctx, lock); err != nil {
+				c.log.Warn(ctx, fmt.Sprintf("SQL: [CACHE] cannot release lock of %s: %s", key, err))
+			}
+		}()
+		// the previous holder may have filled it meanwhile
+		if c.read(ctx, key, dest) {
+			return nil
+		}
+		return c.loadAndStore(ctx, key, opts, dest, load)
+	case errors.Is(err, redis.ErrNotObtained):
+		if hit, err := c.wait(ctx, key, dest); err != nil || hit {
+			return err
+		}
+		return load()
+	default:
+		c.log.Warn(ctx, fmt.Sprintf("SQL: [CACHE] cannot lock %s: %s", key, err))
+		return load()
+	}
+}
+
+func (c *QueryCache) loadAndStore(ctx context.Context, key string, opts CacheOptions, dest interface{}, load func() error) error {
+	if err := load(); err != nil {
+		return err
+	}
+
+	val, err := json.Marshal(dest)
+	if err != nil {
+		c.log.Warn(ctx, fmt.Sprintf("SQL: [CACHE] cannot encode %s: %s", key, err))
+		return nil
+	}
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = c.cfg.TTL
+	}
+	if err := c.redis.SetEX(ctx, key, string(val), ttl); err != nil {
+		c.log.Warn(ctx, fmt.Sprintf("SQL: [CACHE] cannot store %s: %s", key, err))
+	}
+	return nil
+}
+
+// read reports whether key was cached, decoding it into dest when it was.
+func (c *QueryCache) read(ctx context.Context, key string, dest interface{}) bool {
+	val, err := c.redis.Get(ctx, key)
+	if errors.Is(err, redis.Nil) {
+		return false
+	} else if err != nil {
+		c.log.Warn(ctx, fmt.Sprintf("SQL: [CACHE] cannot read %s: %s", key, err))
+		return false
+	}
+	if err := json.Unmarshal([]byte(val), dest); err != nil {
+		c.log.Warn(ctx, fmt.Sprintf("SQL: [CACHE] cannot decode %s: %s", key, err))
+		return false
+	}
+	return true
+}
+
+// wait polls key until the lock holder fills it or cfg.LockWait runs out.
+func (c *QueryCache) wait(ctx context.Context, key string, dest interface{}) (bool, error) {
+	ticker := time.NewTicker(c.cfg.LockRetryInterval)
+	defer ticker.Stop()
+	timeout := time.NewTimer(c.cfg.LockWait)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-timeout.C:
+			return false, nil
+		case <-ticker.C:
+			if c.read(ctx, key, dest) {
+				return true, nil
+			}
+		}
+	}
+}
+
+// key returns <prefix>:<name>:<hash>, where the hash covers the query, its
+// args and the current version of every tag.
+func (c *QueryCache) key(ctx context.Context, name, query string, tags []string, args []interface{}) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(query))
+	for _, arg := range args {
+		fmt.Fprintf(h, "\x00%T\x00%s", arg, formatArg(arg))
+	}
+	for _, tag := range tags {
+		version, err := c.tagVersion(ctx, tag)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "\x00%s=%s", tag, version)
+	}
+	return fmt.Sprintf("%s:%s:%s", c.cfg.KeyPrefix, name, hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// tagVersion returns the current version of tag, starting a new one when
+// none is stored. The first version is set with SET NX and read back, so that
+// a reader racing with InvalidateTags cannot overwrite the bumped version.
+// Only a redis that is not a DataStructureInterface falls back to SETEX.
+func (c *QueryCache) tagVersion(ctx context.Context, tag string) (string, error) {
+	key := c.tagKey(tag)
+	version, err := c.redis.Get(ctx, key)
+	if err == nil {
+		return version, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		return "", err
+	}
+
+	ds, ok := c.redis.(redis.DataStructureInterface)
+	if !ok {
+		version = newTagVersion()
+		if err := c.redis.SetEX(ctx, key, version, c.cfg.TagVersionTTL); err != nil {
+			return "", err
+		}
+		return version, nil
+	}
+	if _, err := ds.SetNX(ctx, key, newTagVersion(), c.cfg.TagVersionTTL); err != nil {
+		return "", err
+	}
+	return c.redis.Get(ctx, key)
+}
+
+func (c *QueryCache) tagKey(tag string) string {
+	return fmt.Sprintf("%s:tag:%s", c.cfg.KeyPrefix, tag)
+}
+
+func newTagVersion() string {
+	return strconv.FormatInt(now().UnixNano(), 36)
+}
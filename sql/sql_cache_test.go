@@ -0,0 +1,326 @@
+package sql
+
+import (
+	"context"
+	goerr "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bsm/redislock"
+	"github.com/downsized-devs/sdk-go/appcontext"
+	"github.com/downsized-devs/sdk-go/header"
+	"github.com/downsized-devs/sdk-go/redis"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis keeps values and locks in memory, ignoring TTLs.
+type fakeRedis struct {
+	redis.DataStructureInterface
+	mu     sync.Mutex
+	values map[string]string
+	locked map[string]bool
+	getErr error
+	// onMiss runs on every Get of a missing key, before it returns.
+	onMiss func(key string)
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{values: map[string]string{}, locked: map[string]bool{}}
+}
+
+func (r *fakeRedis) Get(_ context.Context, key string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.getErr != nil {
+		return "", r.getErr
+	}
+	val, ok := r.values[key]
+	if !ok {
+		if r.onMiss != nil {
+			r.onMiss(key)
+		}
+		return "", redis.Nil
+	}
+	return val, nil
+}
+
+func (r *fakeRedis) SetNX(_ context.Context, key string, val string, _ time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.values[key]; ok {
+		return false, nil
+	}
+	r.values[key] = val
+	return true, nil
+}
+
+func (r *fakeRedis) SetEX(_ context.Context, key string, val string, _ time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[key] = val
+	return nil
+}
+
+func (r *fakeRedis) Lock(_ context.Context, key string, _ time.Duration) (*redislock.Lock, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.locked[key] {
+		return nil, redis.ErrNotObtained
+	}
+	r.locked[key] = true
+	return &redislock.Lock{}, nil
+}
+
+func (r *fakeRedis) LockRelease(_ context.Context, _ *redislock.Lock) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.locked)
+	return nil
+}
+
+// countingQueryer counts the queries that reach the database.
+type countingQueryer struct {
+	Queryer
+	calls int
+}
+
+func (q *countingQueryer) Query(ctx context.Context, name string, query string, args ...interface{}) (*sqlx.Rows, error) {
+	q.calls++
+	return q.Queryer.Query(ctx, name, query, args...)
+}
+
+func TestCachedGet(t *testing.T) {
+	ctx := context.Background()
+	c := newTestSqliteCommand(t)
+	seedAccounts(t, c, "alice", "bob")
+	q := &countingQueryer{Queryer: c}
+	cache := NewQueryCache(newFakeRedis(), newMockLogger(t), QueryCacheConfig{})
+	query := `SELECT id, name, balance FROM account WHERE id = ?`
+
+	got, err := CachedGet[testAccount](ctx, cache, q, "getAccount", query, CacheOptions{Tags: []string{"account"}}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, testAccount{ID: 1, Name: "alice", Balance: 100}, got)
+
+	_, err = c.Exec(ctx, "updateAccount", `UPDATE account SET balance = 0 WHERE id = 1`)
+	require.NoError(t, err)
+
+	// served from the cache, other args miss it
+	got, err = CachedGet[testAccount](ctx, cache, q, "getAccount", query, CacheOptions{Tags: []string{"account"}}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), got.Balance)
+	assert.Equal(t, 1, q.calls)
+	got, err = CachedGet[testAccount](ctx, cache, q, "getAccount", query, CacheOptions{Tags: []string{"account"}}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", got.Name)
+	assert.Equal(t, 2, q.calls)
+
+	// no-cache skips the lookup and refreshes the cached value
+	noCache := appcontext.SetCacheControl(ctx, header.CacheControlNoCache)
+	got, err = CachedGet[testAccount](noCache, cache, q, "getAccount", query, CacheOptions{Tags: []string{"account"}}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), got.Balance)
+	_, err = CachedGet[testAccount](ctx, cache, q, "getAccount", query, CacheOptions{Tags: []string{"account"}}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, q.calls)
+
+	// invalidating the tag drops every entry cached with it
+	_, err = c.Exec(ctx, "updateAccount", `UPDATE account SET balance = 5 WHERE id = 1`)
+	require.NoError(t, err)
+	require.NoError(t, cache.InvalidateTags(ctx, "account"))
+	got, err = CachedGet[testAccount](ctx, cache, q, "getAccount", query, CacheOptions{Tags: []string{"account"}}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), got.Balance)
+	assert.Equal(t, 4, q.calls)
+
+	// misses are not cached
+	for i := 0; i < 2; i++ {
+		_, err = CachedGet[testAccount](ctx, cache, q, "getAccount", query, CacheOptions{}, 3)
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assert.Equal(t, 6, q.calls)
+}
+
+func TestCachedSelect(t *testing.T) {
+	ctx := context.Background()
+	c := newTestSqliteCommand(t)
+	seedAccounts(t, c, "alice", "bob")
+	q := &countingQueryer{Queryer: c}
+	cache := NewQueryCache(newFakeRedis(), newMockLogger(t), QueryCacheConfig{KeyPrefix: "test"})
+
+	for i := 0; i < 2; i++ {
+		got, err := CachedSelect[string](ctx, cache, q, "selectNames", `SELECT name FROM account ORDER BY id`, CacheOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice", "bob"}, got)
+	}
+	assert.Equal(t, 1, q.calls)
+}
+
+func TestQueryCache_Get(t *testing.T) {
+	ctx := context.Background()
+	c := newTestSqliteCommand(t)
+	seedAccounts(t, c, "alice")
+	rds := newFakeRedis()
+	cache := NewQueryCache(rds, newMockLogger(t), QueryCacheConfig{})
+
+	var got testAccount
+	require.NoError(t, cache.Get(ctx, c, "getAccount", `SELECT id, name, balance FROM account WHERE id = ?`, &got, CacheOptions{}, 1))
+	assert.Equal(t, "alice", got.Name)
+
+	_, err := c.Exec(ctx, "deleteAccount", `DELETE FROM account`)
+	require.NoError(t, err)
+	got = testAccount{}
+	require.NoError(t, cache.Get(ctx, c, "getAccount", `SELECT id, name, balance FROM account WHERE id = ?`, &got, CacheOptions{}, 1))
+	assert.Equal(t, "alice", got.Name)
+
+	// a failing redis falls back to the database
+	rds.getErr = goerr.New("connection refused")
+	err = cache.Get(ctx, c, "getAccount", `SELECT id, name, balance FROM account WHERE id = ?`, &got, CacheOptions{}, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestQueryCache_TaggedWrites(t *testing.T) {
+	ctx := context.Background()
+	c := newTestSqliteCommand(t)
+	seedAccounts(t, c, "alice")
+	q := &countingQueryer{Queryer: c}
+	cache := NewQueryCache(newFakeRedis(), newMockLogger(t), QueryCacheConfig{})
+	query := `SELECT id, name, balance FROM account WHERE id = ?`
+	opts := CacheOptions{Tags: []string{"account"}}
+	errAbort := goerr.New("abort")
+
+	balance := func() int64 {
+		t.Helper()
+		got, err := CachedGet[testAccount](ctx, cache, q, "getAccount", query, opts, 1)
+		require.NoError(t, err)
+		return got.Balance
+	}
+	assert.Equal(t, int64(100), balance())
+
+	tests := []struct {
+		name      string
+		write     func() error
+		want      int64
+		wantCalls int
+	}{
+		{
+			name: "exec",
+			write: func() error {
+				_, err := cache.Exec(ctx, c, "updateAccount", `UPDATE account SET balance = 1 WHERE id = 1`, []string{"account"})
+				return err
+			},
+			want:      1,
+			wantCalls: 2,
+		},
+		{
+			name: "named exec",
+			write: func() error {
+				_, err := cache.NamedExec(ctx, c, "updateAccount", `UPDATE account SET balance = :balance WHERE id = 1`, []string{"account"}, map[string]interface{}{"balance": 2})
+				return err
+			},
+			want:      2,
+			wantCalls: 3,
+		},
+		{
+			name: "committed transaction",
+			write: func() error {
+				return cache.WithTx(ctx, c, "txUpdateAccount", TxOptions{}, []string{"account"}, func(tx CommandTx) error {
+					_, err := tx.Exec("updateAccount", `UPDATE account SET balance = 3 WHERE id = 1`)
+					return err
+				})
+			},
+			want:      3,
+			wantCalls: 4,
+		},
+		{
+			name: "rolled back transaction",
+			write: func() error {
+				err := cache.WithTx(ctx, c, "txUpdateAccount", TxOptions{}, []string{"account"}, func(tx CommandTx) error {
+					_, err := tx.Exec("updateAccount", `UPDATE account SET balance = 4 WHERE id = 1`)
+					require.NoError(t, err)
+					return errAbort
+				})
+				if goerr.Is(err, errAbort) {
+					return nil
+				}
+				return err
+			},
+			want:      3,
+			wantCalls: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.write())
+			assert.Equal(t, tt.want, balance())
+			assert.Equal(t, tt.wantCalls, q.calls)
+		})
+	}
+}
+
+func TestQueryCache_TagVersion(t *testing.T) {
+	ctx := context.Background()
+	rds := newFakeRedis()
+	cache := NewQueryCache(rds, newMockLogger(t), QueryCacheConfig{})
+	key := cache.tagKey("account")
+
+	// the tag is invalidated between the first read and its version being set
+	rds.onMiss = func(missed string) {
+		if missed == key {
+			rds.values[key] = "bumped"
+		}
+	}
+
+	version, err := cache.tagVersion(ctx, "account")
+	require.NoError(t, err)
+	assert.Equal(t, "bumped", version)
+	assert.Equal(t, "bumped", rds.values[key])
+
+	rds.onMiss = nil
+	version, err = cache.tagVersion(ctx, "other")
+	require.NoError(t, err)
+	assert.Equal(t, rds.values[cache.tagKey("other")], version)
+}
+
+func TestQueryCache_Stampede(t *testing.T) {
+	ctx := context.Background()
+	c := newTestSqliteCommand(t)
+	seedAccounts(t, c, "alice")
+	query := `SELECT name FROM account WHERE id = ?`
+
+	t.Run("waits for the lock holder", func(t *testing.T) {
+		rds := newFakeRedis()
+		q := &countingQueryer{Queryer: c}
+		cache := NewQueryCache(rds, newMockLogger(t), QueryCacheConfig{LockWait: time.Second, LockRetryInterval: time.Millisecond})
+		key, err := cache.key(ctx, "getName", query, nil, []interface{}{1})
+		require.NoError(t, err)
+		rds.locked[key+":lock"] = true
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			_ = rds.SetEX(ctx, key, `"filled"`, 0)
+		}()
+		got, err := CachedGet[string](ctx, cache, q, "getName", query, CacheOptions{}, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "filled", got)
+		assert.Zero(t, q.calls)
+	})
+
+	t.Run("queries after the wait", func(t *testing.T) {
+		rds := newFakeRedis()
+		q := &countingQueryer{Queryer: c}
+		cache := NewQueryCache(rds, newMockLogger(t), QueryCacheConfig{LockWait: 5 * time.Millisecond, LockRetryInterval: time.Millisecond})
+		key, err := cache.key(ctx, "getName", query, nil, []interface{}{1})
+		require.NoError(t, err)
+		rds.locked[key+":lock"] = true
+
+		got, err := CachedGet[string](ctx, cache, q, "getName", query, CacheOptions{}, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", got)
+		assert.Equal(t, 1, q.calls)
+		_, cached := rds.values[key]
+		assert.False(t, cached)
+	})
+}
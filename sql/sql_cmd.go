@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/downsized-devs/sdk-go/instrument"
 	"github.com/downsized-devs/sdk-go/logger"
@@ -31,6 +32,13 @@ type Command interface {
 type TxOptions struct {
 	Isolation sql.IsolationLevel
 	ReadOnly  bool
+	// MaxRetries caps how many times WithTx re-runs a transaction that failed
+	// with a retryable driver error. Zero uses the default of 3, a negative
+	// value disables retries. BeginTx ignores it.
+	MaxRetries int
+	// RetryBackoff is the delay before the first WithTx retry, doubled on each
+	// following attempt. Zero uses the default of 50ms. BeginTx ignores it.
+	RetryBackoff time.Duration
 }
 
 type command struct {
@@ -41,9 +49,11 @@ type command struct {
 	instrument    instrument.Interface
 	useInstrument bool
 	logQuery      bool
+	slowLog       *slowQueryLog
+	breaker       *circuitBreaker
 }
 
-func initCommand(db *sqlx.DB, connName string, instr instrument.Interface, log logger.Interface, isLeader, useInstr, logQuery bool) Command {
+func initCommand(db *sqlx.DB, connName string, instr instrument.Interface, log logger.Interface, isLeader, useInstr, logQuery bool, slowLog *slowQueryLog, breaker *circuitBreaker) Command {
 	c := &command{
 		db:            db,
 		connName:      connName,
@@ -52,6 +62,8 @@ func initCommand(db *sqlx.DB, connName string, instr instrument.Interface, log l
 		instrument:    instr,
 		useInstrument: useInstr,
 		logQuery:      logQuery,
+		slowLog:       slowLog,
+		breaker:       breaker,
 	}
 
 	if !isLeader {
@@ -61,12 +73,22 @@ func initCommand(db *sqlx.DB, connName string, instr instrument.Interface, log l
 	return c
 }
 
+func (c *command) driverName() string {
+	return c.db.DriverName()
+}
+
 func (c *command) Close() error {
+	c.slowLog.wait()
 	return c.db.Close()
 }
 
 func (c *command) Ping(ctx context.Context) error {
-	return c.db.PingContext(ctx)
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+	err := c.db.PingContext(ctx)
+	c.breaker.record(err)
+	return err
 }
 
 func (c *command) In(query string, args ...interface{}) (string, []interface{}, error) {
@@ -94,74 +116,118 @@ func (c *command) QueryIn(ctx context.Context, name string, query string, args .
 
 // QueryRow should be avoided as it cannot be mocked using ExpectQuery
 func (c *command) QueryRow(ctx context.Context, name string, query string, args ...interface{}) (*sqlx.Row, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
 	if c.useInstrument {
 		timer := c.instrument.DatabaseQueryTimer(c.connName, c.connType, name)
 		defer timer.ObserveDuration()
 	}
+	defer c.slowLog.observe(ctx, name, query, time.Now(), args...)
 	if c.logQuery {
 		c.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args...)))
 	}
 	row := c.db.QueryRowxContext(ctx, query, args...)
+	c.breaker.record(row.Err())
 	return row, row.Err()
 }
 
 func (c *command) Query(ctx context.Context, name string, query string, args ...interface{}) (*sqlx.Rows, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
 	if c.useInstrument {
 		timer := c.instrument.DatabaseQueryTimer(c.connName, c.connType, name)
 		defer timer.ObserveDuration()
 	}
+	defer c.slowLog.observe(ctx, name, query, time.Now(), args...)
 	if c.logQuery {
 		c.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args...)))
 	}
-	return c.db.QueryxContext(ctx, query, args...)
+	rows, err := c.db.QueryxContext(ctx, query, args...)
+	c.breaker.record(err)
+	return rows, err
 }
 
 func (c *command) NamedQuery(ctx context.Context, name string, query string, arg interface{}) (*sqlx.Rows, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
 	if c.useInstrument {
 		timer := c.instrument.DatabaseQueryTimer(c.connName, c.connType, name)
 		defer timer.ObserveDuration()
 	}
+	defer c.slowLog.observe(ctx, name, query, time.Now(), arg)
 	if c.logQuery {
-		c.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query)))
+		c.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, arg)))
 	}
-	return c.db.NamedQueryContext(ctx, query, arg)
+	rows, err := c.db.NamedQueryContext(ctx, query, arg)
+	c.breaker.record(err)
+	return rows, err
 }
 
 func (c *command) Prepare(ctx context.Context, name string, query string) (CommandStmt, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
 	if c.useInstrument {
 		timer := c.instrument.DatabaseQueryTimer(c.connName, c.connType, name)
 		defer timer.ObserveDuration()
 	}
 	stmt, err := c.db.PreparexContext(ctx, query)
+	c.breaker.record(err)
 	if err != nil {
 		return nil, err
 	}
-	return initStmt(ctx, name, c.connName, stmt, c.instrument, c.connType == connTypeLeader, c.useInstrument), nil
+	x := initStmt(ctx, name, c.connName, query, stmt, c.log, c.instrument, c.connType == connTypeLeader, c.useInstrument, c.logQuery, c.slowLog)
+	x.markWrites = c.connType == connTypeLeader
+	return x, nil
 }
 
 func (c *command) NamedExec(ctx context.Context, name string, query string, args interface{}) (sql.Result, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
 	if c.useInstrument {
 		timer := c.instrument.DatabaseQueryTimer(c.connName, c.connType, name)
 		defer timer.ObserveDuration()
 	}
+	defer c.slowLog.observe(ctx, name, query, time.Now(), args)
 	if c.logQuery {
-		c.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query)))
+		c.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args)))
 	}
-	return c.db.NamedExecContext(ctx, query, args)
+	res, err := c.db.NamedExecContext(ctx, query, args)
+	c.breaker.record(err)
+	if err == nil && c.connType == connTypeLeader {
+		markLeaderWrite(ctx)
+	}
+	return res, err
 }
 
 func (c *command) Exec(ctx context.Context, name string, query string, args ...interface{}) (sql.Result, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
 	if c.useInstrument {
 		timer := c.instrument.DatabaseQueryTimer(c.connName, c.connType, name)
 		defer timer.ObserveDuration()
 	}
+	defer c.slowLog.observe(ctx, name, query, time.Now(), args...)
 	if c.logQuery {
 		c.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args...)))
 	}
-	return c.db.ExecContext(ctx, query, args...)
+	res, err := c.db.ExecContext(ctx, query, args...)
+	c.breaker.record(err)
+	if err == nil && c.connType == connTypeLeader {
+		markLeaderWrite(ctx)
+	}
+	return res, err
 }
 
 func (c *command) BeginTx(ctx context.Context, name string, opt TxOptions) (CommandTx, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
 	if c.useInstrument {
 		timer := c.instrument.DatabaseQueryTimer(c.connName, c.connType, name)
 		defer timer.ObserveDuration()
@@ -171,19 +237,26 @@ func (c *command) BeginTx(ctx context.Context, name string, opt TxOptions) (Comm
 		ReadOnly:  opt.ReadOnly,
 	}
 	tx, err := c.db.BeginTxx(ctx, opts)
+	c.breaker.record(err)
 	if err != nil {
 		return nil, err
 	}
-	return initTx(ctx, name, c.connName, tx, opts, c.log, c.instrument, c.connType == connTypeLeader, c.useInstrument, c.logQuery), nil
+	return initTx(ctx, name, c.connName, tx, opts, c.log, c.instrument, c.connType == connTypeLeader, c.useInstrument, c.logQuery, c.slowLog), nil
 }
 
 func (c *command) Get(ctx context.Context, name string, query string, dest interface{}, args ...interface{}) error {
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
 	if c.useInstrument {
 		timer := c.instrument.DatabaseQueryTimer(c.connName, c.connType, name)
 		defer timer.ObserveDuration()
 	}
+	defer c.slowLog.observe(ctx, name, query, time.Now(), args...)
 	if c.logQuery {
 		c.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args...)))
 	}
-	return c.db.GetContext(ctx, dest, query, args...)
+	err := c.db.GetContext(ctx, dest, query, args...)
+	c.breaker.record(err)
+	return err
 }
@@ -0,0 +1,236 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/jmoiron/sqlx"
+)
+
+var now = time.Now
+
+type ReadYourWritesConfig struct {
+	// Enabled routes Follower() reads to the leader after a leader write was
+	// recorded on the same context. See WithWriteTracking.
+	Enabled bool
+	// Window bounds how long after a write the reads stay on the leader. Zero
+	// keeps them on the leader for the rest of the context's lifetime.
+	Window time.Duration
+	// MaxLag takes a follower out of the rotation while its replication lag,
+	// probed on every health check, exceeds it. Zero disables the lag probe.
+	MaxLag time.Duration
+}
+
+type writeTrackerKey struct{}
+
+type writeTracker struct {
+	lastWrite atomic.Int64
+}
+
+// WithWriteTracking returns a context that records leader writes made with it
+// or any context derived from it. Install it once per request, typically in
+// a middleware, so that Follower() reads issued after a write in the same
+// request see that write.
+func WithWriteTracking(ctx context.Context) context.Context {
+	if getWriteTracker(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, writeTrackerKey{}, &writeTracker{})
+}
+
+// MarkLeaderWrite records a leader write on ctx, installing a tracker when
+// ctx has none. Exec, NamedExec, the Exec of a prepared statement and Commit
+// on the leader record writes automatically; call this for writes made any
+// other way, such as an INSERT ... RETURNING through Query.
+func MarkLeaderWrite(ctx context.Context) context.Context {
+	ctx = WithWriteTracking(ctx)
+	getWriteTracker(ctx).lastWrite.Store(now().UnixNano())
+	return ctx
+}
+
+func getWriteTracker(ctx context.Context) *writeTracker {
+	t, _ := ctx.Value(writeTrackerKey{}).(*writeTracker)
+	return t
+}
+
+func markLeaderWrite(ctx context.Context) {
+	if t := getWriteTracker(ctx); t != nil {
+		t.lastWrite.Store(now().UnixNano())
+	}
+}
+
+// hasRecentWrite reports whether ctx carries a leader write that is still
+// inside window. A zero window never expires.
+func hasRecentWrite(ctx context.Context, window time.Duration) bool {
+	t := getWriteTracker(ctx)
+	if t == nil {
+		return false
+	}
+	last := t.lastWrite.Load()
+	if last == 0 {
+		return false
+	}
+	return window <= 0 || now().Sub(time.Unix(0, last)) < window
+}
+
+// postgresLagQuery reports no lag once the replica has replayed all the WAL
+// it received, as the age of the last replayed transaction keeps growing
+// while the primary is idle.
+const postgresLagQuery = `SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0 ELSE COALESCE(EXTRACT(EPOCH FROM (now() - pg_last_xact_replay_timestamp())), 0) END`
+
+// probeLag returns how far f is behind the leader.
+func probeLag(ctx context.Context, driver string, f *follower) (time.Duration, error) {
+	switch driver {
+	case "postgres":
+		var seconds float64
+		if err := f.cmd.Get(ctx, "probeReplicationLag", postgresLagQuery, &seconds); err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	case "mysql":
+		rows, err := f.cmd.Query(ctx, "probeReplicationLag", `SHOW REPLICA STATUS`)
+		if err != nil {
+			// SHOW REPLICA STATUS needs MySQL 8.0.22
+			var slaveErr error
+			if rows, slaveErr = f.cmd.Query(ctx, "probeReplicationLag", `SHOW SLAVE STATUS`); slaveErr != nil {
+				return 0, err
+			}
+		}
+		defer rows.Close()
+		return scanMysqlLag(rows)
+	default:
+		return 0, nil
+	}
+}
+
+func scanMysqlLag(rows *sqlx.Rows) (time.Duration, error) {
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, errors.NewWithCode(codes.CodeSQLRead, "not a replica")
+	}
+
+	status := map[string]interface{}{}
+	if err := rows.MapScan(status); err != nil {
+		return 0, errors.WrapWithCode(err, codes.CodeSQLRowScan, "%s", err.Error())
+	}
+
+	for _, col := range []string{"Seconds_Behind_Source", "Seconds_Behind_Master"} {
+		val, ok := status[col]
+		if !ok {
+			continue
+		}
+		var raw string
+		switch v := val.(type) {
+		case nil:
+			// NULL means the replication threads are not running
+			return 0, errors.NewWithCode(codes.CodeSQLRead, "replication is not running")
+		case []byte:
+			raw = string(v)
+		default:
+			raw = fmt.Sprint(v)
+		}
+		seconds, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return 0, errors.WrapWithCode(err, codes.CodeSQLRowScan, "%s", err.Error())
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	return 0, errors.NewWithCode(codes.CodeSQLRead, "replication lag column not found")
+}
+
+// consistentCommand is returned by Follower() when read-your-writes routing
+// is enabled or there are several followers. Every read is sent to a
+// follower picked for it, or to the leader if read-your-writes is enabled and
+// its context carries a recent leader write. Writes, prepared
+// statements and transactions that are not read-only always go to the
+// leader.
+type consistentCommand struct {
+	s *sqlDB
+}
+
+func (r *consistentCommand) route(ctx context.Context) Command {
+	if r.s.cfg.ReadYourWrites.Enabled && hasRecentWrite(ctx, r.s.cfg.ReadYourWrites.Window) {
+		return r.s.leader
+	}
+	return r.s.pickFollower()
+}
+
+// routeTx sends read-only transactions through route, and the others to the
+// leader.
+func (r *consistentCommand) routeTx(ctx context.Context, opts TxOptions) Command {
+	if opts.ReadOnly {
+		return r.route(ctx)
+	}
+	return r.s.leader
+}
+
+func (r *consistentCommand) driverName() string {
+	return DriverName(r.s.leader)
+}
+
+func (r *consistentCommand) load(ctx context.Context, name string, src *loadSource, opts LoadOptions) (int64, error) {
+	return load(ctx, r.s.leader, name, src, opts)
+}
+
+// Close does nothing: the pools behind the routing are owned, and closed,
+// by Stop.
+func (r *consistentCommand) Close() error {
+	return nil
+}
+
+func (r *consistentCommand) Ping(ctx context.Context) error {
+	return r.route(ctx).Ping(ctx)
+}
+
+func (r *consistentCommand) In(query string, args ...interface{}) (string, []interface{}, error) {
+	return sqlx.In(query, args...)
+}
+
+func (r *consistentCommand) Rebind(query string) string {
+	return r.s.leader.Rebind(query)
+}
+
+func (r *consistentCommand) QueryIn(ctx context.Context, name string, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return r.route(ctx).QueryIn(ctx, name, query, args...)
+}
+
+func (r *consistentCommand) QueryRow(ctx context.Context, name string, query string, args ...interface{}) (*sqlx.Row, error) {
+	return r.route(ctx).QueryRow(ctx, name, query, args...)
+}
+
+func (r *consistentCommand) Query(ctx context.Context, name string, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return r.route(ctx).Query(ctx, name, query, args...)
+}
+
+func (r *consistentCommand) NamedQuery(ctx context.Context, name string, query string, arg interface{}) (*sqlx.Rows, error) {
+	return r.route(ctx).NamedQuery(ctx, name, query, arg)
+}
+
+func (r *consistentCommand) Prepare(ctx context.Context, name string, query string) (CommandStmt, error) {
+	return r.s.leader.Prepare(ctx, name, query)
+}
+
+func (r *consistentCommand) NamedExec(ctx context.Context, name string, query string, args interface{}) (sql.Result, error) {
+	return r.s.leader.NamedExec(ctx, name, query, args)
+}
+
+func (r *consistentCommand) Exec(ctx context.Context, name string, query string, args ...interface{}) (sql.Result, error) {
+	return r.s.leader.Exec(ctx, name, query, args...)
+}
+
+func (r *consistentCommand) BeginTx(ctx context.Context, name string, opts TxOptions) (CommandTx, error) {
+	return r.routeTx(ctx, opts).BeginTx(ctx, name, opts)
+}
+
+func (r *consistentCommand) Get(ctx context.Context, name string, query string, dest interface{}, args ...interface{}) error {
+	return r.route(ctx).Get(ctx, name, query, dest, args...)
+}
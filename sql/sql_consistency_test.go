@@ -0,0 +1,352 @@
+package sql
+
+import (
+	"context"
+	goerr "errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHasRecentWrite(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	current := base
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	assert.False(t, hasRecentWrite(context.Background(), 0), "no tracker")
+	assert.False(t, hasRecentWrite(WithWriteTracking(context.Background()), 0), "no write")
+
+	ctx := MarkLeaderWrite(context.Background())
+	assert.True(t, hasRecentWrite(ctx, 0))
+	assert.True(t, hasRecentWrite(ctx, time.Second))
+
+	current = base.Add(2 * time.Second)
+	assert.True(t, hasRecentWrite(ctx, 0), "zero window never expires")
+	assert.False(t, hasRecentWrite(ctx, time.Second))
+}
+
+func TestWithWriteTracking_Idempotent(t *testing.T) {
+	ctx := WithWriteTracking(context.Background())
+	assert.Equal(t, ctx, WithWriteTracking(ctx))
+}
+
+func TestLeaderWritesAreTracked(t *testing.T) {
+	leader := newTestSqliteCommand(t)
+
+	t.Run("exec", func(t *testing.T) {
+		ctx := WithWriteTracking(context.Background())
+		_, err := leader.Exec(ctx, "insert", `INSERT INTO account (name) VALUES (?)`, "alice")
+		require.NoError(t, err)
+		assert.True(t, hasRecentWrite(ctx, 0))
+	})
+
+	t.Run("named exec", func(t *testing.T) {
+		ctx := WithWriteTracking(context.Background())
+		_, err := leader.NamedExec(ctx, "insert", `INSERT INTO account (name) VALUES (:name)`, map[string]interface{}{"name": "bob"})
+		require.NoError(t, err)
+		assert.True(t, hasRecentWrite(ctx, 0))
+	})
+
+	t.Run("failed exec is not tracked", func(t *testing.T) {
+		ctx := WithWriteTracking(context.Background())
+		_, err := leader.Exec(ctx, "insert", `INSERT INTO missing (name) VALUES (?)`, "bob")
+		require.Error(t, err)
+		assert.False(t, hasRecentWrite(ctx, 0))
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		ctx := WithWriteTracking(context.Background())
+		err := WithTx(ctx, leader, "txInsert", TxOptions{}, func(tx CommandTx) error {
+			_, err := tx.Exec("insert", `INSERT INTO account (name) VALUES (?)`, "carol")
+			return err
+		})
+		require.NoError(t, err)
+		assert.True(t, hasRecentWrite(ctx, 0))
+	})
+
+	t.Run("read only commit is not tracked", func(t *testing.T) {
+		ctx := WithWriteTracking(context.Background())
+		err := WithTx(ctx, leader, "txRead", TxOptions{ReadOnly: true}, func(tx CommandTx) error { return nil })
+		require.NoError(t, err)
+		assert.False(t, hasRecentWrite(ctx, 0))
+	})
+
+	t.Run("follower writes are not tracked", func(t *testing.T) {
+		ctx := WithWriteTracking(context.Background())
+		f := newTestFollower(t, true)
+		_, err := f.cmd.Exec(ctx, "insert", `INSERT INTO account (name) VALUES (?)`, "dave")
+		require.NoError(t, err)
+		assert.False(t, hasRecentWrite(ctx, 0))
+	})
+}
+
+func TestConsistentCommand_Route(t *testing.T) {
+	leader := newTestSqliteCommand(t)
+	f := newTestFollower(t, true)
+	s := &sqlDB{
+		leader:    leader,
+		followers: initFollowerPool([]*follower{f}, "sqlite3", "", 0, newMockLogger(t)),
+		cfg:       Config{ReadYourWrites: ReadYourWritesConfig{Enabled: true}},
+	}
+	s.reader = &consistentCommand{s: s}
+
+	ctx := WithWriteTracking(context.Background())
+	_, err := s.Leader().Exec(ctx, "insert", `INSERT INTO account (name) VALUES (?)`, "alice")
+	require.NoError(t, err)
+
+	var n int
+	require.NoError(t, s.Follower().Get(context.Background(), "count", `SELECT COUNT(*) FROM account`, &n))
+	assert.Equal(t, 0, n, "untracked reads go to the follower")
+
+	require.NoError(t, s.Follower().Get(ctx, "count", `SELECT COUNT(*) FROM account`, &n))
+	assert.Equal(t, 1, n, "reads after a write in the same context go to the leader")
+}
+
+func TestConsistentCommand_Writes(t *testing.T) {
+	leader := newTestSqliteCommand(t)
+	f := newTestFollower(t, true)
+	s := &sqlDB{
+		leader:    leader,
+		followers: initFollowerPool([]*follower{f}, "sqlite3", "", 0, newMockLogger(t)),
+		cfg:       Config{ReadYourWrites: ReadYourWritesConfig{Enabled: true}},
+	}
+	s.reader = &consistentCommand{s: s}
+
+	tests := []struct {
+		name  string
+		write func(ctx context.Context) error
+	}{
+		{
+			name: "exec",
+			write: func(ctx context.Context) error {
+				_, err := s.Follower().Exec(ctx, "insert", `INSERT INTO account (name) VALUES (?)`, "alice")
+				return err
+			},
+		},
+		{
+			name: "named exec",
+			write: func(ctx context.Context) error {
+				_, err := s.Follower().NamedExec(ctx, "insert", `INSERT INTO account (name) VALUES (:name)`, map[string]interface{}{"name": "bob"})
+				return err
+			},
+		},
+		{
+			name: "prepare",
+			write: func(ctx context.Context) error {
+				stmt, err := s.Follower().Prepare(ctx, "insert", `INSERT INTO account (name) VALUES (?)`)
+				if err != nil {
+					return err
+				}
+				defer stmt.Close()
+				_, err = stmt.Exec("insert", "carol")
+				return err
+			},
+		},
+		{
+			name: "begin tx",
+			write: func(ctx context.Context) error {
+				tx, err := s.Follower().BeginTx(ctx, "txInsert", TxOptions{})
+				if err != nil {
+					return err
+				}
+				if _, err := tx.Exec("insert", `INSERT INTO account (name) VALUES (?)`, "dave"); err != nil {
+					tx.Rollback()
+					return err
+				}
+				return tx.Commit()
+			},
+		},
+		{
+			name: "with tx",
+			write: func(ctx context.Context) error {
+				return WithTx(ctx, s.Follower(), "txInsert", TxOptions{}, func(tx CommandTx) error {
+					_, err := tx.Exec("insert", `INSERT INTO account (name) VALUES (?)`, "erin")
+					return err
+				})
+			},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithWriteTracking(context.Background())
+			require.NoError(t, tt.write(ctx))
+			assert.True(t, hasRecentWrite(ctx, 0), "the write is tracked")
+			assert.Equal(t, i+1, countAccounts(t, leader))
+			assert.Equal(t, 0, countAccounts(t, f.cmd.(*command)))
+		})
+	}
+
+	t.Run("read only tx", func(t *testing.T) {
+		var n int
+		err := WithTx(context.Background(), s.Follower(), "txRead", TxOptions{ReadOnly: true}, func(tx CommandTx) error {
+			return tx.Get("count", `SELECT COUNT(*) FROM account`, &n)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 0, n, "read only transactions go to the follower")
+	})
+}
+
+func TestConsistentCommand_Close(t *testing.T) {
+	f := newTestFollower(t, true)
+	s := &sqlDB{
+		leader:    newTestSqliteCommand(t),
+		followers: initFollowerPool([]*follower{f}, "sqlite3", "", 0, newMockLogger(t)),
+		cfg:       Config{ReadYourWrites: ReadYourWritesConfig{Enabled: true}},
+	}
+	s.reader = &consistentCommand{s: s}
+
+	require.NoError(t, s.Follower().Close())
+	assert.NoError(t, s.leader.Ping(context.Background()), "leader still open")
+	assert.NoError(t, f.cmd.Ping(context.Background()), "follower still open")
+}
+
+func TestConsistentCommand_TxMetrics(t *testing.T) {
+	leader := newTestSqliteCommand(t)
+	instr := newMockInstrument(t)
+	leader.instrument, leader.useInstrument = instr, true
+	s := &sqlDB{
+		leader:    leader,
+		followers: initFollowerPool([]*follower{newTestFollower(t, true)}, "sqlite3", "", 0, newMockLogger(t)),
+		cfg:       Config{ReadYourWrites: ReadYourWritesConfig{Enabled: true}},
+	}
+	s.reader = &consistentCommand{s: s}
+
+	instr.EXPECT().DatabaseQueryTimer("testdb", connTypeLeader, gomock.Any()).Return(prometheus.NewTimer(prometheus.ObserverFunc(func(float64) {}))).AnyTimes()
+	instr.EXPECT().DatabaseTxCounter("testdb", connTypeLeader, "txRouted", txOutcomeCommit)
+
+	ctx := MarkLeaderWrite(context.Background())
+	err := WithTx(ctx, s.Follower(), "txRouted", TxOptions{}, func(tx CommandTx) error {
+		_, err := tx.Exec("insert", `INSERT INTO account (name) VALUES (?)`, "alice")
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, countAccounts(t, leader))
+}
+
+func TestFollowerPool_CheckLag(t *testing.T) {
+	t.Run("driver without lag probe", func(t *testing.T) {
+		f := newTestFollower(t, true)
+		p := initFollowerPool([]*follower{f}, "sqlite3", "", time.Second, newMockLogger(t))
+		p.checkHealth(context.Background())
+		assert.False(t, f.lagging.Load())
+		assert.Equal(t, f, p.pick())
+	})
+
+	t.Run("failing probe takes the follower out", func(t *testing.T) {
+		f := newTestFollower(t, true)
+		// sqlite has no pg_last_xact_replay_timestamp, so the postgres probe fails
+		p := initFollowerPool([]*follower{f}, "postgres", "", time.Second, newMockLogger(t))
+		p.checkHealth(context.Background())
+		assert.True(t, f.lagging.Load())
+		assert.Nil(t, p.pick())
+	})
+}
+
+// statusCommand answers the replica status statements it knows with the
+// rows of a sqlite query, and fails the others.
+type statusCommand struct {
+	Command
+	t       *testing.T
+	queries map[string]string
+}
+
+func (c statusCommand) Query(ctx context.Context, name string, query string, args ...interface{}) (*sqlx.Rows, error) {
+	q, ok := c.queries[query]
+	if !ok {
+		return nil, goerr.New("syntax error")
+	}
+	return newTestSqliteDB(c.t).Queryx(q)
+}
+
+// Get answers the replica status queries it knows like Query does.
+func (c statusCommand) Get(ctx context.Context, name string, query string, dest interface{}, args ...interface{}) error {
+	q, ok := c.queries[query]
+	if !ok {
+		return goerr.New("syntax error")
+	}
+	return newTestSqliteDB(c.t).Get(dest, q)
+}
+
+func TestProbeLag_Postgres(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "lagging", queries: map[string]string{postgresLagQuery: `SELECT 1.5`}, want: 1500 * time.Millisecond},
+		{name: "caught up", queries: map[string]string{postgresLagQuery: `SELECT 0`}},
+		{name: "probe failed", queries: map[string]string{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &follower{cmd: statusCommand{t: t, queries: tt.queries}}
+			got, err := probeLag(context.Background(), "postgres", f)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("probeLag() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	assert.Contains(t, postgresLagQuery, "pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0")
+}
+
+func TestProbeLag_Mysql(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "replica status", queries: map[string]string{`SHOW REPLICA STATUS`: `SELECT 7 AS Seconds_Behind_Source`}, want: 7 * time.Second},
+		{name: "before 8.0.22", queries: map[string]string{`SHOW SLAVE STATUS`: `SELECT 3 AS Seconds_Behind_Master`}, want: 3 * time.Second},
+		{name: "no status", queries: map[string]string{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &follower{cmd: statusCommand{t: t, queries: tt.queries}}
+			got, err := probeLag(context.Background(), "mysql", f)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("probeLag() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScanMysqlLag(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "source column", query: `SELECT 7 AS Seconds_Behind_Source`, want: 7 * time.Second},
+		{name: "legacy master column", query: `SELECT '3' AS Seconds_Behind_Master`, want: 3 * time.Second},
+		{name: "replication stopped", query: `SELECT NULL AS Seconds_Behind_Source`, wantErr: true},
+		{name: "not a replica", query: `SELECT 1 AS Seconds_Behind_Source WHERE 1 = 0`, wantErr: true},
+		{name: "missing column", query: `SELECT 1 AS other`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := newTestSqliteDB(t).Queryx(tt.query)
+			require.NoError(t, err)
+			defer rows.Close()
+
+			got, err := scanMysqlLag(rows)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("scanMysqlLag() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
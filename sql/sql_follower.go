@@ -0,0 +1,151 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Replica selection strategies for Config.FollowerSelection.
+const (
+	FollowerSelectionRoundRobin       = "round_robin"
+	FollowerSelectionLeastConnections = "least_connections"
+	FollowerSelectionRandom           = "random"
+)
+
+const defaultFollowerHealthCheckInterval = 10 * time.Second
+
+type follower struct {
+	cmd     Command
+	db      *sqlx.DB
+	addr    string
+	breaker *circuitBreaker
+	healthy atomic.Bool
+	lagging atomic.Bool
+}
+
+type followerPool struct {
+	followers []*follower
+	driver    string
+	selection string
+	maxLag    time.Duration
+	next      atomic.Uint64
+	log       logger.Interface
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+func initFollowerPool(followers []*follower, driver, selection string, maxLag time.Duration, log logger.Interface) *followerPool {
+	return &followerPool{
+		followers: followers,
+		driver:    driver,
+		selection: selection,
+		maxLag:    maxLag,
+		log:       log,
+		done:      make(chan struct{}),
+	}
+}
+
+// pick returns a healthy follower, or nil when there is none.
+func (p *followerPool) pick() *follower {
+	if p == nil || len(p.followers) == 0 {
+		return nil
+	}
+
+	healthy := make([]*follower, 0, len(p.followers))
+	for _, f := range p.followers {
+		if f.healthy.Load() && !f.lagging.Load() && !f.breaker.rejecting() {
+			healthy = append(healthy, f)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	switch p.selection {
+	case FollowerSelectionLeastConnections:
+		least := healthy[0]
+		for _, f := range healthy[1:] {
+			if f.db.Stats().InUse < least.db.Stats().InUse {
+				least = f
+			}
+		}
+		return least
+	case FollowerSelectionRandom:
+		return healthy[rand.Intn(len(healthy))]
+	default:
+		return healthy[(p.next.Add(1)-1)%uint64(len(healthy))]
+	}
+}
+
+func (p *followerPool) startHealthCheck(interval time.Duration) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				p.checkHealth(ctx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// checkHealth pings every follower, probes its replication lag when maxLag
+// is set, and moves it in or out of the rotation.
+func (p *followerPool) checkHealth(ctx context.Context) {
+	for _, f := range p.followers {
+		err := f.cmd.Ping(ctx)
+		wasHealthy := f.healthy.Swap(err == nil)
+		switch {
+		case err != nil && wasHealthy:
+			p.log.Warn(ctx, fmt.Sprintf("SQL: [FOLLOWER] @%s removed from rotation: %s", f.addr, err))
+		case err == nil && !wasHealthy:
+			p.log.Info(ctx, fmt.Sprintf("SQL: [FOLLOWER] @%s back in rotation", f.addr))
+		}
+
+		if err == nil && p.maxLag > 0 {
+			p.checkLag(ctx, f)
+		}
+	}
+}
+
+func (p *followerPool) checkLag(ctx context.Context, f *follower) {
+	lag, err := probeLag(ctx, p.driver, f)
+	isLagging := err != nil || lag > p.maxLag
+	wasLagging := f.lagging.Swap(isLagging)
+	switch {
+	case isLagging && !wasLagging && err != nil:
+		p.log.Warn(ctx, fmt.Sprintf("SQL: [FOLLOWER] @%s removed from rotation, cannot probe replication lag: %s", f.addr, err))
+	case isLagging && !wasLagging:
+		p.log.Warn(ctx, fmt.Sprintf("SQL: [FOLLOWER] @%s removed from rotation, replication lag %s exceeds %s", f.addr, lag, p.maxLag))
+	case !isLagging && wasLagging:
+		p.log.Info(ctx, fmt.Sprintf("SQL: [FOLLOWER] @%s caught up, back in rotation", f.addr))
+	}
+}
+
+// stop ends the health check and closes every follower pool.
+func (p *followerPool) stop(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	close(p.done)
+	p.wg.Wait()
+	for _, f := range p.followers {
+		if err := f.cmd.Close(); err != nil {
+			p.log.Error(ctx, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFollower(t *testing.T, healthy bool) *follower {
+	t.Helper()
+	db := newTestSqliteDB(t)
+	f := &follower{
+		cmd:  initCommand(db, "testdb", nil, newMockLogger(t), false, false, false, nil, nil),
+		db:   db,
+		addr: "sqlite",
+	}
+	f.healthy.Store(healthy)
+	return f
+}
+
+func TestFollowerPool_Pick(t *testing.T) {
+	t.Run("nil pool", func(t *testing.T) {
+		var p *followerPool
+		assert.Nil(t, p.pick())
+	})
+
+	t.Run("round robin skips unhealthy followers", func(t *testing.T) {
+		f0, f1, f2 := newTestFollower(t, true), newTestFollower(t, false), newTestFollower(t, true)
+		p := initFollowerPool([]*follower{f0, f1, f2}, "sqlite3", FollowerSelectionRoundRobin, 0, newMockLogger(t))
+		assert.Equal(t, []*follower{f0, f2, f0, f2}, []*follower{p.pick(), p.pick(), p.pick(), p.pick()})
+	})
+
+	t.Run("least connections", func(t *testing.T) {
+		f0, f1 := newTestFollower(t, true), newTestFollower(t, true)
+		conn, err := f0.db.Conn(context.Background())
+		assert.NoError(t, err)
+		defer conn.Close()
+
+		p := initFollowerPool([]*follower{f0, f1}, "sqlite3", FollowerSelectionLeastConnections, 0, newMockLogger(t))
+		assert.Equal(t, f1, p.pick())
+	})
+
+	t.Run("random only returns healthy followers", func(t *testing.T) {
+		f0, f1 := newTestFollower(t, false), newTestFollower(t, true)
+		p := initFollowerPool([]*follower{f0, f1}, "sqlite3", FollowerSelectionRandom, 0, newMockLogger(t))
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, f1, p.pick())
+		}
+	})
+
+	t.Run("all unhealthy", func(t *testing.T) {
+		p := initFollowerPool([]*follower{newTestFollower(t, false)}, "sqlite3", FollowerSelectionRoundRobin, 0, newMockLogger(t))
+		assert.Nil(t, p.pick())
+	})
+}
+
+func TestFollowerPool_CheckHealth(t *testing.T) {
+	down, recovering := newTestFollower(t, true), newTestFollower(t, false)
+	assert.NoError(t, down.db.Close())
+
+	p := initFollowerPool([]*follower{down, recovering}, "sqlite3", FollowerSelectionRoundRobin, 0, newMockLogger(t))
+	p.checkHealth(context.Background())
+
+	assert.False(t, down.healthy.Load())
+	assert.True(t, recovering.healthy.Load())
+}
+
+func TestSqlDB_Follower(t *testing.T) {
+	leader := newTestSqliteCommand(t)
+
+	t.Run("no followers falls back to leader", func(t *testing.T) {
+		s := &sqlDB{leader: leader}
+		assert.Equal(t, Command(leader), s.Follower())
+	})
+
+	t.Run("every follower down falls back to leader", func(t *testing.T) {
+		s := &sqlDB{
+			leader:    leader,
+			followers: initFollowerPool([]*follower{newTestFollower(t, false)}, "sqlite3", "", 0, newMockLogger(t)),
+		}
+		assert.Equal(t, Command(leader), s.Follower())
+	})
+
+	t.Run("healthy follower", func(t *testing.T) {
+		f := newTestFollower(t, true)
+		s := &sqlDB{
+			leader:    leader,
+			followers: initFollowerPool([]*follower{f}, "sqlite3", "", 0, newMockLogger(t)),
+		}
+		assert.Equal(t, f.cmd, s.Follower())
+	})
+}
+
+func TestSqlDB_StatsLabel(t *testing.T) {
+	s := &sqlDB{cfg: Config{Name: "app"}}
+	assert.Equal(t, "app_leader", s.statsLabel(connTypeLeader, 0, 1))
+	assert.Equal(t, "app_follower", s.statsLabel(connTypeFollower, 0, 1))
+	assert.Equal(t, "app_follower_2", s.statsLabel(connTypeFollower, 2, 3))
+}
+
+func TestSqlDB_FollowerConfigs(t *testing.T) {
+	leader := ConnConfig{Host: "leader", Port: 3306}
+	replicas := []ConnConfig{{Host: "r1", Port: 3306}, {Host: "r2", Port: 3306}}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want []ConnConfig
+	}{
+		{name: "none", cfg: Config{Leader: leader}},
+		{name: "legacy follower same as leader", cfg: Config{Leader: leader, Follower: leader}},
+		{name: "legacy follower", cfg: Config{Leader: leader, Follower: replicas[0]}, want: replicas[:1]},
+		{name: "followers take precedence", cfg: Config{Leader: leader, Follower: replicas[0], Followers: replicas}, want: replicas},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sqlDB{cfg: tt.cfg}
+			assert.Equal(t, tt.want, s.followerConfigs())
+		})
+	}
+}
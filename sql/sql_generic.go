@@ -0,0 +1,118 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"iter"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// Queryer is the read surface used by Select, Get and Iterate. Command
+// satisfies it; wrap a CommandTx with TxQueryer.
+type Queryer interface {
+	Query(ctx context.Context, name string, query string, args ...interface{}) (*sqlx.Rows, error)
+}
+
+type txQueryer struct {
+	tx CommandTx
+}
+
+// TxQueryer adapts tx to Queryer. The per-call context is honoured when tx
+// implements CommandTxContext, which every transaction from BeginTx does.
+func TxQueryer(tx CommandTx) Queryer {
+	return &txQueryer{tx: tx}
+}
+
+func (q *txQueryer) Query(ctx context.Context, name string, query string, args ...interface{}) (*sqlx.Rows, error) {
+	if tx, ok := q.tx.(CommandTxContext); ok {
+		return tx.QueryContext(ctx, name, query, args...)
+	}
+	return q.tx.Query(name, query, args...)
+}
+
+// Select runs query and scans every row into a T. Structs, and pointers to
+// structs, are mapped by their `db` tags; any other T, such as int64 or
+// string, receives the single column of each row.
+func Select[T any](ctx context.Context, q Queryer, name string, query string, args ...interface{}) ([]T, error) {
+	result := []T{}
+	for v, err := range Iterate[T](ctx, q, name, query, args...) {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+// Get runs query and scans its first row into a T. It returns ErrNotFound when
+// the query yields no rows.
+func Get[T any](ctx context.Context, q Queryer, name string, query string, args ...interface{}) (T, error) {
+	for v, err := range Iterate[T](ctx, q, name, query, args...) {
+		return v, err
+	}
+	var zero T
+	return zero, ErrNotFound
+}
+
+// Iterate runs query and yields its rows one at a time without loading the
+// whole result set. Iteration stops after the first error; breaking out of
+// the loop closes the rows.
+func Iterate[T any](ctx context.Context, q Queryer, name string, query string, args ...interface{}) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		rows, err := q.Query(ctx, name, query, args...)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			v, err := scanRow[T](rows)
+			if !yield(v, err) || err != nil {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+func scanRow[T any](rows *sqlx.Rows) (T, error) {
+	var v T
+	t := reflect.TypeOf(v)
+	if isStructRow(t) {
+		return v, rows.StructScan(&v)
+	}
+	// a pointer to a struct row gets a new struct for every row
+	if t != nil && t.Kind() == reflect.Pointer && isStructRow(t.Elem()) {
+		p := reflect.New(t.Elem())
+		if err := rows.StructScan(p.Interface()); err != nil {
+			return v, err
+		}
+		return p.Interface().(T), nil
+	}
+	return v, rows.Scan(&v)
+}
+
+// isStructRow reports whether t should be filled column by column through its
+// `db` tags rather than scanned as a single value, following sqlx's rules: a
+// sql.Scanner or a struct without exported fields, such as time.Time, is a
+// single value.
+func isStructRow(t reflect.Type) bool {
+	if t == nil || t.Kind() != reflect.Struct || reflect.PointerTo(t).Implements(scannerType) {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
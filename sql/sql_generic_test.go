@@ -0,0 +1,193 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAccount struct {
+	ID      int64  `db:"id"`
+	Name    string `db:"name"`
+	Balance int64  `db:"balance"`
+}
+
+func seedAccounts(t *testing.T, c *command, names ...string) {
+	t.Helper()
+	for i, name := range names {
+		_, err := c.Exec(context.Background(), "seed", `INSERT INTO account (name, balance) VALUES (?, ?)`, name, (i+1)*100)
+		require.NoError(t, err)
+	}
+}
+
+func TestGenericSelect(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	seedAccounts(t, c, "alice", "bob")
+
+	tests := []struct {
+		name    string
+		query   string
+		args    []interface{}
+		want    []testAccount
+		wantErr bool
+	}{
+		{
+			name:  "all rows",
+			query: `SELECT id, name, balance FROM account ORDER BY id`,
+			want:  []testAccount{{ID: 1, Name: "alice", Balance: 100}, {ID: 2, Name: "bob", Balance: 200}},
+		},
+		{
+			name:  "no rows",
+			query: `SELECT id, name, balance FROM account WHERE name = ?`,
+			args:  []interface{}{"carol"},
+			want:  []testAccount{},
+		},
+		{
+			name:    "unknown column",
+			query:   `SELECT id, name, balance, 1 AS extra FROM account`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid query",
+			query:   `SELECT FROM`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Select[testAccount](context.Background(), c, "selectAccount", tt.query, tt.args...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGenericSelect_Scalar(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	seedAccounts(t, c, "alice", "bob")
+
+	names, err := Select[string](context.Background(), c, "selectName", `SELECT name FROM account ORDER BY id`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, names)
+}
+
+func TestGenericSelect_Pointer(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	seedAccounts(t, c, "alice", "bob")
+
+	got, err := Select[*testAccount](context.Background(), c, "selectAccount", `SELECT id, name, balance FROM account ORDER BY id`)
+	require.NoError(t, err)
+	assert.Equal(t, []*testAccount{{ID: 1, Name: "alice", Balance: 100}, {ID: 2, Name: "bob", Balance: 200}}, got)
+	assert.NotSame(t, got[0], got[1], "every row gets its own struct")
+
+	one, err := Get[*testAccount](context.Background(), c, "getAccount", `SELECT id, name, balance FROM account WHERE name = ?`, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, &testAccount{ID: 2, Name: "bob", Balance: 200}, one)
+
+	balance, err := Get[*int64](context.Background(), c, "getBalance", `SELECT balance FROM account WHERE name = ?`, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), *balance)
+}
+
+func Test_isStructRow(t *testing.T) {
+	tests := []struct {
+		name string
+		t    reflect.Type
+		want bool
+	}{
+		{name: "struct", t: reflect.TypeOf(testAccount{}), want: true},
+		{name: "time", t: reflect.TypeOf(time.Time{}), want: false},
+		{name: "scanner", t: reflect.TypeOf(sql.NullString{}), want: false},
+		{name: "string", t: reflect.TypeOf(""), want: false},
+		{name: "pointer", t: reflect.TypeOf(&testAccount{}), want: false},
+		{name: "nil", t: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isStructRow(tt.t))
+		})
+	}
+}
+
+func TestGenericGet(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	seedAccounts(t, c, "alice")
+
+	got, err := Get[testAccount](context.Background(), c, "getAccount", `SELECT id, name, balance FROM account WHERE name = ?`, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, testAccount{ID: 1, Name: "alice", Balance: 100}, got)
+
+	_, err = Get[testAccount](context.Background(), c, "getAccount", `SELECT id, name, balance FROM account WHERE name = ?`, "bob")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	balance, err := Get[int64](context.Background(), c, "getBalance", `SELECT balance FROM account WHERE name = ?`, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), balance)
+}
+
+func TestGenericIterate(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	seedAccounts(t, c, "alice", "bob", "carol")
+
+	var names []string
+	for a, err := range Iterate[testAccount](context.Background(), c, "iterateAccount", `SELECT id, name, balance FROM account ORDER BY id`) {
+		require.NoError(t, err)
+		names = append(names, a.Name)
+		if len(names) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"alice", "bob"}, names)
+
+	// breaking out of the loop must release the single pooled connection
+	n, err := Get[int](context.Background(), c, "count", `SELECT COUNT(*) FROM account`)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	var errs int
+	for _, err := range Iterate[testAccount](context.Background(), c, "iterateAccount", `SELECT FROM`) {
+		assert.Error(t, err)
+		errs++
+	}
+	assert.Equal(t, 1, errs)
+}
+
+func TestGenericHelpers_Tx(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	seedAccounts(t, c, "alice")
+
+	err := WithTx(context.Background(), c, "tx", TxOptions{}, func(tx CommandTx) error {
+		if _, err := tx.Exec("insert", `INSERT INTO account (name) VALUES (?)`, "bob"); err != nil {
+			return err
+		}
+
+		// the uncommitted row is visible through the transaction
+		got, err := Select[testAccount](context.Background(), TxQueryer(tx), "selectAccount", `SELECT id, name, balance FROM account ORDER BY id`)
+		if err != nil {
+			return err
+		}
+		assert.Len(t, got, 2)
+
+		_, err = Get[testAccount](context.Background(), TxQueryer(tx), "getAccount", `SELECT id, name, balance FROM account WHERE name = ?`, "carol")
+		assert.ErrorIs(t, err, ErrNotFound)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = WithTx(context.Background(), c, "tx", TxOptions{}, func(tx CommandTx) error {
+		_, err := Select[testAccount](ctx, TxQueryer(tx), "selectAccount", `SELECT id, name, balance FROM account`)
+		return err
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,482 @@
+package sql
+
+import (
+	"bufio"
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/parser"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+const defaultLoadProgressEvery = 10000
+
+var (
+	// mysql LOAD DATA reader handlers must have unique names
+	loadHandlerSeq atomic.Uint64
+	loadEscaper    = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+type LoadOptions struct {
+	// Table receives the rows. A "schema.table" name is supported.
+	Table string
+	// Columns limits the loaded columns to these `db` tags, or CSV header
+	// names for LoadCSV. Defaults to all of them.
+	Columns []string
+	// OmitColumns drops columns from the loaded ones.
+	OmitColumns []string
+	// BatchSize caps the rows sent in one INSERT on sqlite. Zero uses as many
+	// rows as the placeholder limit allows. Postgres and MySQL stream every
+	// row through a single COPY / LOAD DATA.
+	BatchSize int
+	// CSV configures the reader used by LoadCSV. A zero Separator means ','.
+	CSV parser.CsvOptions
+	// ProgressEvery is the number of rows between two OnProgress calls.
+	// Defaults to 10000.
+	ProgressEvery int64
+	// OnProgress receives the number of rows sent so far, every
+	// ProgressEvery rows and once more when the load completes.
+	OnProgress func(loaded int64)
+}
+
+// loadSource streams the rows to load. next returns io.EOF after the last
+// row.
+type loadSource struct {
+	columns []string
+	next    func() ([]interface{}, error)
+}
+
+// bulkLoader is implemented by the commands able to stream rows into a
+// table.
+type bulkLoader interface {
+	load(ctx context.Context, name string, src *loadSource, opts LoadOptions) (int64, error)
+}
+
+// Load streams rows into opts.Table with the fastest path of the driver:
+// COPY FROM STDIN on postgres, LOAD DATA LOCAL INFILE on mysql and batched
+// prepared INSERTs on sqlite. The load runs in one transaction and returns
+// the number of rows written. T is a struct, or a pointer to one, whose `db`
+// tags name the columns; rows can come straight from Iterate.
+//
+// MySQL needs local_infile enabled on the server. It skips the duplicate or
+// invalid rows of a LOAD DATA LOCAL with a warning instead of failing, so the
+// load is rolled back when fewer rows are written than were sent; a table
+// that is not transactional, such as MyISAM, keeps the written ones.
+func Load[T any](ctx context.Context, cmd Command, name string, rows iter.Seq2[T, error], opts LoadOptions) (int64, error) {
+	rowType := reflect.TypeOf((*T)(nil)).Elem()
+	structType := rowType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return 0, errors.NewWithCode(codes.CodeSQLBuilder, "load rows must be structs, got %s", rowType)
+	}
+
+	tagged := map[string][]int{}
+	var order []string
+	collectDBFields(structType, nil, tagged, &order)
+	picked, err := pickColumns(order, opts.Columns, opts.OmitColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	src := &loadSource{}
+	fields := make([][]int, 0, len(picked))
+	for _, i := range picked {
+		src.columns = append(src.columns, order[i])
+		fields = append(fields, tagged[order[i]])
+	}
+
+	next, stop := iter.Pull2(rows)
+	defer stop()
+	src.next = func() ([]interface{}, error) {
+		row, err, ok := next()
+		if !ok {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		v := reflect.Indirect(reflect.ValueOf(row))
+		if !v.IsValid() {
+			return nil, errors.NewWithCode(codes.CodeSQLBuilder, "cannot load a nil row")
+		}
+		vals := make([]interface{}, len(fields))
+		for i, index := range fields {
+			vals[i] = v.FieldByIndex(index).Interface()
+		}
+		return vals, nil
+	}
+
+	return load(ctx, cmd, name, src, opts)
+}
+
+// LoadCSV streams the CSV records of r into opts.Table like Load does. The
+// first record is the header naming the columns, as parser.CsvInterface
+// expects, and empty fields are loaded as NULL.
+func LoadCSV(ctx context.Context, cmd Command, name string, r io.Reader, opts LoadOptions) (int64, error) {
+	cr := csv.NewReader(r)
+	cr.LazyQuotes = opts.CSV.LazyQuotes
+	if opts.CSV.Separator != 0 {
+		cr.Comma = opts.CSV.Separator
+	}
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.WrapWithCode(err, codes.CodeSQLBuilder, "cannot read csv header: %s", err.Error())
+	}
+	picked, err := pickColumns(header, opts.Columns, opts.OmitColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	src := &loadSource{}
+	for _, i := range picked {
+		src.columns = append(src.columns, header[i])
+	}
+	src.next = func() ([]interface{}, error) {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, errors.WrapWithCode(err, codes.CodeSQLBuilder, "cannot read csv record: %s", err.Error())
+		}
+		vals := make([]interface{}, len(picked))
+		for i, j := range picked {
+			if record[j] != "" {
+				vals[i] = record[j]
+			}
+		}
+		return vals, nil
+	}
+
+	return load(ctx, cmd, name, src, opts)
+}
+
+func load(ctx context.Context, cmd Command, name string, src *loadSource, opts LoadOptions) (int64, error) {
+	if opts.Table == "" {
+		return 0, errors.NewWithCode(codes.CodeSQLBuilder, "load needs a table")
+	}
+	l, ok := cmd.(bulkLoader)
+	if !ok {
+		return 0, errors.NewWithCode(codes.CodeSQLBuilder, "bulk load is not supported by %T", cmd)
+	}
+	return l.load(ctx, name, src, opts)
+}
+
+type loadProgress struct {
+	every  int64
+	fn     func(int64)
+	loaded int64
+}
+
+func newLoadProgress(opts LoadOptions) *loadProgress {
+	p := &loadProgress{every: opts.ProgressEvery, fn: opts.OnProgress}
+	if p.every <= 0 {
+		p.every = defaultLoadProgressEvery
+	}
+	return p
+}
+
+func (p *loadProgress) add() {
+	p.loaded++
+	if p.fn != nil && p.loaded%p.every == 0 {
+		p.fn(p.loaded)
+	}
+}
+
+func (p *loadProgress) done() {
+	if p.fn != nil && (p.loaded == 0 || p.loaded%p.every != 0) {
+		p.fn(p.loaded)
+	}
+}
+
+func (c *command) load(ctx context.Context, name string, src *loadSource, opts LoadOptions) (int64, error) {
+	if err := c.breaker.allow(); err != nil {
+		return 0, err
+	}
+	if c.useInstrument {
+		timer := c.instrument.DatabaseQueryTimer(c.connName, c.connType, name)
+		defer timer.ObserveDuration()
+	}
+	if c.logQuery {
+		c.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, fmt.Sprintf("LOAD INTO %s (%s)", opts.Table, strings.Join(src.columns, ", "))))
+	}
+
+	var (
+		progress = newLoadProgress(opts)
+		loaded   int64
+		err      error
+	)
+	switch c.db.DriverName() {
+	case "postgres":
+		loaded, err = c.copyIn(ctx, src, opts, progress)
+	case "mysql":
+		loaded, err = c.loadDataInfile(ctx, src, opts, progress)
+	default:
+		loaded, err = c.batchInsert(ctx, src, opts, progress)
+	}
+	c.breaker.record(err)
+	if err != nil {
+		if errors.GetCode(err) != codes.NoCode {
+			return 0, err
+		}
+		return 0, errors.WrapWithCode(err, codes.CodeSQLTxExec, "load into %s failed: %s", opts.Table, err.Error())
+	}
+
+	progress.done()
+	if c.connType == connTypeLeader {
+		markLeaderWrite(ctx)
+	}
+	return loaded, nil
+}
+
+// copyIn streams the rows through COPY FROM STDIN in one transaction.
+func (c *command) copyIn(ctx context.Context, src *loadSource, opts LoadOptions, progress *loadProgress) (int64, error) {
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, copyInQuery(opts.Table, src.columns))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for {
+		row, err := src.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return 0, err
+		}
+		progress.add()
+	}
+	// an Exec without args flushes the buffered rows
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, err
+	}
+	if err := stmt.Close(); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return progress.loaded, nil
+}
+
+func copyInQuery(table string, columns []string) string {
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		return pq.CopyInSchema(schema, name, columns...)
+	}
+	return pq.CopyIn(table, columns...)
+}
+
+// loadDataInfile streams the rows, encoded as CSV, through LOAD DATA LOCAL
+// INFILE and a reader handler registered for this load only, in one
+// transaction that is rolled back when rows were skipped.
+func (c *command) loadDataInfile(ctx context.Context, src *loadSource, opts LoadOptions, progress *loadProgress) (int64, error) {
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	handler := fmt.Sprintf("sdk_go_load_%d", loadHandlerSeq.Add(1))
+	pr, pw := io.Pipe()
+	mysql.RegisterReaderHandler(handler, func() io.Reader { return pr })
+	defer mysql.DeregisterReaderHandler(handler)
+
+	var writeErr error
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		writeErr = writeLoadRows(pw, src, progress)
+		pw.CloseWithError(writeErr)
+	}()
+
+	res, err := tx.ExecContext(ctx, loadDataQuery(handler, opts.Table, src.columns))
+	// unblock the writer if the server stopped reading early
+	pr.Close()
+	<-written
+	if writeErr != nil {
+		return 0, writeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if err := checkLoaded(opts.Table, affected, progress.loaded); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
+
+// checkLoaded fails a load that did not write every row it sent, as mysql
+// only warns about the rows it skipped.
+func checkLoaded(table string, affected, sent int64) error {
+	if affected != sent {
+		return errors.NewWithCode(codes.CodeSQLTxExec, "load into %s wrote %d of %d rows", table, affected, sent)
+	}
+	return nil
+}
+
+func loadDataQuery(handler, table string, columns []string) string {
+	return fmt.Sprintf(`LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s CHARACTER SET utf8mb4 FIELDS TERMINATED BY ',' ENCLOSED BY '"' ESCAPED BY '\\' LINES TERMINATED BY '\n' (%s)`, handler, table, strings.Join(columns, ", "))
+}
+
+// writeLoadRows encodes the rows in the format declared by loadDataQuery:
+// every value is enclosed in double quotes with backslash escapes, and NULL
+// is written as \N.
+func writeLoadRows(w io.Writer, src *loadSource, progress *loadProgress) error {
+	buf := bufio.NewWriter(w)
+	for {
+		row, err := src.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		for i, val := range row {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			field, isNull, err := loadField(val)
+			if err != nil {
+				return err
+			}
+			if isNull {
+				buf.WriteString(`\N`)
+				continue
+			}
+			buf.WriteByte('"')
+			buf.WriteString(loadEscaper.Replace(field))
+			buf.WriteByte('"')
+		}
+		if err := buf.WriteByte('\n'); err != nil {
+			return err
+		}
+		progress.add()
+	}
+	return buf.Flush()
+}
+
+func loadField(val interface{}) (string, bool, error) {
+	v, err := driver.DefaultParameterConverter.ConvertValue(val)
+	if err != nil {
+		return "", false, errors.WrapWithCode(err, codes.CodeSQLBuilder, "%s", err.Error())
+	}
+	switch v := v.(type) {
+	case nil:
+		return "", true, nil
+	case []byte:
+		return string(v), false, nil
+	case string:
+		return v, false, nil
+	case bool:
+		if v {
+			return "1", false, nil
+		}
+		return "0", false, nil
+	case int64:
+		return strconv.FormatInt(v, 10), false, nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), false, nil
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999"), false, nil
+	default:
+		return fmt.Sprintf("%v", v), false, nil
+	}
+}
+
+// batchInsert writes the rows with prepared multi-row INSERTs in one
+// transaction.
+func (c *command) batchInsert(ctx context.Context, src *loadSource, opts LoadOptions, progress *loadProgress) (int64, error) {
+	size, err := batchRows(c.db.DriverName(), opts.Table, len(src.columns), opts.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	w := &bulkWriter{opts: BulkOptions{Table: opts.Table}, columns: src.columns}
+
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	full, err := tx.PreparexContext(ctx, tx.Rebind(w.query(size)))
+	if err != nil {
+		return 0, err
+	}
+	defer full.Close()
+
+	args := make([]interface{}, 0, size*len(src.columns))
+	flush := func() error {
+		rows := len(args) / len(src.columns)
+		if rows == size {
+			_, err := full.ExecContext(ctx, args...)
+			return err
+		}
+		_, err := tx.ExecContext(ctx, tx.Rebind(w.query(rows)), args...)
+		return err
+	}
+
+	for {
+		row, err := src.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, row...)
+		progress.add()
+
+		if len(args) == cap(args) {
+			if err := flush(); err != nil {
+				return 0, err
+			}
+			args = args[:0]
+		}
+	}
+	if len(args) > 0 {
+		if err := flush(); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return progress.loaded, nil
+}
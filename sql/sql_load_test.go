@@ -0,0 +1,260 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	goerr "errors"
+	"io"
+	"iter"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seqOf[T any](rows []T, err error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for _, row := range rows {
+			if !yield(row, nil) {
+				return
+			}
+		}
+		if err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	rows := []bulkRow{{ID: 1, Name: "a", Balance: 1}, {ID: 2, Name: "b", Balance: 2}, {ID: 3, Name: "c", Balance: 3}, {ID: 4, Name: "d", Balance: 4}, {ID: 5, Name: "e", Balance: 5}}
+	errIter := goerr.New("source failed")
+
+	tests := []struct {
+		name         string
+		rows         iter.Seq2[*bulkRow, error]
+		opts         LoadOptions
+		want         int64
+		wantProgress []int64
+		wantCode     codes.Code
+		wantErr      error
+	}{
+		{
+			name:         "batches and progress",
+			rows:         seqOf([]*bulkRow{&rows[0], &rows[1], &rows[2], &rows[3], &rows[4]}, nil),
+			opts:         LoadOptions{Table: "account", OmitColumns: []string{"created_by"}, BatchSize: 2, ProgressEvery: 2},
+			want:         5,
+			wantProgress: []int64{2, 4, 5},
+		},
+		{
+			name:         "empty",
+			rows:         seqOf([]*bulkRow{}, nil),
+			opts:         LoadOptions{Table: "account", OmitColumns: []string{"created_by"}},
+			wantProgress: []int64{0},
+		},
+		{
+			name:    "source error rolls back",
+			rows:    seqOf([]*bulkRow{&rows[0], &rows[1], &rows[2]}, errIter),
+			opts:    LoadOptions{Table: "account", OmitColumns: []string{"created_by"}, BatchSize: 2},
+			wantErr: errIter,
+		},
+		{
+			name:     "nil row",
+			rows:     seqOf([]*bulkRow{nil}, nil),
+			opts:     LoadOptions{Table: "account", OmitColumns: []string{"created_by"}},
+			wantCode: codes.CodeSQLBuilder,
+		},
+		{
+			name:     "missing table",
+			rows:     seqOf([]*bulkRow{&rows[0]}, nil),
+			wantCode: codes.CodeSQLBuilder,
+		},
+		{
+			name:     "unknown column",
+			rows:     seqOf([]*bulkRow{&rows[0]}, nil),
+			opts:     LoadOptions{Table: "account", Columns: []string{"email"}},
+			wantCode: codes.CodeSQLBuilder,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestSqliteCommand(t)
+			var progress []int64
+			tt.opts.OnProgress = func(loaded int64) { progress = append(progress, loaded) }
+
+			got, err := Load(context.Background(), c, "loadAccount", tt.rows, tt.opts)
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantCode != 0:
+				assert.Equal(t, tt.wantCode, errors.GetCode(err))
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantProgress, progress)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, int(tt.want), countAccounts(t, c))
+		})
+	}
+}
+
+func TestLoad_FromIterate(t *testing.T) {
+	from := newTestSqliteCommand(t)
+	seedAccounts(t, from, "alice", "bob")
+	to := newTestSqliteCommand(t)
+
+	n, err := Load(context.Background(), to, "copyAccount", Iterate[testAccount](context.Background(), from, "iterateAccount", `SELECT id, name, balance FROM account`), LoadOptions{Table: "account"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	got, err := Select[testAccount](context.Background(), to, "selectAccount", `SELECT id, name, balance FROM account ORDER BY id`)
+	assert.NoError(t, err)
+	assert.Equal(t, []testAccount{{ID: 1, Name: "alice", Balance: 100}, {ID: 2, Name: "bob", Balance: 200}}, got)
+}
+
+func TestLoadCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		opts     LoadOptions
+		want     int64
+		wantRows []testNote
+		wantCode codes.Code
+	}{
+		{
+			name:     "header and nulls",
+			csv:      "id,body,extra\n1,hello,x\n2,,y\n",
+			opts:     LoadOptions{Table: "note", OmitColumns: []string{"extra"}},
+			want:     2,
+			wantRows: []testNote{{ID: 1, Body: strPtr("hello")}, {ID: 2}},
+		},
+		{
+			name:     "separator and column order",
+			csv:      "body;id\n\"a;b\";7\n",
+			opts:     LoadOptions{Table: "note", Columns: []string{"id", "body"}, CSV: parser.CsvOptions{Separator: ';'}},
+			want:     1,
+			wantRows: []testNote{{ID: 7, Body: strPtr("a;b")}},
+		},
+		{
+			name:     "empty input",
+			csv:      "",
+			opts:     LoadOptions{Table: "note"},
+			wantRows: []testNote{},
+		},
+		{
+			name:     "unknown column",
+			csv:      "id,body\n1,a\n",
+			opts:     LoadOptions{Table: "note", Columns: []string{"title"}},
+			wantRows: []testNote{},
+			wantCode: codes.CodeSQLBuilder,
+		},
+		{
+			name:     "malformed record",
+			csv:      "id,body\n1,a\n2\n",
+			opts:     LoadOptions{Table: "note"},
+			wantRows: []testNote{},
+			wantCode: codes.CodeSQLBuilder,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestSqliteCommand(t)
+			_, err := c.Exec(context.Background(), "createNote", `CREATE TABLE note (id INTEGER PRIMARY KEY, body TEXT)`)
+			require.NoError(t, err)
+
+			got, err := LoadCSV(context.Background(), c, "loadNote", strings.NewReader(tt.csv), tt.opts)
+			if tt.wantCode != 0 {
+				assert.Equal(t, tt.wantCode, errors.GetCode(err))
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+
+			rows, err := Select[testNote](context.Background(), c, "selectNote", `SELECT id, body FROM note ORDER BY id`)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantRows, rows)
+		})
+	}
+}
+
+type testNote struct {
+	ID   int64   `db:"id"`
+	Body *string `db:"body"`
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestLoad_Unsupported(t *testing.T) {
+	// a Command implemented outside this package, such as a mock
+	cmd := struct{ Command }{}
+	_, err := LoadCSV(context.Background(), cmd, "loadNote", strings.NewReader("id\n1\n"), LoadOptions{Table: "note"})
+	assert.Equal(t, codes.CodeSQLBuilder, errors.GetCode(err))
+}
+
+func Test_copyInQuery(t *testing.T) {
+	assert.Equal(t, `COPY "account" ("id", "name") FROM STDIN`, copyInQuery("account", []string{"id", "name"}))
+	assert.Equal(t, `COPY "billing"."account" ("id") FROM STDIN`, copyInQuery("billing.account", []string{"id"}))
+}
+
+func Test_loadDataQuery(t *testing.T) {
+	assert.Equal(t,
+		`LOAD DATA LOCAL INFILE 'Reader::h1' INTO TABLE account CHARACTER SET utf8mb4 FIELDS TERMINATED BY ',' ENCLOSED BY '"' ESCAPED BY '\\' LINES TERMINATED BY '\n' (id, name)`,
+		loadDataQuery("h1", "account", []string{"id", "name"}))
+}
+
+func Test_writeLoadRows(t *testing.T) {
+	at := time.Date(2024, 5, 6, 7, 8, 9, 500000000, time.UTC)
+	rows := [][]interface{}{
+		{int64(1), `say "hi"`, nil},
+		{2, `back\slash`, true},
+		{3.5, []byte("raw"), at},
+	}
+	src := &loadSource{next: func() ([]interface{}, error) {
+		if len(rows) == 0 {
+			return nil, io.EOF
+		}
+		row := rows[0]
+		rows = rows[1:]
+		return row, nil
+	}}
+
+	var buf bytes.Buffer
+	var progress []int64
+	err := writeLoadRows(&buf, src, &loadProgress{every: 2, fn: func(n int64) { progress = append(progress, n) }})
+	assert.NoError(t, err)
+	assert.Equal(t, "\"1\",\"say \\\"hi\\\"\",\\N\n\"2\",\"back\\\\slash\",\"1\"\n\"3.5\",\"raw\",\"2024-05-06 07:08:09.5\"\n", buf.String())
+	assert.Equal(t, []int64{2}, progress)
+}
+
+func TestLoad_Follower(t *testing.T) {
+	leader := newTestSqliteCommand(t)
+	f := newTestFollower(t, true)
+	s := &sqlDB{
+		leader:    leader,
+		followers: initFollowerPool([]*follower{f}, "sqlite3", "", 0, newMockLogger(t)),
+		cfg:       Config{ReadYourWrites: ReadYourWritesConfig{Enabled: true}},
+	}
+	s.reader = &consistentCommand{s: s}
+
+	rows := []bulkRow{{ID: 1, Name: "a", Balance: 1}, {ID: 2, Name: "b", Balance: 2}}
+	n, err := Load(context.Background(), s.Follower(), "loadAccount", seqOf(rows, nil), LoadOptions{Table: "account", OmitColumns: []string{"created_by"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, 2, countAccounts(t, leader), "loads go to the leader")
+	assert.Equal(t, 0, countAccounts(t, f.cmd.(*command)))
+}
+
+func Test_checkLoaded(t *testing.T) {
+	assert.NoError(t, checkLoaded("account", 3, 3))
+
+	err := checkLoaded("account", 2, 3)
+	assert.Equal(t, codes.CodeSQLTxExec, errors.GetCode(err))
+	assert.ErrorContains(t, err, "wrote 2 of 3 rows")
+}
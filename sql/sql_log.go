@@ -1,19 +1,348 @@
 package sql
 
 import (
+	"context"
+	"database/sql/driver"
 	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/logger"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
 )
 
 const (
-	queryLogMessage = "executing query: %s, with query string: %s"
+	queryLogMessage     = "executing query: %s, with query string: %s"
+	slowQueryLogMessage = "SQL: [SLOW QUERY] %s on %s %s took %s, with query string: %s"
+	redactedArg         = "[REDACTED]"
+
+	slowQueryExplainTimeout = 5 * time.Second
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	namedArgMapper = reflectx.NewMapperFunc("db", sqlx.NameMapper)
+
+	// column compared to the placeholder that follows, e.g. `u.password = ?`
+	// or `token IN (?, ?`
+	comparedColumnPattern = regexp.MustCompile(`(?i)([a-z_][a-z0-9_]*)["` + "`" + `]?\s*(?:=|<>|!=|<=|>=|<|>|\s(?:not\s+)?i?like|\s(?:not\s+)?in\s*\([^()]*)\s*$`)
+	// column list of an INSERT, whose VALUES tuples map onto it by position
+	insertColumnsPattern = regexp.MustCompile(`(?is)^\s*(?:insert|replace)\s+(?:ignore\s+)?into\s+\S+\s*\(([^)]*)\)\s*values\s*`)
 )
 
 // Replace query bindvars with args value
 func replaceBindvarsWithArgs(str string, args ...interface{}) string {
-	str = strings.Join(strings.Fields(str), " ")
-	for _, a := range args {
-		str = strings.Replace(str, "?", fmt.Sprintf("%v", a), 1)
+	return formatQuery(str, nil, args...)
+}
+
+// formatQuery collapses the whitespace of query and interpolates args into
+// its `?`, `$n` and `:name` bindvars. A named query takes a single struct or
+// map arg, as NamedExec does. Args bound to a column in redact are masked.
+// Bindvars inside quoted literals and bindvars without an arg are kept as is.
+func formatQuery(query string, redact map[string]bool, args ...interface{}) string {
+	query = strings.Join(strings.Fields(query), " ")
+
+	insertCols := []string{}
+	if len(redact) > 0 {
+		if match := insertColumnsPattern.FindStringSubmatch(query); match != nil {
+			for _, col := range strings.Split(match[1], ",") {
+				insertCols = append(insertCols, strings.ToLower(strings.Trim(strings.TrimSpace(col), "\"`")))
+			}
+		}
+	}
+
+	var (
+		out      strings.Builder
+		quote    byte
+		next     int // next positional arg
+		inserted int // placeholders mapped onto insertCols so far
+		scanned  int // out up to here was already searched for a column
+		inColumn string
+	)
+	// columnOf returns the column the next placeholder is bound to, searching
+	// only the text written since the previous one. The column of an IN list
+	// carries over to its next values.
+	columnOf := func() string {
+		seg := out.String()[scanned:]
+		if inColumn != "" && strings.TrimSpace(seg) == "," {
+			return inColumn
+		}
+		inColumn = ""
+		if match := comparedColumnPattern.FindStringSubmatch(seg); match != nil {
+			col := strings.ToLower(match[1])
+			if strings.Contains(match[0], "(") {
+				inColumn = col
+			}
+			return col
+		}
+		if len(insertCols) == 0 {
+			return ""
+		}
+		col := insertCols[inserted%len(insertCols)]
+		inserted++
+		return col
+	}
+	write := func(val interface{}, col func() string) {
+		if len(redact) == 0 {
+			out.WriteString(formatArg(val))
+			return
+		}
+		if redact[col()] {
+			out.WriteString(redactedArg)
+		} else {
+			out.WriteString(formatArg(val))
+		}
+		scanned = out.Len()
+	}
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			out.WriteByte(c)
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			out.WriteByte(c)
+		case c == '?':
+			if next >= len(args) {
+				out.WriteByte(c)
+				continue
+			}
+			write(args[next], columnOf)
+			next++
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			j := i + 1
+			for j < len(query) && isDigit(query[j]) {
+				j++
+			}
+			n, _ := strconv.Atoi(query[i+1 : j])
+			if n < 1 || n > len(args) {
+				out.WriteString(query[i:j])
+			} else {
+				write(args[n-1], columnOf)
+			}
+			i = j - 1
+		case c == ':' && i+1 < len(query) && isNameStart(query[i+1]) && (i == 0 || query[i-1] != ':'):
+			j := i + 1
+			for j < len(query) && isNameChar(query[j]) {
+				j++
+			}
+			name := query[i+1 : j]
+			if val, ok := namedArg(args, name); ok {
+				write(val, func() string { return strings.ToLower(name) })
+			} else {
+				out.WriteString(query[i:j])
+			}
+			i = j - 1
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	return out.String()
+}
+
+// isNamedArg reports whether args is the single struct or map arg of a named
+// query.
+func isNamedArg(args []interface{}) bool {
+	if len(args) != 1 {
+		return false
+	}
+	if _, ok := args[0].(driver.Valuer); ok {
+		return false
+	}
+	v := reflect.Indirect(reflect.ValueOf(args[0]))
+	return v.Kind() == reflect.Map || (v.Kind() == reflect.Struct && v.Type() != timeType)
+}
+
+// namedArg looks up name in the single struct or map arg of a named query.
+func namedArg(args []interface{}, name string) (interface{}, bool) {
+	if !isNamedArg(args) {
+		return nil, false
+	}
+	if m, ok := args[0].(map[string]interface{}); ok {
+		val, ok := m[name]
+		return val, ok
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(args[0]))
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	fi := namedArgMapper.TypeMap(v.Type()).GetByPath(name)
+	if fi == nil {
+		return nil, false
+	}
+	return reflectx.FieldByIndexesReadOnly(v, fi.Index).Interface(), true
+}
+
+func formatArg(arg interface{}) string {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		if val, err := valuer.Value(); err == nil {
+			arg = val
+		}
+	}
+
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return "NULL"
+	}
+	if b, ok := v.Interface().([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || isDigit(c) || c == '.'
+}
+
+// slowQueryLog warns about queries that run longer than the configured
+// threshold. It is shared by a command and the transactions and statements
+// created from it; a nil *slowQueryLog disables the slow query log.
+type slowQueryLog struct {
+	threshold time.Duration
+	explain   bool
+	redact    map[string]bool
+	db        *sqlx.DB
+	connName  string
+	connType  string
+	log       logger.Interface
+	// explaining holds a token while an EXPLAIN runs, so that a burst of
+	// slow queries cannot take over the pool
+	explaining chan struct{}
+	wg         sync.WaitGroup
+}
+
+func initSlowQueryLog(cfg Config, db *sqlx.DB, log logger.Interface, isLeader bool) *slowQueryLog {
+	if cfg.SlowQueryThreshold <= 0 {
+		return nil
+	}
+
+	s := &slowQueryLog{
+		threshold:  cfg.SlowQueryThreshold,
+		explain:    cfg.SlowQueryExplain,
+		redact:     map[string]bool{},
+		db:         db,
+		connName:   cfg.Name,
+		connType:   connTypeLeader,
+		log:        log,
+		explaining: make(chan struct{}, 1),
+	}
+	if !isLeader {
+		s.connType = connTypeFollower
+	}
+	for _, col := range cfg.SlowQueryRedactColumns {
+		s.redact[strings.ToLower(col)] = true
+	}
+	return s
+}
+
+// observe logs query when it started longer than the threshold ago. It is
+// meant to be deferred with the start time, e.g.
+//
+//	defer c.slowLog.observe(ctx, name, query, time.Now(), args...)
+func (s *slowQueryLog) observe(ctx context.Context, name, query string, start time.Time, args ...interface{}) {
+	if s == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	if elapsed <= s.threshold {
+		return
+	}
+
+	msg := fmt.Sprintf(slowQueryLogMessage, name, s.connName, s.connType, elapsed, formatQuery(query, s.redact, args...))
+	if !s.explain || query == "" {
+		s.log.Warn(ctx, msg)
+		return
+	}
+
+	select {
+	case s.explaining <- struct{}{}:
+	default:
+		s.log.Warn(ctx, msg+", query plan skipped: another EXPLAIN is running")
+		return
+	}
+
+	// the query's own context may be about to end, and a transaction's
+	// connection may still be busy with its rows, so the plan is fetched on a
+	// pooled connection in the background
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() { <-s.explaining }()
+
+		explainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), slowQueryExplainTimeout)
+		defer cancel()
+
+		plan, err := s.queryPlan(explainCtx, query, args...)
+		if err != nil {
+			s.log.Warn(ctx, fmt.Sprintf("%s, cannot explain query: %s", msg, err))
+			return
+		}
+		s.log.Warn(ctx, fmt.Sprintf("%s, with query plan:\n%s", msg, plan))
+	}()
+}
+
+// wait blocks until the background EXPLAINs are done.
+func (s *slowQueryLog) wait() {
+	if s != nil {
+		s.wg.Wait()
+	}
+}
+
+// queryPlan runs EXPLAIN on query and renders one line per plan row.
+func (s *slowQueryLog) queryPlan(ctx context.Context, query string, args ...interface{}) (string, error) {
+	if isNamedArg(args) {
+		q, a, err := sqlx.Named(query, args[0])
+		if err != nil {
+			return "", err
+		}
+		query, args = s.db.Rebind(q), a
+	}
+
+	explain := "EXPLAIN "
+	if s.db.DriverName() == "sqlite3" {
+		explain = "EXPLAIN QUERY PLAN "
+	}
+
+	rows, err := s.db.QueryxContext(ctx, explain+query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		cols, err := rows.SliceScan()
+		if err != nil {
+			return "", err
+		}
+		vals := make([]string, len(cols))
+		for i, col := range cols {
+			vals[i] = formatArg(col)
+		}
+		lines = append(lines, strings.Join(vals, " | "))
 	}
-	return str
+	return strings.Join(lines, "\n"), rows.Err()
 }
@@ -0,0 +1,215 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFormatQuery(t *testing.T) {
+	type user struct {
+		ID       int64  `db:"id"`
+		Name     string `db:"name"`
+		Password string `db:"password"`
+	}
+	name := "alice"
+	redact := map[string]bool{"password": true, "token": true}
+
+	tests := []struct {
+		name   string
+		query  string
+		redact map[string]bool
+		args   []interface{}
+		want   string
+	}{
+		{
+			name:  "question mark",
+			query: "SELECT *\n\tFROM account  WHERE id = ? AND name = ?",
+			args:  []interface{}{1, "alice"},
+			want:  "SELECT * FROM account WHERE id = 1 AND name = alice",
+		},
+		{
+			name:  "dollar",
+			query: "SELECT * FROM account WHERE name = $2 AND id = $1",
+			args:  []interface{}{1, "alice"},
+			want:  "SELECT * FROM account WHERE name = alice AND id = 1",
+		},
+		{
+			name:  "dollar above ten",
+			query: "SELECT $1, $10",
+			args:  []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			want:  "SELECT 1, 10",
+		},
+		{
+			name:  "named struct",
+			query: "UPDATE account SET name = :name WHERE id = :id",
+			args:  []interface{}{user{ID: 1, Name: "alice"}},
+			want:  "UPDATE account SET name = alice WHERE id = 1",
+		},
+		{
+			name:  "named map",
+			query: "SELECT * FROM account WHERE id = :id",
+			args:  []interface{}{map[string]interface{}{"id": 7}},
+			want:  "SELECT * FROM account WHERE id = 7",
+		},
+		{
+			name:  "cast and quoted literal are kept",
+			query: "SELECT '?', ':id', created_at::date FROM account WHERE id = $1",
+			args:  []interface{}{1},
+			want:  "SELECT '?', ':id', created_at::date FROM account WHERE id = 1",
+		},
+		{
+			name:  "missing args are kept",
+			query: "SELECT * FROM account WHERE id = ? AND name = $2 AND x = :x",
+			args:  []interface{}{1},
+			want:  "SELECT * FROM account WHERE id = 1 AND name = $2 AND x = :x",
+		},
+		{
+			name:  "nil, pointer and valuer",
+			query: "SELECT ?, ?, ?, ?",
+			args:  []interface{}{nil, &name, sql.NullString{}, []byte("raw")},
+			want:  "SELECT NULL, alice, NULL, raw",
+		},
+		{
+			name:   "redact compared column",
+			query:  "SELECT * FROM account a WHERE a.password = ? AND token IN (?, ?) AND name LIKE ?",
+			redact: redact,
+			args:   []interface{}{"secret", "t1", "t2", "al%"},
+			want:   "SELECT * FROM account a WHERE a.password = [REDACTED] AND token IN ([REDACTED], [REDACTED]) AND name LIKE al%",
+		},
+		{
+			name:   "redaction ends with its IN list",
+			query:  "SELECT * FROM account WHERE token NOT IN (?,?, ?) AND name = ? AND id IN (?, ?)",
+			redact: redact,
+			args:   []interface{}{"t1", "t2", "t3", "alice", 1, 2},
+			want:   "SELECT * FROM account WHERE token NOT IN ([REDACTED],[REDACTED], [REDACTED]) AND name = alice AND id IN (1, 2)",
+		},
+		{
+			name:   "redact insert column",
+			query:  `INSERT INTO account (name, "password") VALUES ($1, $2), ($3, $4)`,
+			redact: redact,
+			args:   []interface{}{"alice", "s1", "bob", "s2"},
+			want:   `INSERT INTO account (name, "password") VALUES (alice, [REDACTED]), (bob, [REDACTED])`,
+		},
+		{
+			name:   "redact named column",
+			query:  "INSERT INTO account (name, password) VALUES (:name, :password)",
+			redact: redact,
+			args:   []interface{}{user{Name: "alice", Password: "secret"}},
+			want:   "INSERT INTO account (name, password) VALUES (alice, [REDACTED])",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatQuery(tt.query, tt.redact, tt.args...))
+		})
+	}
+}
+
+// recordingLogger captures Warn messages.
+type recordingLogger struct {
+	*mock_log.MockInterface
+	mu    sync.Mutex
+	warns []string
+}
+
+func newRecordingLogger(t *testing.T) *recordingLogger {
+	t.Helper()
+	l := &recordingLogger{MockInterface: mock_log.NewMockInterface(gomock.NewController(t))}
+	l.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	l.EXPECT().Warn(gomock.Any(), gomock.Any()).Do(func(_ context.Context, obj any) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.warns = append(l.warns, obj.(string))
+	}).AnyTimes()
+	return l
+}
+
+func (l *recordingLogger) messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.warns...)
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       Config
+		run       func(c *command) error
+		wantLogs  int
+		wantParts []string
+	}{
+		{
+			name: "disabled",
+			cfg:  Config{Name: "testdb"},
+			run: func(c *command) error {
+				_, err := c.Exec(context.Background(), "insertAccount", `INSERT INTO account (name) VALUES (?)`, "alice")
+				return err
+			},
+		},
+		{
+			name: "under threshold",
+			cfg:  Config{Name: "testdb", SlowQueryThreshold: time.Hour},
+			run: func(c *command) error {
+				_, err := c.Exec(context.Background(), "insertAccount", `INSERT INTO account (name) VALUES (?)`, "alice")
+				return err
+			},
+		},
+		{
+			name: "slow query with redaction",
+			cfg:  Config{Name: "testdb", SlowQueryThreshold: time.Nanosecond, SlowQueryRedactColumns: []string{"NAME"}},
+			run: func(c *command) error {
+				_, err := c.Exec(context.Background(), "insertAccount", `INSERT INTO account (name, balance) VALUES (?, ?)`, "alice", 10)
+				return err
+			},
+			wantLogs:  1,
+			wantParts: []string{"[SLOW QUERY] insertAccount on testdb leader", "VALUES ([REDACTED], 10)"},
+		},
+		{
+			name: "slow query with plan",
+			cfg:  Config{Name: "testdb", SlowQueryThreshold: time.Nanosecond, SlowQueryExplain: true},
+			run: func(c *command) error {
+				var n int
+				return c.Get(context.Background(), "countAccount", `SELECT COUNT(*) FROM account WHERE name = ?`, &n, "alice")
+			},
+			wantLogs:  1,
+			wantParts: []string{"[SLOW QUERY] countAccount", "name = alice", "with query plan:", "SCAN account"},
+		},
+		{
+			name: "slow query in transaction",
+			cfg:  Config{Name: "testdb", SlowQueryThreshold: time.Nanosecond},
+			run: func(c *command) error {
+				return WithTx(context.Background(), c, "tx", TxOptions{}, func(tx CommandTx) error {
+					_, err := tx.NamedExec("insertAccount", `INSERT INTO account (name) VALUES (:name)`, map[string]interface{}{"name": "bob"})
+					return err
+				})
+			},
+			wantLogs:  1,
+			wantParts: []string{"[SLOW QUERY] insertAccount", "VALUES (bob)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := newRecordingLogger(t)
+			db := newTestSqliteDB(t)
+			slowLog := initSlowQueryLog(tt.cfg, db, log, true)
+			c := initCommand(db, "testdb", nil, log, true, false, false, slowLog, nil).(*command)
+
+			assert.NoError(t, tt.run(c))
+			slowLog.wait()
+
+			msgs := log.messages()
+			assert.Len(t, msgs, tt.wantLogs)
+			for _, part := range tt.wantParts {
+				assert.True(t, strings.Contains(strings.Join(msgs, "\n"), part), "missing %q in %q", part, msgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+)
+
+// Execer is the write surface used by ExecOptimistic. Command satisfies it;
+// wrap a CommandTx with TxExecer.
+type Execer interface {
+	Exec(ctx context.Context, name string, query string, args ...interface{}) (sql.Result, error)
+}
+
+type txExecer struct {
+	tx CommandTx
+}
+
+// TxExecer adapts tx to Execer. The per-call context is honoured when tx
+// implements CommandTxContext, which every transaction from BeginTx does.
+func TxExecer(tx CommandTx) Execer {
+	return &txExecer{tx: tx}
+}
+
+func (e *txExecer) Exec(ctx context.Context, name string, query string, args ...interface{}) (sql.Result, error) {
+	if tx, ok := e.tx.(CommandTxContext); ok {
+		return tx.ExecContext(ctx, name, query, args...)
+	}
+	return e.tx.Exec(name, query, args...)
+}
+
+// ExecOptimistic runs a guarded update, such as the version-locked one
+// query.BuildUpdate writes, and fails with codes.CodeConflict when it
+// affects no row: the row was changed or removed since it was read.
+func ExecOptimistic(ctx context.Context, e Execer, name string, query string, args ...interface{}) (sql.Result, error) {
+	res, err := e.Exec(ctx, name, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, errors.WrapWithCode(err, codes.CodeSQLNoRowsAffected, "%s: rows affected", name)
+	}
+	if n == 0 {
+		return nil, errors.NewWithCode(codes.CodeConflict, "%s: the row was changed or removed since it was read", name)
+	}
+	return res, nil
+}
@@ -0,0 +1,48 @@
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecOptimistic(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	seedAccounts(t, c, "alice")
+	const update = `UPDATE account SET balance=? WHERE id=? AND balance=?`
+
+	res, err := ExecOptimistic(context.Background(), c, "updateAccount", update, 150, 1, 100)
+	require.NoError(t, err)
+	n, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	// the balance read before the first update is stale now
+	_, err = ExecOptimistic(context.Background(), c, "updateAccount", update, 200, 1, 100)
+	assert.Equal(t, codes.CodeConflict, errors.GetCode(err))
+
+	_, err = ExecOptimistic(context.Background(), c, "updateAccount", `UPDATE account SET missing=1`)
+	assert.Error(t, err)
+	assert.NotEqual(t, codes.CodeConflict, errors.GetCode(err))
+}
+
+func TestExecOptimistic_Tx(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	seedAccounts(t, c, "alice")
+
+	err := WithTx(context.Background(), c, "tx", TxOptions{}, func(tx CommandTx) error {
+		_, err := ExecOptimistic(context.Background(), TxExecer(tx), "updateAccount", `UPDATE account SET balance=? WHERE id=?`, 150, 2)
+		return err
+	})
+	assert.Equal(t, codes.CodeConflict, errors.GetCode(err))
+
+	err = WithTx(context.Background(), c, "tx", TxOptions{}, func(tx CommandTx) error {
+		_, err := ExecOptimistic(context.Background(), TxExecer(tx), "updateAccount", `UPDATE account SET balance=? WHERE id=?`, 150, 1)
+		return err
+	})
+	assert.NoError(t, err)
+}
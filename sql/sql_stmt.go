@@ -3,8 +3,11 @@ package sql
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	"github.com/downsized-devs/sdk-go/instrument"
+	"github.com/downsized-devs/sdk-go/logger"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -17,25 +20,48 @@ type CommandStmt interface {
 	Exec(name string, args ...interface{}) (sql.Result, error)
 }
 
+// CommandStmtContext extends CommandStmt with variants that take a per-call
+// context instead of the one captured by Prepare. Statements returned by
+// Prepare and CommandTx.Stmt implement it.
+type CommandStmtContext interface {
+	CommandStmt
+	SelectContext(ctx context.Context, name string, dest interface{}, args ...interface{}) error
+	GetContext(ctx context.Context, name string, dest interface{}, args ...interface{}) error
+	QueryRowContext(ctx context.Context, name string, args ...interface{}) (*sqlx.Row, error)
+	QueryContext(ctx context.Context, name string, args ...interface{}) (*sqlx.Rows, error)
+	ExecContext(ctx context.Context, name string, args ...interface{}) (sql.Result, error)
+}
+
 type commandStmt struct {
 	ctx           context.Context
 	name          string
 	connName      string
 	connType      string
+	query         string
 	stmt          *sqlx.Stmt
+	log           logger.Interface
 	instrument    instrument.Interface
 	useInstrument bool
+	logQuery      bool
+	slowLog       *slowQueryLog
+	// markWrites records a successful Exec as a leader write, for statements
+	// prepared outside a transaction, whose Commit records it instead.
+	markWrites bool
 }
 
-func initStmt(ctx context.Context, name, connName string, stmt *sqlx.Stmt, instr instrument.Interface, isLeader, useInstr bool) CommandStmt {
+func initStmt(ctx context.Context, name, connName, query string, stmt *sqlx.Stmt, log logger.Interface, instr instrument.Interface, isLeader, useInstr, logQuery bool, slowLog *slowQueryLog) *commandStmt {
 	c := &commandStmt{
 		ctx:           ctx,
 		name:          name,
 		connName:      connName,
 		connType:      connTypeLeader,
+		query:         query,
 		stmt:          stmt,
+		log:           log,
 		instrument:    instr,
 		useInstrument: useInstr,
+		logQuery:      logQuery,
+		slowLog:       slowLog,
 	}
 
 	if !isLeader {
@@ -50,41 +76,84 @@ func (x *commandStmt) Close() error {
 }
 
 func (x *commandStmt) Select(name string, dest interface{}, args ...interface{}) error {
+	return x.SelectContext(x.ctx, name, dest, args...)
+}
+
+func (x *commandStmt) SelectContext(ctx context.Context, name string, dest interface{}, args ...interface{}) error {
 	if x.useInstrument {
 		timer := x.instrument.DatabaseQueryTimer(x.connName, x.connType, name)
 		defer timer.ObserveDuration()
 	}
-	return x.stmt.SelectContext(x.ctx, dest, args...)
+	defer x.slowLog.observe(ctx, name, x.query, time.Now(), args...)
+	x.logStmt(ctx, name, args...)
+	return x.stmt.SelectContext(ctx, dest, args...)
 }
 
 func (x *commandStmt) Get(name string, dest interface{}, args ...interface{}) error {
+	return x.GetContext(x.ctx, name, dest, args...)
+}
+
+func (x *commandStmt) GetContext(ctx context.Context, name string, dest interface{}, args ...interface{}) error {
 	if x.useInstrument {
 		timer := x.instrument.DatabaseQueryTimer(x.connName, x.connType, name)
 		defer timer.ObserveDuration()
 	}
-	return x.stmt.GetContext(x.ctx, dest, args...)
+	defer x.slowLog.observe(ctx, name, x.query, time.Now(), args...)
+	x.logStmt(ctx, name, args...)
+	return x.stmt.GetContext(ctx, dest, args...)
 }
 
 func (x *commandStmt) QueryRow(name string, args ...interface{}) (*sqlx.Row, error) {
+	return x.QueryRowContext(x.ctx, name, args...)
+}
+
+func (x *commandStmt) QueryRowContext(ctx context.Context, name string, args ...interface{}) (*sqlx.Row, error) {
 	if x.useInstrument {
 		timer := x.instrument.DatabaseQueryTimer(x.connName, x.connType, name)
 		defer timer.ObserveDuration()
 	}
-	return x.stmt.QueryRowxContext(x.ctx, args...), nil
+	defer x.slowLog.observe(ctx, name, x.query, time.Now(), args...)
+	x.logStmt(ctx, name, args...)
+	return x.stmt.QueryRowxContext(ctx, args...), nil
 }
 
 func (x *commandStmt) Query(name string, args ...interface{}) (*sqlx.Rows, error) {
+	return x.QueryContext(x.ctx, name, args...)
+}
+
+func (x *commandStmt) QueryContext(ctx context.Context, name string, args ...interface{}) (*sqlx.Rows, error) {
 	if x.useInstrument {
 		timer := x.instrument.DatabaseQueryTimer(x.connName, x.connType, name)
 		defer timer.ObserveDuration()
 	}
-	return x.stmt.QueryxContext(x.ctx, args...)
+	defer x.slowLog.observe(ctx, name, x.query, time.Now(), args...)
+	x.logStmt(ctx, name, args...)
+	return x.stmt.QueryxContext(ctx, args...)
 }
 
 func (x *commandStmt) Exec(name string, args ...interface{}) (sql.Result, error) {
+	return x.ExecContext(x.ctx, name, args...)
+}
+
+func (x *commandStmt) ExecContext(ctx context.Context, name string, args ...interface{}) (sql.Result, error) {
 	if x.useInstrument {
 		timer := x.instrument.DatabaseQueryTimer(x.connName, x.connType, name)
 		defer timer.ObserveDuration()
 	}
-	return x.stmt.ExecContext(x.ctx, args...)
+	defer x.slowLog.observe(ctx, name, x.query, time.Now(), args...)
+	x.logStmt(ctx, name, args...)
+	res, err := x.stmt.ExecContext(ctx, args...)
+	if err == nil && x.markWrites {
+		markLeaderWrite(ctx)
+	}
+	return res, err
+}
+
+// logStmt logs the prepared query with its args. Statements wrapped through
+// CommandTx.Stmt carry no query string and are not logged.
+func (x *commandStmt) logStmt(ctx context.Context, name string, args ...interface{}) {
+	if !x.logQuery || x.query == "" {
+		return
+	}
+	x.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(x.query, args...)))
 }
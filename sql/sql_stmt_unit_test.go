@@ -0,0 +1,48 @@
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandStmtContext(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	tx, err := c.BeginTx(context.Background(), "txStmt", TxOptions{})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	stmt, err := tx.(CommandTxContext).PrepareContext(context.Background(), "prepare", `INSERT INTO account (name) VALUES (?)`)
+	require.NoError(t, err)
+	defer stmt.Close()
+
+	stmtCtx, ok := stmt.(CommandStmtContext)
+	require.True(t, ok, "Prepare result should implement CommandStmtContext")
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = stmtCtx.ExecContext(canceled, "insert", "alice")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = stmtCtx.ExecContext(context.Background(), "insert", "bob")
+	assert.NoError(t, err)
+
+	sel, err := tx.Prepare("prepareSelect", `SELECT name FROM account WHERE name = ?`)
+	require.NoError(t, err)
+	defer sel.Close()
+
+	var names []string
+	assert.ErrorIs(t, sel.(CommandStmtContext).SelectContext(canceled, "select", &names, "bob"), context.Canceled)
+	assert.NoError(t, sel.(CommandStmtContext).SelectContext(context.Background(), "select", &names, "bob"))
+	assert.Equal(t, []string{"bob"}, names)
+
+	var name string
+	assert.NoError(t, sel.(CommandStmtContext).GetContext(context.Background(), "get", &name, "bob"))
+	assert.Equal(t, "bob", name)
+
+	_, err = sel.(CommandStmtContext).QueryContext(canceled, "query", "bob")
+	assert.ErrorIs(t, err, context.Canceled)
+}
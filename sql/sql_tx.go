@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	goerr "errors"
 	"fmt"
+	"time"
 
 	"github.com/downsized-devs/sdk-go/instrument"
 	"github.com/downsized-devs/sdk-go/logger"
@@ -26,29 +27,50 @@ type CommandTx interface {
 	Stmt(name string, stmt *sqlx.Stmt) CommandStmt
 }
 
+// CommandTxContext extends CommandTx with variants that take a per-query
+// context instead of the one captured by BeginTx, so deadlines, cancellation
+// and request-scoped log fields apply to each statement. Transactions returned
+// by Command.BeginTx implement it.
+type CommandTxContext interface {
+	CommandTx
+	SelectContext(ctx context.Context, name string, query string, dest interface{}, args ...interface{}) error
+	GetContext(ctx context.Context, name string, query string, dest interface{}, args ...interface{}) error
+	QueryRowContext(ctx context.Context, name string, query string, args ...interface{}) (*sqlx.Row, error)
+	QueryContext(ctx context.Context, name string, query string, args ...interface{}) (*sqlx.Rows, error)
+	PrepareContext(ctx context.Context, name string, query string) (CommandStmt, error)
+
+	NamedExecContext(ctx context.Context, name string, query string, args interface{}) (sql.Result, error)
+	ExecContext(ctx context.Context, name string, query string, args ...interface{}) (sql.Result, error)
+	StmtContext(ctx context.Context, name string, stmt *sqlx.Stmt) CommandStmt
+}
+
 type commandTx struct {
 	ctx           context.Context
 	name          string
 	connName      string
 	connType      string
 	tx            *sqlx.Tx
+	readOnly      bool
 	log           logger.Interface
 	instrument    instrument.Interface
 	useInstrument bool
 	logQuery      bool
+	slowLog       *slowQueryLog
 }
 
-func initTx(ctx context.Context, name, connName string, tx *sqlx.Tx, opts *sql.TxOptions, log logger.Interface, instr instrument.Interface, isLeader, useInstr, logQuery bool) CommandTx {
+func initTx(ctx context.Context, name, connName string, tx *sqlx.Tx, opts *sql.TxOptions, log logger.Interface, instr instrument.Interface, isLeader, useInstr, logQuery bool, slowLog *slowQueryLog) CommandTx {
 	c := &commandTx{
 		ctx:           ctx,
 		name:          name,
 		connName:      connName,
 		connType:      connTypeLeader,
 		tx:            tx,
+		readOnly:      opts.ReadOnly,
 		log:           log,
 		instrument:    instr,
 		useInstrument: useInstr,
 		logQuery:      logQuery,
+		slowLog:       slowLog,
 	}
 
 	if !isLeader {
@@ -58,8 +80,18 @@ func initTx(ctx context.Context, name, connName string, tx *sqlx.Tx, opts *sql.T
 	return c
 }
 
+func (x *commandTx) driverName() string {
+	return x.tx.DriverName()
+}
+
 func (x *commandTx) Commit() error {
-	return x.tx.Commit()
+	if err := x.tx.Commit(); err != nil {
+		return err
+	}
+	if x.connType == connTypeLeader && !x.readOnly {
+		markLeaderWrite(x.ctx)
+	}
+	return nil
 }
 
 // Rollback needs to be called with defer right after calling BeginTx.
@@ -75,88 +107,126 @@ func (x *commandTx) Rebind(query string) string {
 }
 
 func (x *commandTx) Select(name string, query string, dest interface{}, args ...interface{}) error {
+	return x.SelectContext(x.ctx, name, query, dest, args...)
+}
+
+func (x *commandTx) SelectContext(ctx context.Context, name string, query string, dest interface{}, args ...interface{}) error {
 	if x.useInstrument {
 		timer := x.instrument.DatabaseQueryTimer(x.connName, x.connType, name)
 		defer timer.ObserveDuration()
 	}
+	defer x.slowLog.observe(ctx, name, query, time.Now(), args...)
 	if x.logQuery {
-		x.log.Info(x.ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args...)))
+		x.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args...)))
 	}
-	return x.tx.SelectContext(x.ctx, dest, query, args...)
+	return x.tx.SelectContext(ctx, dest, query, args...)
 }
 
 func (x *commandTx) Get(name string, query string, dest interface{}, args ...interface{}) error {
+	return x.GetContext(x.ctx, name, query, dest, args...)
+}
+
+func (x *commandTx) GetContext(ctx context.Context, name string, query string, dest interface{}, args ...interface{}) error {
 	if x.useInstrument {
 		timer := x.instrument.DatabaseQueryTimer(x.connName, x.connType, name)
 		defer timer.ObserveDuration()
 	}
+	defer x.slowLog.observe(ctx, name, query, time.Now(), args...)
 	if x.logQuery {
-		x.log.Info(x.ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args...)))
+		x.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args...)))
 	}
-	return x.tx.GetContext(x.ctx, dest, query, args...)
+	return x.tx.GetContext(ctx, dest, query, args...)
 }
 
 func (x *commandTx) QueryRow(name string, query string, args ...interface{}) (*sqlx.Row, error) {
+	return x.QueryRowContext(x.ctx, name, query, args...)
+}
+
+func (x *commandTx) QueryRowContext(ctx context.Context, name string, query string, args ...interface{}) (*sqlx.Row, error) {
 	if x.useInstrument {
 		timer := x.instrument.DatabaseQueryTimer(x.connName, x.connType, name)
 		defer timer.ObserveDuration()
 	}
+	defer x.slowLog.observe(ctx, name, query, time.Now(), args...)
 	if x.logQuery {
-		x.log.Info(x.ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args...)))
+		x.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args...)))
 	}
-	row := x.tx.QueryRowxContext(x.ctx, query, args...)
+	row := x.tx.QueryRowxContext(ctx, query, args...)
 	return row, row.Err()
 }
 
 func (x *commandTx) Query(name string, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return x.QueryContext(x.ctx, name, query, args...)
+}
+
+func (x *commandTx) QueryContext(ctx context.Context, name string, query string, args ...interface{}) (*sqlx.Rows, error) {
 	if x.useInstrument {
 		timer := x.instrument.DatabaseQueryTimer(x.connName, x.connType, name)
 		defer timer.ObserveDuration()
 	}
+	defer x.slowLog.observe(ctx, name, query, time.Now(), args...)
 	if x.logQuery {
-		x.log.Info(x.ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args...)))
+		x.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args...)))
 	}
-	return x.tx.QueryxContext(x.ctx, query, args...)
+	return x.tx.QueryxContext(ctx, query, args...)
 }
 
 func (x *commandTx) Prepare(name string, query string) (CommandStmt, error) {
+	return x.PrepareContext(x.ctx, name, query)
+}
+
+func (x *commandTx) PrepareContext(ctx context.Context, name string, query string) (CommandStmt, error) {
 	if x.useInstrument {
 		timer := x.instrument.DatabaseQueryTimer(x.connName, x.connType, name)
 		defer timer.ObserveDuration()
 	}
-	stmt, err := x.tx.PreparexContext(x.ctx, query)
+	stmt, err := x.tx.PreparexContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
-	return initStmt(x.ctx, name, x.connName, stmt, x.instrument, x.connType == connTypeLeader, x.useInstrument), nil
+	return initStmt(ctx, name, x.connName, query, stmt, x.log, x.instrument, x.connType == connTypeLeader, x.useInstrument, x.logQuery, x.slowLog), nil
 }
 
 func (x *commandTx) NamedExec(name string, query string, args interface{}) (sql.Result, error) {
+	return x.NamedExecContext(x.ctx, name, query, args)
+}
+
+func (x *commandTx) NamedExecContext(ctx context.Context, name string, query string, args interface{}) (sql.Result, error) {
 	if x.useInstrument {
 		timer := x.instrument.DatabaseQueryTimer(x.connName, x.connType, name)
 		defer timer.ObserveDuration()
 	}
+	defer x.slowLog.observe(ctx, name, query, time.Now(), args)
 	if x.logQuery {
-		x.log.Info(x.ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query)))
+		x.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args)))
 	}
-	return x.tx.NamedExecContext(x.ctx, query, args)
+	return x.tx.NamedExecContext(ctx, query, args)
 }
 
 func (x *commandTx) Exec(name string, query string, args ...interface{}) (sql.Result, error) {
+	return x.ExecContext(x.ctx, name, query, args...)
+}
+
+func (x *commandTx) ExecContext(ctx context.Context, name string, query string, args ...interface{}) (sql.Result, error) {
 	if x.useInstrument {
 		timer := x.instrument.DatabaseQueryTimer(x.connName, x.connType, name)
 		defer timer.ObserveDuration()
 	}
+	defer x.slowLog.observe(ctx, name, query, time.Now(), args...)
 	if x.logQuery {
-		x.log.Info(x.ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args...)))
+		x.log.Info(ctx, fmt.Sprintf(queryLogMessage, name, replaceBindvarsWithArgs(query, args...)))
 	}
-	return x.tx.ExecContext(x.ctx, query, args...)
+	return x.tx.ExecContext(ctx, query, args...)
 }
 
 func (x *commandTx) Stmt(name string, stmt *sqlx.Stmt) CommandStmt {
+	return x.StmtContext(x.ctx, name, stmt)
+}
+
+func (x *commandTx) StmtContext(ctx context.Context, name string, stmt *sqlx.Stmt) CommandStmt {
 	if x.useInstrument {
 		timer := x.instrument.DatabaseQueryTimer(x.connName, x.connType, name)
 		defer timer.ObserveDuration()
 	}
-	return initStmt(x.ctx, name, x.connName, stmt, x.instrument, x.connType == connTypeLeader, x.useInstrument)
+	return initStmt(ctx, name, x.connName, "", stmt, x.log, x.instrument, x.connType == connTypeLeader, x.useInstrument, x.logQuery, x.slowLog)
 }
@@ -0,0 +1,144 @@
+package sql
+
+import (
+	"context"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/downsized-devs/sdk-go/instrument"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultTxMaxRetries   = 3
+	defaultTxRetryBackoff = 50 * time.Millisecond
+
+	txOutcomeCommit   = "commit"
+	txOutcomeRollback = "rollback"
+	txOutcomeRetry    = "retry"
+)
+
+var (
+	// Postgres SQLSTATE codes that are safe to retry as a whole transaction.
+	retryablePostgresCodes = map[pq.ErrorCode]bool{
+		"40001": true, // serialization_failure
+		"40P01": true, // deadlock_detected
+	}
+	// MySQL error numbers that are safe to retry as a whole transaction.
+	retryableMysqlNumbers = map[uint16]bool{
+		1213: true, // ER_LOCK_DEADLOCK
+	}
+)
+
+// txRecorder is implemented by commands that can report transaction outcomes.
+type txRecorder interface {
+	recordTx(name, outcome string)
+}
+
+// commandRouter is implemented by commands that send every call to another
+// command picked from its context, such as Follower() with read-your-writes.
+type commandRouter interface {
+	routeTx(ctx context.Context, opts TxOptions) Command
+}
+
+// WithTx runs fn inside a transaction started on cmd. The transaction is
+// committed when fn returns nil and rolled back when fn returns an error or
+// panics; a panic is re-raised after the rollback. When the driver reports a
+// serialization failure or deadlock, the whole transaction is re-run up to
+// opts.MaxRetries times with exponential backoff, so fn must be safe to call
+// more than once.
+func WithTx(ctx context.Context, cmd Command, name string, opts TxOptions, fn func(tx CommandTx) error) error {
+	maxRetries := opts.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultTxMaxRetries
+	} else if maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	backoff := opts.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultTxRetryBackoff
+	}
+
+	// the transaction and its metrics go to the command it is routed to
+	if r, ok := cmd.(commandRouter); ok {
+		cmd = r.routeTx(ctx, opts)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := runTx(ctx, cmd, name, opts, fn)
+		if err == nil || attempt >= maxRetries || !isRetryableTxError(err) {
+			return err
+		}
+
+		recordTx(cmd, name, txOutcomeRetry)
+		select {
+		case <-ctx.Done():
+			return errors.WrapWithCode(ctx.Err(), codes.CodeContextCanceled, "transaction %s aborted while waiting to retry", name)
+		case <-time.After(backoff << attempt):
+		}
+	}
+}
+
+func runTx(ctx context.Context, cmd Command, name string, opts TxOptions, fn func(tx CommandTx) error) (err error) {
+	tx, err := cmd.BeginTx(ctx, name, opts)
+	if err != nil {
+		return errors.WrapWithCode(err, codes.CodeSQLTxBegin, "%s", err.Error())
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			recordTx(cmd, name, txOutcomeRollback)
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		recordTx(cmd, name, txOutcomeRollback)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		recordTx(cmd, name, txOutcomeRollback)
+		return errors.WrapWithCode(err, codes.CodeSQLTxCommit, "%s", err.Error())
+	}
+
+	recordTx(cmd, name, txOutcomeCommit)
+	return nil
+}
+
+func recordTx(cmd Command, name, outcome string) {
+	if r, ok := cmd.(txRecorder); ok {
+		r.recordTx(name, outcome)
+	}
+}
+
+// isRetryableTxError reports whether err carries a driver error that signals
+// the transaction lost a race and can be re-run from the start.
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return retryablePostgresCodes[pqErr.Code]
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return retryableMysqlNumbers[mysqlErr.Number]
+	}
+
+	return false
+}
+
+func (c *command) recordTx(name, outcome string) {
+	if !c.useInstrument {
+		return
+	}
+	if instr, ok := c.instrument.(instrument.InterfaceV2); ok {
+		instr.DatabaseTxCounter(c.connName, c.connType, name, outcome)
+	}
+}
@@ -0,0 +1,175 @@
+package sql
+
+import (
+	"context"
+	goerr "errors"
+	"testing"
+	"time"
+
+	"github.com/downsized-devs/sdk-go/codes"
+	"github.com/downsized-devs/sdk-go/errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func countAccounts(t *testing.T, c *command) int {
+	t.Helper()
+	var n int
+	assert.NoError(t, c.Get(context.Background(), "count", `SELECT COUNT(*) FROM account`, &n))
+	return n
+}
+
+func TestWithTx(t *testing.T) {
+	errFn := goerr.New("fn failed")
+
+	tests := []struct {
+		name      string
+		opts      TxOptions
+		fn        func(calls *int) func(tx CommandTx) error
+		wantErr   bool
+		wantCalls int
+		wantRows  int
+	}{
+		{
+			name: "commit on nil",
+			fn: func(calls *int) func(tx CommandTx) error {
+				return func(tx CommandTx) error {
+					*calls++
+					_, err := tx.Exec("insert", `INSERT INTO account (name) VALUES (?)`, "alice")
+					return err
+				}
+			},
+			wantCalls: 1,
+			wantRows:  1,
+		},
+		{
+			name: "rollback on error",
+			fn: func(calls *int) func(tx CommandTx) error {
+				return func(tx CommandTx) error {
+					*calls++
+					if _, err := tx.Exec("insert", `INSERT INTO account (name) VALUES (?)`, "alice"); err != nil {
+						return err
+					}
+					return errFn
+				}
+			},
+			wantErr:   true,
+			wantCalls: 1,
+			wantRows:  0,
+		},
+		{
+			name: "retry on postgres serialization failure",
+			opts: TxOptions{RetryBackoff: time.Nanosecond},
+			fn: func(calls *int) func(tx CommandTx) error {
+				return func(tx CommandTx) error {
+					*calls++
+					if _, err := tx.Exec("insert", `INSERT INTO account (name) VALUES (?)`, "alice"); err != nil {
+						return err
+					}
+					if *calls < 3 {
+						return &pq.Error{Code: "40001"}
+					}
+					return nil
+				}
+			},
+			wantCalls: 3,
+			wantRows:  1,
+		},
+		{
+			name: "give up after max retries on mysql deadlock",
+			opts: TxOptions{MaxRetries: 2, RetryBackoff: time.Nanosecond},
+			fn: func(calls *int) func(tx CommandTx) error {
+				return func(tx CommandTx) error {
+					*calls++
+					return errors.WrapWithCode(&mysql.MySQLError{Number: 1213}, codes.CodeSQLTxExec, "deadlock")
+				}
+			},
+			wantErr:   true,
+			wantCalls: 3,
+		},
+		{
+			name: "no retry when disabled",
+			opts: TxOptions{MaxRetries: -1},
+			fn: func(calls *int) func(tx CommandTx) error {
+				return func(tx CommandTx) error {
+					*calls++
+					return &pq.Error{Code: "40P01"}
+				}
+			},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name: "no retry on non retryable driver error",
+			fn: func(calls *int) func(tx CommandTx) error {
+				return func(tx CommandTx) error {
+					*calls++
+					return &pq.Error{Code: "23505"}
+				}
+			},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestSqliteCommand(t)
+			calls := 0
+			err := WithTx(context.Background(), c, "txTest", tt.opts, tt.fn(&calls))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WithTx() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.wantRows, countAccounts(t, c))
+		})
+	}
+}
+
+func TestWithTx_Panic(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = WithTx(context.Background(), c, "txPanic", TxOptions{}, func(tx CommandTx) error {
+			if _, err := tx.Exec("insert", `INSERT INTO account (name) VALUES (?)`, "alice"); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	})
+	assert.Equal(t, 0, countAccounts(t, c))
+}
+
+func TestWithTx_ContextCanceledWhileWaiting(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	err := WithTx(ctx, c, "txCancel", TxOptions{RetryBackoff: time.Hour}, func(tx CommandTx) error {
+		cancel()
+		return &pq.Error{Code: "40001"}
+	})
+	assert.Equal(t, codes.CodeContextCanceled, errors.GetCode(err))
+}
+
+func TestWithTx_Metrics(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	instr := newMockInstrument(t)
+	c.instrument, c.useInstrument = instr, true
+
+	instr.EXPECT().DatabaseQueryTimer("testdb", connTypeLeader, gomock.Any()).Return(prometheus.NewTimer(prometheus.ObserverFunc(func(float64) {}))).AnyTimes()
+	gomock.InOrder(
+		instr.EXPECT().DatabaseTxCounter("testdb", connTypeLeader, "txMetrics", txOutcomeRollback),
+		instr.EXPECT().DatabaseTxCounter("testdb", connTypeLeader, "txMetrics", txOutcomeRetry),
+		instr.EXPECT().DatabaseTxCounter("testdb", connTypeLeader, "txMetrics", txOutcomeCommit),
+	)
+
+	calls := 0
+	err := WithTx(context.Background(), c, "txMetrics", TxOptions{RetryBackoff: time.Nanosecond}, func(tx CommandTx) error {
+		calls++
+		if calls == 1 {
+			return &mysql.MySQLError{Number: 1213}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
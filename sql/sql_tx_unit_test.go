@@ -0,0 +1,64 @@
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/downsized-devs/sdk-go/appcontext"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCommandTxContext_UsesPerQueryContext(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	tx, err := c.BeginTx(context.Background(), "txCtx", TxOptions{})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	txCtx, ok := tx.(CommandTxContext)
+	require.True(t, ok, "BeginTx result should implement CommandTxContext")
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = txCtx.ExecContext(canceled, "insert", `INSERT INTO account (name) VALUES (?)`, "alice")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = txCtx.ExecContext(context.Background(), "insert", `INSERT INTO account (name) VALUES (?)`, "bob")
+	assert.NoError(t, err)
+
+	var names []string
+	assert.NoError(t, txCtx.SelectContext(context.Background(), "select", `SELECT name FROM account`, &names))
+	assert.Equal(t, []string{"bob"}, names)
+
+	var name string
+	assert.ErrorIs(t, txCtx.GetContext(canceled, "get", `SELECT name FROM account`, &name), context.Canceled)
+	assert.NoError(t, txCtx.GetContext(context.Background(), "get", `SELECT name FROM account`, &name))
+	assert.Equal(t, "bob", name)
+
+	_, err = txCtx.QueryContext(canceled, "query", `SELECT name FROM account`)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = txCtx.NamedExecContext(canceled, "named", `INSERT INTO account (name) VALUES (:name)`, map[string]interface{}{"name": "carol"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCommandTxContext_LogsRequestID(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	log := mock_log.NewMockInterface(gomock.NewController(t))
+	c.log, c.logQuery = log, true
+
+	tx, err := c.BeginTx(context.Background(), "txLog", TxOptions{})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	ctx := appcontext.SetRequestId(context.Background(), "req-123")
+	log.EXPECT().Info(gomock.Cond(func(x any) bool {
+		return appcontext.GetRequestId(x.(context.Context)) == "req-123"
+	}), gomock.Any()).Times(1)
+
+	_, err = tx.(CommandTxContext).ExecContext(ctx, "insert", `INSERT INTO account (name) VALUES (?)`, "alice")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,107 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	mock_instrument "github.com/downsized-devs/sdk-go/tests/mock/instrument"
+	mock_log "github.com/downsized-devs/sdk-go/tests/mock/logger"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// Unit-test counterpart to the build-tagged sql tests, which need live MySQL
+// and Postgres servers. These run against an in-memory modernc sqlite
+// database so the default `go test` run still exercises the command layer.
+
+func newMockLogger(t *testing.T) *mock_log.MockInterface {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	log := mock_log.NewMockInterface(ctrl)
+	log.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Warn(gomock.Any(), gomock.Any()).AnyTimes()
+	log.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+	return log
+}
+
+func newMockInstrument(t *testing.T) *mock_instrument.MockInterfaceV2 {
+	t.Helper()
+	return mock_instrument.NewMockInterfaceV2(gomock.NewController(t))
+}
+
+// newTestSqliteDB opens a fresh in-memory sqlite database holding a single
+// `account` table.
+func newTestSqliteDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	sqlDB, err := sql.Open(openDriverName("sqlite3"), ":memory:")
+	require.NoError(t, err)
+	db := sqlx.NewDb(sqlDB, "sqlite3")
+	// every pooled connection to :memory: gets its own database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE account (id INTEGER PRIMARY KEY, name TEXT NOT NULL, balance INTEGER NOT NULL DEFAULT 0)`)
+	require.NoError(t, err)
+	return db
+}
+
+func newTestSqliteCommand(t *testing.T) *command {
+	t.Helper()
+	return initCommand(newTestSqliteDB(t), "testdb", nil, newMockLogger(t), true, false, false, nil, nil).(*command)
+}
+
+func TestDriverName(t *testing.T) {
+	c := newTestSqliteCommand(t)
+	assert.Equal(t, "sqlite3", DriverName(c))
+
+	tx, err := c.BeginTx(context.Background(), "txDriver", TxOptions{})
+	require.NoError(t, err)
+	defer tx.Rollback()
+	assert.Equal(t, "sqlite3", DriverName(tx))
+
+	assert.Equal(t, "", DriverName(struct{}{}))
+}
+
+func TestInit_Sqlite(t *testing.T) {
+	db := Init(Config{
+		Driver:                      "sqlite3",
+		Leader:                      ConnConfig{DB: filepath.Join(t.TempDir(), "init.db")},
+		FollowerHealthCheckInterval: -1,
+	}, newMockLogger(t), nil)
+	defer db.Stop()
+
+	assert.Equal(t, "sqlite3", DriverName(db.Leader()))
+	assert.NoError(t, db.Leader().Ping(context.Background()))
+	assert.Equal(t, db.Leader(), db.Follower())
+}
+
+func TestInit_Followers(t *testing.T) {
+	dir := t.TempDir()
+	db := Init(Config{
+		Driver:                      "sqlite3",
+		Leader:                      ConnConfig{DB: filepath.Join(dir, "leader.db")},
+		Followers:                   []ConnConfig{{DB: filepath.Join(dir, "f0.db")}, {DB: filepath.Join(dir, "f1.db")}},
+		FollowerHealthCheckInterval: -1,
+	}, newMockLogger(t), nil)
+	defer db.Stop()
+
+	for i, f := range db.(*sqlDB).followers.followers {
+		_, err := f.cmd.Exec(context.Background(), "create", `CREATE TABLE replica AS SELECT ? AS id`, i)
+		require.NoError(t, err)
+	}
+
+	// a stored Command still spreads its reads over the replicas
+	follower := db.Follower()
+	seen := map[int]bool{}
+	for range 4 {
+		var id int
+		require.NoError(t, follower.Get(context.Background(), "replica", `SELECT id FROM replica`, &id))
+		seen[id] = true
+	}
+	assert.Equal(t, map[int]bool{0: true, 1: true}, seen)
+}
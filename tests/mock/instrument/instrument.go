@@ -159,3 +159,181 @@ func (mr *MockInterfaceMockRecorder) SchedulerRunningTimer(schedulername any) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchedulerRunningTimer", reflect.TypeOf((*MockInterface)(nil).SchedulerRunningTimer), schedulername)
 }
+
+// MockInterfaceV2 is a mock of InterfaceV2 interface.
+type MockInterfaceV2 struct {
+	ctrl     *gomock.Controller
+	recorder *MockInterfaceV2MockRecorder
+	isgomock struct{}
+}
+
+// MockInterfaceV2MockRecorder is the mock recorder for MockInterfaceV2.
+type MockInterfaceV2MockRecorder struct {
+	mock *MockInterfaceV2
+}
+
+// NewMockInterfaceV2 creates a new mock instance.
+func NewMockInterfaceV2(ctrl *gomock.Controller) *MockInterfaceV2 {
+	mock := &MockInterfaceV2{ctrl: ctrl}
+	mock.recorder = &MockInterfaceV2MockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInterfaceV2) EXPECT() *MockInterfaceV2MockRecorder {
+	return m.recorder
+}
+
+// CacheCounter mocks base method.
+func (m *MockInterfaceV2) CacheCounter(cachename, event string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CacheCounter", cachename, event)
+}
+
+// CacheCounter indicates an expected call of CacheCounter.
+func (mr *MockInterfaceV2MockRecorder) CacheCounter(cachename, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheCounter", reflect.TypeOf((*MockInterfaceV2)(nil).CacheCounter), cachename, event)
+}
+
+// DatabaseCircuitBreakerState mocks base method.
+func (m *MockInterfaceV2) DatabaseCircuitBreakerState(dbname, conntype, state string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DatabaseCircuitBreakerState", dbname, conntype, state)
+}
+
+// DatabaseCircuitBreakerState indicates an expected call of DatabaseCircuitBreakerState.
+func (mr *MockInterfaceV2MockRecorder) DatabaseCircuitBreakerState(dbname, conntype, state any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DatabaseCircuitBreakerState", reflect.TypeOf((*MockInterfaceV2)(nil).DatabaseCircuitBreakerState), dbname, conntype, state)
+}
+
+// DatabaseQueryTimer mocks base method.
+func (m *MockInterfaceV2) DatabaseQueryTimer(dbname, conntype, queryname string) *prometheus.Timer {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DatabaseQueryTimer", dbname, conntype, queryname)
+	ret0, _ := ret[0].(*prometheus.Timer)
+	return ret0
+}
+
+// DatabaseQueryTimer indicates an expected call of DatabaseQueryTimer.
+func (mr *MockInterfaceV2MockRecorder) DatabaseQueryTimer(dbname, conntype, queryname any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DatabaseQueryTimer", reflect.TypeOf((*MockInterfaceV2)(nil).DatabaseQueryTimer), dbname, conntype, queryname)
+}
+
+// DatabaseTxCounter mocks base method.
+func (m *MockInterfaceV2) DatabaseTxCounter(dbname, conntype, txname, outcome string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DatabaseTxCounter", dbname, conntype, txname, outcome)
+}
+
+// DatabaseTxCounter indicates an expected call of DatabaseTxCounter.
+func (mr *MockInterfaceV2MockRecorder) DatabaseTxCounter(dbname, conntype, txname, outcome any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DatabaseTxCounter", reflect.TypeOf((*MockInterfaceV2)(nil).DatabaseTxCounter), dbname, conntype, txname, outcome)
+}
+
+// HTTPRequestCounter mocks base method.
+func (m *MockInterfaceV2) HTTPRequestCounter(path, method string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HTTPRequestCounter", path, method)
+}
+
+// HTTPRequestCounter indicates an expected call of HTTPRequestCounter.
+func (mr *MockInterfaceV2MockRecorder) HTTPRequestCounter(path, method any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HTTPRequestCounter", reflect.TypeOf((*MockInterfaceV2)(nil).HTTPRequestCounter), path, method)
+}
+
+// HTTPRequestTimer mocks base method.
+func (m *MockInterfaceV2) HTTPRequestTimer(path, method string) *prometheus.Timer {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HTTPRequestTimer", path, method)
+	ret0, _ := ret[0].(*prometheus.Timer)
+	return ret0
+}
+
+// HTTPRequestTimer indicates an expected call of HTTPRequestTimer.
+func (mr *MockInterfaceV2MockRecorder) HTTPRequestTimer(path, method any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HTTPRequestTimer", reflect.TypeOf((*MockInterfaceV2)(nil).HTTPRequestTimer), path, method)
+}
+
+// HTTPResponseStatusCounter mocks base method.
+func (m *MockInterfaceV2) HTTPResponseStatusCounter(code int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HTTPResponseStatusCounter", code)
+}
+
+// HTTPResponseStatusCounter indicates an expected call of HTTPResponseStatusCounter.
+func (mr *MockInterfaceV2MockRecorder) HTTPResponseStatusCounter(code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HTTPResponseStatusCounter", reflect.TypeOf((*MockInterfaceV2)(nil).HTTPResponseStatusCounter), code)
+}
+
+// IsEnabled mocks base method.
+func (m *MockInterfaceV2) IsEnabled() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsEnabled")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsEnabled indicates an expected call of IsEnabled.
+func (mr *MockInterfaceV2MockRecorder) IsEnabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEnabled", reflect.TypeOf((*MockInterfaceV2)(nil).IsEnabled))
+}
+
+// MetricsHandler mocks base method.
+func (m *MockInterfaceV2) MetricsHandler() http.Handler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MetricsHandler")
+	ret0, _ := ret[0].(http.Handler)
+	return ret0
+}
+
+// MetricsHandler indicates an expected call of MetricsHandler.
+func (mr *MockInterfaceV2MockRecorder) MetricsHandler() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MetricsHandler", reflect.TypeOf((*MockInterfaceV2)(nil).MetricsHandler))
+}
+
+// RegisterDBStats mocks base method.
+func (m *MockInterfaceV2) RegisterDBStats(db *sql.DB, dbname string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RegisterDBStats", db, dbname)
+}
+
+// RegisterDBStats indicates an expected call of RegisterDBStats.
+func (mr *MockInterfaceV2MockRecorder) RegisterDBStats(db, dbname any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterDBStats", reflect.TypeOf((*MockInterfaceV2)(nil).RegisterDBStats), db, dbname)
+}
+
+// SchedulerRunningCounter mocks base method.
+func (m *MockInterfaceV2) SchedulerRunningCounter(schedulername string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SchedulerRunningCounter", schedulername)
+}
+
+// SchedulerRunningCounter indicates an expected call of SchedulerRunningCounter.
+func (mr *MockInterfaceV2MockRecorder) SchedulerRunningCounter(schedulername any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchedulerRunningCounter", reflect.TypeOf((*MockInterfaceV2)(nil).SchedulerRunningCounter), schedulername)
+}
+
+// SchedulerRunningTimer mocks base method.
+func (m *MockInterfaceV2) SchedulerRunningTimer(schedulername string) *prometheus.Timer {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SchedulerRunningTimer", schedulername)
+	ret0, _ := ret[0].(*prometheus.Timer)
+	return ret0
+}
+
+// SchedulerRunningTimer indicates an expected call of SchedulerRunningTimer.
+func (mr *MockInterfaceV2MockRecorder) SchedulerRunningTimer(schedulername any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchedulerRunningTimer", reflect.TypeOf((*MockInterfaceV2)(nil).SchedulerRunningTimer), schedulername)
+}
@@ -0,0 +1,484 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./redis/redis_data.go
+//
+// Generated by this command:
+//
+//	mockgen -source ./redis/redis_data.go -destination ./tests/mock/redis/redis_data.go
+//
+
+// Package mock_redis is a generated GoMock package.
+package mock_redis
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	redislock "github.com/bsm/redislock"
+	redis "github.com/downsized-devs/sdk-go/redis"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDataStructureInterface is a mock of DataStructureInterface interface.
+type MockDataStructureInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockDataStructureInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockDataStructureInterfaceMockRecorder is the mock recorder for MockDataStructureInterface.
+type MockDataStructureInterfaceMockRecorder struct {
+	mock *MockDataStructureInterface
+}
+
+// NewMockDataStructureInterface creates a new mock instance.
+func NewMockDataStructureInterface(ctrl *gomock.Controller) *MockDataStructureInterface {
+	mock := &MockDataStructureInterface{ctrl: ctrl}
+	mock.recorder = &MockDataStructureInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDataStructureInterface) EXPECT() *MockDataStructureInterfaceMockRecorder {
+	return m.recorder
+}
+
+// BRPop mocks base method.
+func (m *MockDataStructureInterface) BRPop(ctx context.Context, timeout time.Duration, keys ...string) (string, string, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, timeout}
+	for _, a := range keys {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "BRPop", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// BRPop indicates an expected call of BRPop.
+func (mr *MockDataStructureInterfaceMockRecorder) BRPop(ctx, timeout any, keys ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, timeout}, keys...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BRPop", reflect.TypeOf((*MockDataStructureInterface)(nil).BRPop), varargs...)
+}
+
+// Del mocks base method.
+func (m *MockDataStructureInterface) Del(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Del", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Del indicates an expected call of Del.
+func (mr *MockDataStructureInterfaceMockRecorder) Del(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockDataStructureInterface)(nil).Del), ctx, key)
+}
+
+// DelKeys mocks base method.
+func (m *MockDataStructureInterface) DelKeys(ctx context.Context, keys ...string) (int64, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range keys {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DelKeys", varargs...)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DelKeys indicates an expected call of DelKeys.
+func (mr *MockDataStructureInterfaceMockRecorder) DelKeys(ctx any, keys ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, keys...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DelKeys", reflect.TypeOf((*MockDataStructureInterface)(nil).DelKeys), varargs...)
+}
+
+// DelPattern mocks base method.
+func (m *MockDataStructureInterface) DelPattern(ctx context.Context, pattern string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DelPattern", ctx, pattern)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DelPattern indicates an expected call of DelPattern.
+func (mr *MockDataStructureInterfaceMockRecorder) DelPattern(ctx, pattern any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DelPattern", reflect.TypeOf((*MockDataStructureInterface)(nil).DelPattern), ctx, pattern)
+}
+
+// Expire mocks base method.
+func (m *MockDataStructureInterface) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Expire", ctx, key, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Expire indicates an expected call of Expire.
+func (mr *MockDataStructureInterfaceMockRecorder) Expire(ctx, key, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Expire", reflect.TypeOf((*MockDataStructureInterface)(nil).Expire), ctx, key, ttl)
+}
+
+// FlushAll mocks base method.
+func (m *MockDataStructureInterface) FlushAll(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushAll", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushAll indicates an expected call of FlushAll.
+func (mr *MockDataStructureInterfaceMockRecorder) FlushAll(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushAll", reflect.TypeOf((*MockDataStructureInterface)(nil).FlushAll), ctx)
+}
+
+// FlushAllAsync mocks base method.
+func (m *MockDataStructureInterface) FlushAllAsync(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushAllAsync", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushAllAsync indicates an expected call of FlushAllAsync.
+func (mr *MockDataStructureInterfaceMockRecorder) FlushAllAsync(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushAllAsync", reflect.TypeOf((*MockDataStructureInterface)(nil).FlushAllAsync), ctx)
+}
+
+// FlushDB mocks base method.
+func (m *MockDataStructureInterface) FlushDB(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushDB", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushDB indicates an expected call of FlushDB.
+func (mr *MockDataStructureInterfaceMockRecorder) FlushDB(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushDB", reflect.TypeOf((*MockDataStructureInterface)(nil).FlushDB), ctx)
+}
+
+// FlushDBAsync mocks base method.
+func (m *MockDataStructureInterface) FlushDBAsync(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushDBAsync", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushDBAsync indicates an expected call of FlushDBAsync.
+func (mr *MockDataStructureInterfaceMockRecorder) FlushDBAsync(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushDBAsync", reflect.TypeOf((*MockDataStructureInterface)(nil).FlushDBAsync), ctx)
+}
+
+// Get mocks base method.
+func (m *MockDataStructureInterface) Get(ctx context.Context, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockDataStructureInterfaceMockRecorder) Get(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDataStructureInterface)(nil).Get), ctx, key)
+}
+
+// GetDefaultTTL mocks base method.
+func (m *MockDataStructureInterface) GetDefaultTTL(ctx context.Context) time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDefaultTTL", ctx)
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetDefaultTTL indicates an expected call of GetDefaultTTL.
+func (mr *MockDataStructureInterfaceMockRecorder) GetDefaultTTL(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDefaultTTL", reflect.TypeOf((*MockDataStructureInterface)(nil).GetDefaultTTL), ctx)
+}
+
+// HGetAll mocks base method.
+func (m *MockDataStructureInterface) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HGetAll", ctx, key)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HGetAll indicates an expected call of HGetAll.
+func (mr *MockDataStructureInterfaceMockRecorder) HGetAll(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HGetAll", reflect.TypeOf((*MockDataStructureInterface)(nil).HGetAll), ctx, key)
+}
+
+// HSet mocks base method.
+func (m *MockDataStructureInterface) HSet(ctx context.Context, key string, values map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HSet", ctx, key, values)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HSet indicates an expected call of HSet.
+func (mr *MockDataStructureInterfaceMockRecorder) HSet(ctx, key, values any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HSet", reflect.TypeOf((*MockDataStructureInterface)(nil).HSet), ctx, key, values)
+}
+
+// Incr mocks base method.
+func (m *MockDataStructureInterface) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Incr", ctx, key, ttl)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Incr indicates an expected call of Incr.
+func (mr *MockDataStructureInterfaceMockRecorder) Incr(ctx, key, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Incr", reflect.TypeOf((*MockDataStructureInterface)(nil).Incr), ctx, key, ttl)
+}
+
+// IncrBy mocks base method.
+func (m *MockDataStructureInterface) IncrBy(ctx context.Context, key string, by int64, ttl time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrBy", ctx, key, by, ttl)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrBy indicates an expected call of IncrBy.
+func (mr *MockDataStructureInterfaceMockRecorder) IncrBy(ctx, key, by, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrBy", reflect.TypeOf((*MockDataStructureInterface)(nil).IncrBy), ctx, key, by, ttl)
+}
+
+// LPush mocks base method.
+func (m *MockDataStructureInterface) LPush(ctx context.Context, key string, values ...string) (int64, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, key}
+	for _, a := range values {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "LPush", varargs...)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LPush indicates an expected call of LPush.
+func (mr *MockDataStructureInterfaceMockRecorder) LPush(ctx, key any, values ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, key}, values...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LPush", reflect.TypeOf((*MockDataStructureInterface)(nil).LPush), varargs...)
+}
+
+// Lock mocks base method.
+func (m *MockDataStructureInterface) Lock(ctx context.Context, key string, expTime time.Duration) (*redislock.Lock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, key, expTime)
+	ret0, _ := ret[0].(*redislock.Lock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockDataStructureInterfaceMockRecorder) Lock(ctx, key, expTime any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockDataStructureInterface)(nil).Lock), ctx, key, expTime)
+}
+
+// LockRelease mocks base method.
+func (m *MockDataStructureInterface) LockRelease(ctx context.Context, lock *redislock.Lock) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockRelease", ctx, lock)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockRelease indicates an expected call of LockRelease.
+func (mr *MockDataStructureInterfaceMockRecorder) LockRelease(ctx, lock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockRelease", reflect.TypeOf((*MockDataStructureInterface)(nil).LockRelease), ctx, lock)
+}
+
+// MGet mocks base method.
+func (m *MockDataStructureInterface) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range keys {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "MGet", varargs...)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MGet indicates an expected call of MGet.
+func (mr *MockDataStructureInterfaceMockRecorder) MGet(ctx any, keys ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, keys...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MGet", reflect.TypeOf((*MockDataStructureInterface)(nil).MGet), varargs...)
+}
+
+// MSet mocks base method.
+func (m *MockDataStructureInterface) MSet(ctx context.Context, values map[string]string, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MSet", ctx, values, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MSet indicates an expected call of MSet.
+func (mr *MockDataStructureInterfaceMockRecorder) MSet(ctx, values, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MSet", reflect.TypeOf((*MockDataStructureInterface)(nil).MSet), ctx, values, ttl)
+}
+
+// Ping mocks base method.
+func (m *MockDataStructureInterface) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockDataStructureInterfaceMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockDataStructureInterface)(nil).Ping), ctx)
+}
+
+// SAdd mocks base method.
+func (m *MockDataStructureInterface) SAdd(ctx context.Context, key string, members ...string) (int64, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, key}
+	for _, a := range members {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SAdd", varargs...)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SAdd indicates an expected call of SAdd.
+func (mr *MockDataStructureInterfaceMockRecorder) SAdd(ctx, key any, members ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, key}, members...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SAdd", reflect.TypeOf((*MockDataStructureInterface)(nil).SAdd), varargs...)
+}
+
+// SMembers mocks base method.
+func (m *MockDataStructureInterface) SMembers(ctx context.Context, key string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SMembers", ctx, key)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SMembers indicates an expected call of SMembers.
+func (mr *MockDataStructureInterfaceMockRecorder) SMembers(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SMembers", reflect.TypeOf((*MockDataStructureInterface)(nil).SMembers), ctx, key)
+}
+
+// SetEX mocks base method.
+func (m *MockDataStructureInterface) SetEX(ctx context.Context, key, val string, expTime time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEX", ctx, key, val, expTime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEX indicates an expected call of SetEX.
+func (mr *MockDataStructureInterfaceMockRecorder) SetEX(ctx, key, val, expTime any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEX", reflect.TypeOf((*MockDataStructureInterface)(nil).SetEX), ctx, key, val, expTime)
+}
+
+// SetNX mocks base method.
+func (m *MockDataStructureInterface) SetNX(ctx context.Context, key, val string, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNX", ctx, key, val, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetNX indicates an expected call of SetNX.
+func (mr *MockDataStructureInterfaceMockRecorder) SetNX(ctx, key, val, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNX", reflect.TypeOf((*MockDataStructureInterface)(nil).SetNX), ctx, key, val, ttl)
+}
+
+// TTL mocks base method.
+func (m *MockDataStructureInterface) TTL(ctx context.Context, key string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TTL", ctx, key)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TTL indicates an expected call of TTL.
+func (mr *MockDataStructureInterfaceMockRecorder) TTL(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TTL", reflect.TypeOf((*MockDataStructureInterface)(nil).TTL), ctx, key)
+}
+
+// ZAdd mocks base method.
+func (m *MockDataStructureInterface) ZAdd(ctx context.Context, key string, members ...redis.ZMember) (int64, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, key}
+	for _, a := range members {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ZAdd", varargs...)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ZAdd indicates an expected call of ZAdd.
+func (mr *MockDataStructureInterfaceMockRecorder) ZAdd(ctx, key any, members ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, key}, members...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZAdd", reflect.TypeOf((*MockDataStructureInterface)(nil).ZAdd), varargs...)
+}
+
+// ZRangeByScore mocks base method.
+func (m *MockDataStructureInterface) ZRangeByScore(ctx context.Context, key string, min, max float64, offset, count int64) ([]redis.ZMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZRangeByScore", ctx, key, min, max, offset, count)
+	ret0, _ := ret[0].([]redis.ZMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ZRangeByScore indicates an expected call of ZRangeByScore.
+func (mr *MockDataStructureInterfaceMockRecorder) ZRangeByScore(ctx, key, min, max, offset, count any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZRangeByScore", reflect.TypeOf((*MockDataStructureInterface)(nil).ZRangeByScore), ctx, key, min, max, offset, count)
+}
@@ -0,0 +1,213 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./redis/redis_local.go
+//
+// Generated by this command:
+//
+//	mockgen -source ./redis/redis_local.go -destination ./tests/mock/redis/redis_local.go
+//
+
+// Package mock_redis is a generated GoMock package.
+package mock_redis
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	redislock "github.com/bsm/redislock"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLocalCacheInterface is a mock of LocalCacheInterface interface.
+type MockLocalCacheInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockLocalCacheInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockLocalCacheInterfaceMockRecorder is the mock recorder for MockLocalCacheInterface.
+type MockLocalCacheInterfaceMockRecorder struct {
+	mock *MockLocalCacheInterface
+}
+
+// NewMockLocalCacheInterface creates a new mock instance.
+func NewMockLocalCacheInterface(ctrl *gomock.Controller) *MockLocalCacheInterface {
+	mock := &MockLocalCacheInterface{ctrl: ctrl}
+	mock.recorder = &MockLocalCacheInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLocalCacheInterface) EXPECT() *MockLocalCacheInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockLocalCacheInterface) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockLocalCacheInterfaceMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockLocalCacheInterface)(nil).Close))
+}
+
+// Del mocks base method.
+func (m *MockLocalCacheInterface) Del(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Del", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Del indicates an expected call of Del.
+func (mr *MockLocalCacheInterfaceMockRecorder) Del(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockLocalCacheInterface)(nil).Del), ctx, key)
+}
+
+// FlushAll mocks base method.
+func (m *MockLocalCacheInterface) FlushAll(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushAll", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushAll indicates an expected call of FlushAll.
+func (mr *MockLocalCacheInterfaceMockRecorder) FlushAll(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushAll", reflect.TypeOf((*MockLocalCacheInterface)(nil).FlushAll), ctx)
+}
+
+// FlushAllAsync mocks base method.
+func (m *MockLocalCacheInterface) FlushAllAsync(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushAllAsync", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushAllAsync indicates an expected call of FlushAllAsync.
+func (mr *MockLocalCacheInterfaceMockRecorder) FlushAllAsync(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushAllAsync", reflect.TypeOf((*MockLocalCacheInterface)(nil).FlushAllAsync), ctx)
+}
+
+// FlushDB mocks base method.
+func (m *MockLocalCacheInterface) FlushDB(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushDB", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushDB indicates an expected call of FlushDB.
+func (mr *MockLocalCacheInterfaceMockRecorder) FlushDB(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushDB", reflect.TypeOf((*MockLocalCacheInterface)(nil).FlushDB), ctx)
+}
+
+// FlushDBAsync mocks base method.
+func (m *MockLocalCacheInterface) FlushDBAsync(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushDBAsync", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushDBAsync indicates an expected call of FlushDBAsync.
+func (mr *MockLocalCacheInterfaceMockRecorder) FlushDBAsync(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushDBAsync", reflect.TypeOf((*MockLocalCacheInterface)(nil).FlushDBAsync), ctx)
+}
+
+// Get mocks base method.
+func (m *MockLocalCacheInterface) Get(ctx context.Context, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockLocalCacheInterfaceMockRecorder) Get(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockLocalCacheInterface)(nil).Get), ctx, key)
+}
+
+// GetDefaultTTL mocks base method.
+func (m *MockLocalCacheInterface) GetDefaultTTL(ctx context.Context) time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDefaultTTL", ctx)
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetDefaultTTL indicates an expected call of GetDefaultTTL.
+func (mr *MockLocalCacheInterfaceMockRecorder) GetDefaultTTL(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDefaultTTL", reflect.TypeOf((*MockLocalCacheInterface)(nil).GetDefaultTTL), ctx)
+}
+
+// Lock mocks base method.
+func (m *MockLocalCacheInterface) Lock(ctx context.Context, key string, expTime time.Duration) (*redislock.Lock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, key, expTime)
+	ret0, _ := ret[0].(*redislock.Lock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockLocalCacheInterfaceMockRecorder) Lock(ctx, key, expTime any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockLocalCacheInterface)(nil).Lock), ctx, key, expTime)
+}
+
+// LockRelease mocks base method.
+func (m *MockLocalCacheInterface) LockRelease(ctx context.Context, lock *redislock.Lock) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockRelease", ctx, lock)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockRelease indicates an expected call of LockRelease.
+func (mr *MockLocalCacheInterfaceMockRecorder) LockRelease(ctx, lock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockRelease", reflect.TypeOf((*MockLocalCacheInterface)(nil).LockRelease), ctx, lock)
+}
+
+// Ping mocks base method.
+func (m *MockLocalCacheInterface) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockLocalCacheInterfaceMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockLocalCacheInterface)(nil).Ping), ctx)
+}
+
+// SetEX mocks base method.
+func (m *MockLocalCacheInterface) SetEX(ctx context.Context, key, val string, expTime time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEX", ctx, key, val, expTime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEX indicates an expected call of SetEX.
+func (mr *MockLocalCacheInterfaceMockRecorder) SetEX(ctx, key, val, expTime any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEX", reflect.TypeOf((*MockLocalCacheInterface)(nil).SetEX), ctx, key, val, expTime)
+}
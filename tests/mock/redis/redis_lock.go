@@ -0,0 +1,243 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./redis/redis_lock.go
+//
+// Generated by this command:
+//
+//	mockgen -source ./redis/redis_lock.go -destination ./tests/mock/redis/redis_lock.go
+//
+
+// Package mock_redis is a generated GoMock package.
+package mock_redis
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	redislock "github.com/bsm/redislock"
+	redis "github.com/downsized-devs/sdk-go/redis"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLockInterface is a mock of LockInterface interface.
+type MockLockInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockLockInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockLockInterfaceMockRecorder is the mock recorder for MockLockInterface.
+type MockLockInterfaceMockRecorder struct {
+	mock *MockLockInterface
+}
+
+// NewMockLockInterface creates a new mock instance.
+func NewMockLockInterface(ctrl *gomock.Controller) *MockLockInterface {
+	mock := &MockLockInterface{ctrl: ctrl}
+	mock.recorder = &MockLockInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLockInterface) EXPECT() *MockLockInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Del mocks base method.
+func (m *MockLockInterface) Del(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Del", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Del indicates an expected call of Del.
+func (mr *MockLockInterfaceMockRecorder) Del(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockLockInterface)(nil).Del), ctx, key)
+}
+
+// FlushAll mocks base method.
+func (m *MockLockInterface) FlushAll(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushAll", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushAll indicates an expected call of FlushAll.
+func (mr *MockLockInterfaceMockRecorder) FlushAll(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushAll", reflect.TypeOf((*MockLockInterface)(nil).FlushAll), ctx)
+}
+
+// FlushAllAsync mocks base method.
+func (m *MockLockInterface) FlushAllAsync(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushAllAsync", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushAllAsync indicates an expected call of FlushAllAsync.
+func (mr *MockLockInterfaceMockRecorder) FlushAllAsync(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushAllAsync", reflect.TypeOf((*MockLockInterface)(nil).FlushAllAsync), ctx)
+}
+
+// FlushDB mocks base method.
+func (m *MockLockInterface) FlushDB(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushDB", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushDB indicates an expected call of FlushDB.
+func (mr *MockLockInterfaceMockRecorder) FlushDB(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushDB", reflect.TypeOf((*MockLockInterface)(nil).FlushDB), ctx)
+}
+
+// FlushDBAsync mocks base method.
+func (m *MockLockInterface) FlushDBAsync(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushDBAsync", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushDBAsync indicates an expected call of FlushDBAsync.
+func (mr *MockLockInterfaceMockRecorder) FlushDBAsync(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushDBAsync", reflect.TypeOf((*MockLockInterface)(nil).FlushDBAsync), ctx)
+}
+
+// Get mocks base method.
+func (m *MockLockInterface) Get(ctx context.Context, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockLockInterfaceMockRecorder) Get(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockLockInterface)(nil).Get), ctx, key)
+}
+
+// GetDefaultTTL mocks base method.
+func (m *MockLockInterface) GetDefaultTTL(ctx context.Context) time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDefaultTTL", ctx)
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetDefaultTTL indicates an expected call of GetDefaultTTL.
+func (mr *MockLockInterfaceMockRecorder) GetDefaultTTL(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDefaultTTL", reflect.TypeOf((*MockLockInterface)(nil).GetDefaultTTL), ctx)
+}
+
+// Lock mocks base method.
+func (m *MockLockInterface) Lock(ctx context.Context, key string, expTime time.Duration) (*redislock.Lock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, key, expTime)
+	ret0, _ := ret[0].(*redislock.Lock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockLockInterfaceMockRecorder) Lock(ctx, key, expTime any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockLockInterface)(nil).Lock), ctx, key, expTime)
+}
+
+// LockRelease mocks base method.
+func (m *MockLockInterface) LockRelease(ctx context.Context, lock *redislock.Lock) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockRelease", ctx, lock)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockRelease indicates an expected call of LockRelease.
+func (mr *MockLockInterfaceMockRecorder) LockRelease(ctx, lock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockRelease", reflect.TypeOf((*MockLockInterface)(nil).LockRelease), ctx, lock)
+}
+
+// LockWithOptions mocks base method.
+func (m *MockLockInterface) LockWithOptions(ctx context.Context, key string, ttl time.Duration, opts redis.LockOptions) (*redis.Lock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockWithOptions", ctx, key, ttl, opts)
+	ret0, _ := ret[0].(*redis.Lock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockWithOptions indicates an expected call of LockWithOptions.
+func (mr *MockLockInterfaceMockRecorder) LockWithOptions(ctx, key, ttl, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockWithOptions", reflect.TypeOf((*MockLockInterface)(nil).LockWithOptions), ctx, key, ttl, opts)
+}
+
+// Ping mocks base method.
+func (m *MockLockInterface) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockLockInterfaceMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockLockInterface)(nil).Ping), ctx)
+}
+
+// SetEX mocks base method.
+func (m *MockLockInterface) SetEX(ctx context.Context, key, val string, expTime time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEX", ctx, key, val, expTime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEX indicates an expected call of SetEX.
+func (mr *MockLockInterfaceMockRecorder) SetEX(ctx, key, val, expTime any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEX", reflect.TypeOf((*MockLockInterface)(nil).SetEX), ctx, key, val, expTime)
+}
+
+// WithLock mocks base method.
+func (m *MockLockInterface) WithLock(ctx context.Context, key string, ttl time.Duration, fn func(context.Context, *redis.Lock) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithLock", ctx, key, ttl, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithLock indicates an expected call of WithLock.
+func (mr *MockLockInterfaceMockRecorder) WithLock(ctx, key, ttl, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithLock", reflect.TypeOf((*MockLockInterface)(nil).WithLock), ctx, key, ttl, fn)
+}
+
+// WithLockOptions mocks base method.
+func (m *MockLockInterface) WithLockOptions(ctx context.Context, key string, ttl time.Duration, opts redis.LockOptions, fn func(context.Context, *redis.Lock) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithLockOptions", ctx, key, ttl, opts, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithLockOptions indicates an expected call of WithLockOptions.
+func (mr *MockLockInterfaceMockRecorder) WithLockOptions(ctx, key, ttl, opts, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithLockOptions", reflect.TypeOf((*MockLockInterface)(nil).WithLockOptions), ctx, key, ttl, opts, fn)
+}
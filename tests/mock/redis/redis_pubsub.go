@@ -0,0 +1,234 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./redis/redis_pubsub.go
+//
+// Generated by this command:
+//
+//	mockgen -source ./redis/redis_pubsub.go -destination ./tests/mock/redis/redis_pubsub.go
+//
+
+// Package mock_redis is a generated GoMock package.
+package mock_redis
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	redislock "github.com/bsm/redislock"
+	redis "github.com/go-redis/redis/v8"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPubSubInterface is a mock of PubSubInterface interface.
+type MockPubSubInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockPubSubInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockPubSubInterfaceMockRecorder is the mock recorder for MockPubSubInterface.
+type MockPubSubInterfaceMockRecorder struct {
+	mock *MockPubSubInterface
+}
+
+// NewMockPubSubInterface creates a new mock instance.
+func NewMockPubSubInterface(ctrl *gomock.Controller) *MockPubSubInterface {
+	mock := &MockPubSubInterface{ctrl: ctrl}
+	mock.recorder = &MockPubSubInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPubSubInterface) EXPECT() *MockPubSubInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Del mocks base method.
+func (m *MockPubSubInterface) Del(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Del", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Del indicates an expected call of Del.
+func (mr *MockPubSubInterfaceMockRecorder) Del(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockPubSubInterface)(nil).Del), ctx, key)
+}
+
+// FlushAll mocks base method.
+func (m *MockPubSubInterface) FlushAll(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushAll", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushAll indicates an expected call of FlushAll.
+func (mr *MockPubSubInterfaceMockRecorder) FlushAll(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushAll", reflect.TypeOf((*MockPubSubInterface)(nil).FlushAll), ctx)
+}
+
+// FlushAllAsync mocks base method.
+func (m *MockPubSubInterface) FlushAllAsync(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushAllAsync", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushAllAsync indicates an expected call of FlushAllAsync.
+func (mr *MockPubSubInterfaceMockRecorder) FlushAllAsync(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushAllAsync", reflect.TypeOf((*MockPubSubInterface)(nil).FlushAllAsync), ctx)
+}
+
+// FlushDB mocks base method.
+func (m *MockPubSubInterface) FlushDB(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushDB", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushDB indicates an expected call of FlushDB.
+func (mr *MockPubSubInterfaceMockRecorder) FlushDB(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushDB", reflect.TypeOf((*MockPubSubInterface)(nil).FlushDB), ctx)
+}
+
+// FlushDBAsync mocks base method.
+func (m *MockPubSubInterface) FlushDBAsync(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushDBAsync", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushDBAsync indicates an expected call of FlushDBAsync.
+func (mr *MockPubSubInterfaceMockRecorder) FlushDBAsync(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushDBAsync", reflect.TypeOf((*MockPubSubInterface)(nil).FlushDBAsync), ctx)
+}
+
+// Get mocks base method.
+func (m *MockPubSubInterface) Get(ctx context.Context, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockPubSubInterfaceMockRecorder) Get(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPubSubInterface)(nil).Get), ctx, key)
+}
+
+// GetDefaultTTL mocks base method.
+func (m *MockPubSubInterface) GetDefaultTTL(ctx context.Context) time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDefaultTTL", ctx)
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetDefaultTTL indicates an expected call of GetDefaultTTL.
+func (mr *MockPubSubInterfaceMockRecorder) GetDefaultTTL(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDefaultTTL", reflect.TypeOf((*MockPubSubInterface)(nil).GetDefaultTTL), ctx)
+}
+
+// Lock mocks base method.
+func (m *MockPubSubInterface) Lock(ctx context.Context, key string, expTime time.Duration) (*redislock.Lock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, key, expTime)
+	ret0, _ := ret[0].(*redislock.Lock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockPubSubInterfaceMockRecorder) Lock(ctx, key, expTime any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockPubSubInterface)(nil).Lock), ctx, key, expTime)
+}
+
+// LockRelease mocks base method.
+func (m *MockPubSubInterface) LockRelease(ctx context.Context, lock *redislock.Lock) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockRelease", ctx, lock)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockRelease indicates an expected call of LockRelease.
+func (mr *MockPubSubInterfaceMockRecorder) LockRelease(ctx, lock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockRelease", reflect.TypeOf((*MockPubSubInterface)(nil).LockRelease), ctx, lock)
+}
+
+// Ping mocks base method.
+func (m *MockPubSubInterface) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockPubSubInterfaceMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockPubSubInterface)(nil).Ping), ctx)
+}
+
+// Publish mocks base method.
+func (m *MockPubSubInterface) Publish(ctx context.Context, channel, message string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, channel, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockPubSubInterfaceMockRecorder) Publish(ctx, channel, message any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockPubSubInterface)(nil).Publish), ctx, channel, message)
+}
+
+// SetEX mocks base method.
+func (m *MockPubSubInterface) SetEX(ctx context.Context, key, val string, expTime time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEX", ctx, key, val, expTime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEX indicates an expected call of SetEX.
+func (mr *MockPubSubInterfaceMockRecorder) SetEX(ctx, key, val, expTime any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEX", reflect.TypeOf((*MockPubSubInterface)(nil).SetEX), ctx, key, val, expTime)
+}
+
+// Subscribe mocks base method.
+func (m *MockPubSubInterface) Subscribe(ctx context.Context, channels ...string) (*redis.PubSub, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range channels {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Subscribe", varargs...)
+	ret0, _ := ret[0].(*redis.PubSub)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockPubSubInterfaceMockRecorder) Subscribe(ctx any, channels ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, channels...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockPubSubInterface)(nil).Subscribe), varargs...)
+}
@@ -10,6 +10,7 @@
 package mock_sql
 
 import (
+	context "context"
 	sql "database/sql"
 	reflect "reflect"
 
@@ -152,3 +153,237 @@ func (mr *MockCommandStmtMockRecorder) Select(name, dest any, args ...any) *gomo
 	varargs := append([]any{name, dest}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Select", reflect.TypeOf((*MockCommandStmt)(nil).Select), varargs...)
 }
+
+// MockCommandStmtContext is a mock of CommandStmtContext interface.
+type MockCommandStmtContext struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommandStmtContextMockRecorder
+	isgomock struct{}
+}
+
+// MockCommandStmtContextMockRecorder is the mock recorder for MockCommandStmtContext.
+type MockCommandStmtContextMockRecorder struct {
+	mock *MockCommandStmtContext
+}
+
+// NewMockCommandStmtContext creates a new mock instance.
+func NewMockCommandStmtContext(ctrl *gomock.Controller) *MockCommandStmtContext {
+	mock := &MockCommandStmtContext{ctrl: ctrl}
+	mock.recorder = &MockCommandStmtContextMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommandStmtContext) EXPECT() *MockCommandStmtContextMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockCommandStmtContext) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockCommandStmtContextMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockCommandStmtContext)(nil).Close))
+}
+
+// Exec mocks base method.
+func (m *MockCommandStmtContext) Exec(name string, args ...any) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{name}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Exec", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exec indicates an expected call of Exec.
+func (mr *MockCommandStmtContextMockRecorder) Exec(name any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{name}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockCommandStmtContext)(nil).Exec), varargs...)
+}
+
+// ExecContext mocks base method.
+func (m *MockCommandStmtContext) ExecContext(ctx context.Context, name string, args ...any) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, name}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext.
+func (mr *MockCommandStmtContextMockRecorder) ExecContext(ctx, name any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, name}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockCommandStmtContext)(nil).ExecContext), varargs...)
+}
+
+// Get mocks base method.
+func (m *MockCommandStmtContext) Get(name string, dest any, args ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []any{name, dest}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Get", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockCommandStmtContextMockRecorder) Get(name, dest any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{name, dest}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCommandStmtContext)(nil).Get), varargs...)
+}
+
+// GetContext mocks base method.
+func (m *MockCommandStmtContext) GetContext(ctx context.Context, name string, dest any, args ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, name, dest}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetContext", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetContext indicates an expected call of GetContext.
+func (mr *MockCommandStmtContextMockRecorder) GetContext(ctx, name, dest any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, name, dest}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContext", reflect.TypeOf((*MockCommandStmtContext)(nil).GetContext), varargs...)
+}
+
+// Query mocks base method.
+func (m *MockCommandStmtContext) Query(name string, args ...any) (*sqlx.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{name}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Query", varargs...)
+	ret0, _ := ret[0].(*sqlx.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockCommandStmtContextMockRecorder) Query(name any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{name}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockCommandStmtContext)(nil).Query), varargs...)
+}
+
+// QueryContext mocks base method.
+func (m *MockCommandStmtContext) QueryContext(ctx context.Context, name string, args ...any) (*sqlx.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, name}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryContext", varargs...)
+	ret0, _ := ret[0].(*sqlx.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryContext indicates an expected call of QueryContext.
+func (mr *MockCommandStmtContextMockRecorder) QueryContext(ctx, name any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, name}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockCommandStmtContext)(nil).QueryContext), varargs...)
+}
+
+// QueryRow mocks base method.
+func (m *MockCommandStmtContext) QueryRow(name string, args ...any) (*sqlx.Row, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{name}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRow", varargs...)
+	ret0, _ := ret[0].(*sqlx.Row)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryRow indicates an expected call of QueryRow.
+func (mr *MockCommandStmtContextMockRecorder) QueryRow(name any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{name}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRow", reflect.TypeOf((*MockCommandStmtContext)(nil).QueryRow), varargs...)
+}
+
+// QueryRowContext mocks base method.
+func (m *MockCommandStmtContext) QueryRowContext(ctx context.Context, name string, args ...any) (*sqlx.Row, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, name}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRowContext", varargs...)
+	ret0, _ := ret[0].(*sqlx.Row)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryRowContext indicates an expected call of QueryRowContext.
+func (mr *MockCommandStmtContextMockRecorder) QueryRowContext(ctx, name any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, name}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockCommandStmtContext)(nil).QueryRowContext), varargs...)
+}
+
+// Select mocks base method.
+func (m *MockCommandStmtContext) Select(name string, dest any, args ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []any{name, dest}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Select", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Select indicates an expected call of Select.
+func (mr *MockCommandStmtContextMockRecorder) Select(name, dest any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{name, dest}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Select", reflect.TypeOf((*MockCommandStmtContext)(nil).Select), varargs...)
+}
+
+// SelectContext mocks base method.
+func (m *MockCommandStmtContext) SelectContext(ctx context.Context, name string, dest any, args ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, name, dest}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SelectContext", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SelectContext indicates an expected call of SelectContext.
+func (mr *MockCommandStmtContextMockRecorder) SelectContext(ctx, name, dest any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, name, dest}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectContext", reflect.TypeOf((*MockCommandStmtContext)(nil).SelectContext), varargs...)
+}
@@ -10,6 +10,7 @@
 package mock_sql
 
 import (
+	context "context"
 	sql "database/sql"
 	reflect "reflect"
 
@@ -223,3 +224,351 @@ func (mr *MockCommandTxMockRecorder) Stmt(name, stmt any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stmt", reflect.TypeOf((*MockCommandTx)(nil).Stmt), name, stmt)
 }
+
+// MockCommandTxContext is a mock of CommandTxContext interface.
+type MockCommandTxContext struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommandTxContextMockRecorder
+	isgomock struct{}
+}
+
+// MockCommandTxContextMockRecorder is the mock recorder for MockCommandTxContext.
+type MockCommandTxContextMockRecorder struct {
+	mock *MockCommandTxContext
+}
+
+// NewMockCommandTxContext creates a new mock instance.
+func NewMockCommandTxContext(ctrl *gomock.Controller) *MockCommandTxContext {
+	mock := &MockCommandTxContext{ctrl: ctrl}
+	mock.recorder = &MockCommandTxContextMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommandTxContext) EXPECT() *MockCommandTxContextMockRecorder {
+	return m.recorder
+}
+
+// Commit mocks base method.
+func (m *MockCommandTxContext) Commit() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Commit")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Commit indicates an expected call of Commit.
+func (mr *MockCommandTxContextMockRecorder) Commit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockCommandTxContext)(nil).Commit))
+}
+
+// Exec mocks base method.
+func (m *MockCommandTxContext) Exec(name, query string, args ...any) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{name, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Exec", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exec indicates an expected call of Exec.
+func (mr *MockCommandTxContextMockRecorder) Exec(name, query any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{name, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockCommandTxContext)(nil).Exec), varargs...)
+}
+
+// ExecContext mocks base method.
+func (m *MockCommandTxContext) ExecContext(ctx context.Context, name, query string, args ...any) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, name, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext.
+func (mr *MockCommandTxContextMockRecorder) ExecContext(ctx, name, query any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, name, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockCommandTxContext)(nil).ExecContext), varargs...)
+}
+
+// Get mocks base method.
+func (m *MockCommandTxContext) Get(name, query string, dest any, args ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []any{name, query, dest}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Get", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockCommandTxContextMockRecorder) Get(name, query, dest any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{name, query, dest}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCommandTxContext)(nil).Get), varargs...)
+}
+
+// GetContext mocks base method.
+func (m *MockCommandTxContext) GetContext(ctx context.Context, name, query string, dest any, args ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, name, query, dest}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetContext", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetContext indicates an expected call of GetContext.
+func (mr *MockCommandTxContextMockRecorder) GetContext(ctx, name, query, dest any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, name, query, dest}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContext", reflect.TypeOf((*MockCommandTxContext)(nil).GetContext), varargs...)
+}
+
+// NamedExec mocks base method.
+func (m *MockCommandTxContext) NamedExec(name, query string, args any) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NamedExec", name, query, args)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NamedExec indicates an expected call of NamedExec.
+func (mr *MockCommandTxContextMockRecorder) NamedExec(name, query, args any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NamedExec", reflect.TypeOf((*MockCommandTxContext)(nil).NamedExec), name, query, args)
+}
+
+// NamedExecContext mocks base method.
+func (m *MockCommandTxContext) NamedExecContext(ctx context.Context, name, query string, args any) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NamedExecContext", ctx, name, query, args)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NamedExecContext indicates an expected call of NamedExecContext.
+func (mr *MockCommandTxContextMockRecorder) NamedExecContext(ctx, name, query, args any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NamedExecContext", reflect.TypeOf((*MockCommandTxContext)(nil).NamedExecContext), ctx, name, query, args)
+}
+
+// Prepare mocks base method.
+func (m *MockCommandTxContext) Prepare(name, query string) (sql0.CommandStmt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Prepare", name, query)
+	ret0, _ := ret[0].(sql0.CommandStmt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Prepare indicates an expected call of Prepare.
+func (mr *MockCommandTxContextMockRecorder) Prepare(name, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prepare", reflect.TypeOf((*MockCommandTxContext)(nil).Prepare), name, query)
+}
+
+// PrepareContext mocks base method.
+func (m *MockCommandTxContext) PrepareContext(ctx context.Context, name, query string) (sql0.CommandStmt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrepareContext", ctx, name, query)
+	ret0, _ := ret[0].(sql0.CommandStmt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PrepareContext indicates an expected call of PrepareContext.
+func (mr *MockCommandTxContextMockRecorder) PrepareContext(ctx, name, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrepareContext", reflect.TypeOf((*MockCommandTxContext)(nil).PrepareContext), ctx, name, query)
+}
+
+// Query mocks base method.
+func (m *MockCommandTxContext) Query(name, query string, args ...any) (*sqlx.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{name, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Query", varargs...)
+	ret0, _ := ret[0].(*sqlx.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockCommandTxContextMockRecorder) Query(name, query any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{name, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockCommandTxContext)(nil).Query), varargs...)
+}
+
+// QueryContext mocks base method.
+func (m *MockCommandTxContext) QueryContext(ctx context.Context, name, query string, args ...any) (*sqlx.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, name, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryContext", varargs...)
+	ret0, _ := ret[0].(*sqlx.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryContext indicates an expected call of QueryContext.
+func (mr *MockCommandTxContextMockRecorder) QueryContext(ctx, name, query any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, name, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockCommandTxContext)(nil).QueryContext), varargs...)
+}
+
+// QueryRow mocks base method.
+func (m *MockCommandTxContext) QueryRow(name, query string, args ...any) (*sqlx.Row, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{name, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRow", varargs...)
+	ret0, _ := ret[0].(*sqlx.Row)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryRow indicates an expected call of QueryRow.
+func (mr *MockCommandTxContextMockRecorder) QueryRow(name, query any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{name, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRow", reflect.TypeOf((*MockCommandTxContext)(nil).QueryRow), varargs...)
+}
+
+// QueryRowContext mocks base method.
+func (m *MockCommandTxContext) QueryRowContext(ctx context.Context, name, query string, args ...any) (*sqlx.Row, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, name, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRowContext", varargs...)
+	ret0, _ := ret[0].(*sqlx.Row)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryRowContext indicates an expected call of QueryRowContext.
+func (mr *MockCommandTxContextMockRecorder) QueryRowContext(ctx, name, query any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, name, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockCommandTxContext)(nil).QueryRowContext), varargs...)
+}
+
+// Rebind mocks base method.
+func (m *MockCommandTxContext) Rebind(query string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rebind", query)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Rebind indicates an expected call of Rebind.
+func (mr *MockCommandTxContextMockRecorder) Rebind(query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rebind", reflect.TypeOf((*MockCommandTxContext)(nil).Rebind), query)
+}
+
+// Rollback mocks base method.
+func (m *MockCommandTxContext) Rollback() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Rollback")
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockCommandTxContextMockRecorder) Rollback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockCommandTxContext)(nil).Rollback))
+}
+
+// Select mocks base method.
+func (m *MockCommandTxContext) Select(name, query string, dest any, args ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []any{name, query, dest}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Select", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Select indicates an expected call of Select.
+func (mr *MockCommandTxContextMockRecorder) Select(name, query, dest any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{name, query, dest}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Select", reflect.TypeOf((*MockCommandTxContext)(nil).Select), varargs...)
+}
+
+// SelectContext mocks base method.
+func (m *MockCommandTxContext) SelectContext(ctx context.Context, name, query string, dest any, args ...any) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, name, query, dest}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SelectContext", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SelectContext indicates an expected call of SelectContext.
+func (mr *MockCommandTxContextMockRecorder) SelectContext(ctx, name, query, dest any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, name, query, dest}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectContext", reflect.TypeOf((*MockCommandTxContext)(nil).SelectContext), varargs...)
+}
+
+// Stmt mocks base method.
+func (m *MockCommandTxContext) Stmt(name string, stmt *sqlx.Stmt) sql0.CommandStmt {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stmt", name, stmt)
+	ret0, _ := ret[0].(sql0.CommandStmt)
+	return ret0
+}
+
+// Stmt indicates an expected call of Stmt.
+func (mr *MockCommandTxContextMockRecorder) Stmt(name, stmt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stmt", reflect.TypeOf((*MockCommandTxContext)(nil).Stmt), name, stmt)
+}
+
+// StmtContext mocks base method.
+func (m *MockCommandTxContext) StmtContext(ctx context.Context, name string, stmt *sqlx.Stmt) sql0.CommandStmt {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StmtContext", ctx, name, stmt)
+	ret0, _ := ret[0].(sql0.CommandStmt)
+	return ret0
+}
+
+// StmtContext indicates an expected call of StmtContext.
+func (mr *MockCommandTxContextMockRecorder) StmtContext(ctx, name, stmt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StmtContext", reflect.TypeOf((*MockCommandTxContext)(nil).StmtContext), ctx, name, stmt)
+}